@@ -0,0 +1,258 @@
+// Package alert provides in-process threshold alert rules, evaluated against go-metrics registry.
+//
+// Rule expression compares metrics expressions with optional duration, while condition must be true before firing:
+//
+//	rate(http.server.requests{status=5xx}) / rate(http.server.requests) > 0.05 for 2m
+//	p99(http.server.duration{route=users.get}) > 500ms
+//	value(db.health) < 1 for 30s
+//
+// Functions:
+//
+//	value(NAME{TAGS}) or NAME{TAGS} - sum of matched metrics values (observations count for histograms, status for healthchecks)
+//	rate(NAME{TAGS}) - sum of matched metrics per-second rates (rates of Rate and FRate, values changes between evaluations for other metrics)
+//	p99(NAME{TAGS}), p999(NAME{TAGS}), p100(NAME{TAGS}), ... - maximum of matched non-empty histograms quantiles (in seconds for duration histograms),
+//	  two digits are percentiles (p05, p50, p99), p100 is maximum, longer names starting with 9 are fractions (p995, p999),
+//	  other names (like p5) are rejected
+//	mean(NAME{TAGS}) - matched histograms observations mean (in seconds for duration histograms)
+//
+// Selector tags are matched as subset of metric tags, so all metrics with NAME are matched without tags.
+// Durations (like 500ms) are converted to seconds. Absent metrics and division by zero make rule condition false.
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+var (
+	ErrSyntax   = errors.New("alert rule syntax error")
+	ErrRuleName = errors.New("alert rule name is empty or duplicated")
+	ErrStarted  = runner.ErrStarted
+	ErrInterval = runner.ErrInterval
+)
+
+// State is an alert state
+type State int
+
+const (
+	// StateInactive is a state for rule with false condition
+	StateInactive State = iota
+	// StatePending is a state for rule with true condition, but shorter than rule for duration
+	StatePending
+	// StateFiring is a state for rule with true condition during rule for duration
+	StateFiring
+	// StateResolved is a state for notifications about firing to inactive transitions
+	StateResolved
+)
+
+var stateNames = []string{"inactive", "pending", "firing", "resolved"}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return "unknown"
+	}
+	return stateNames[s]
+}
+
+// MarshalText returns state name (for JSON encoding).
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Rule is an alert rule, declared in code or config.
+type Rule struct {
+	Name string            `toml:"name" yaml:"name" json:"name"` // Alert name
+	Expr string            `toml:"expr" yaml:"expr" json:"expr"` // Rule expression, like rate(http.errors) / rate(http.requests) > 0.05 for 2m
+	Tags map[string]string `toml:"tags" yaml:"tags" json:"tags"` // Tags for notifications (like severity)
+}
+
+// Event is an alert state (or state transition for notifications).
+type Event struct {
+	Rule     string            `json:"rule"`
+	Expr     string            `json:"expr"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	State    State             `json:"state"`
+	Value    float64           `json:"value"`     // Rule expression left side value (NaN for absent metrics, encoded as null)
+	ActiveAt time.Time         `json:"active_at"` // Condition became true (zero for inactive alert)
+	Time     time.Time         `json:"time"`      // Evaluation time
+}
+
+// MarshalJSON encodes event, NaN value is encoded as null.
+func (ev Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	v := struct {
+		event
+		Value *float64 `json:"value"`
+	}{event: event(ev)}
+	if !math.IsNaN(ev.Value) && !math.IsInf(ev.Value, 0) {
+		v.Value = &ev.Value
+	}
+	return json.Marshal(v)
+}
+
+// Options is an alert manager options.
+type Options struct {
+	// Registry with metrics for rules, metrics.DefaultRegistry if nil
+	Registry metrics.Registry
+	// Rules for evaluation
+	Rules []Rule
+	// Notifiers for alerts state transitions (pending, firing and resolved)
+	Notifiers []Notifier
+	// LogError reports notification errors, log.Printf by default
+	LogError func(error)
+}
+
+type ruleState struct {
+	rule     Rule
+	cond     *condition
+	state    State
+	value    float64
+	activeAt time.Time
+	evalAt   time.Time
+}
+
+// Manager evaluates alert rules periodically and sends state transitions to notifiers.
+type Manager struct {
+	r         metrics.Registry
+	rules     []*ruleState
+	notifiers []Notifier
+	logError  func(error)
+
+	lock sync.Mutex // evaluation lock
+
+	runner runner.Runner
+}
+
+func logError(err error) {
+	log.Printf("alert: %v", err)
+}
+
+// New constructs alert manager. Returns ErrSyntax (wrapped) error for invalid rule expression.
+func New(opts Options) (*Manager, error) {
+	if opts.Registry == nil {
+		opts.Registry = metrics.DefaultRegistry
+	}
+	if opts.LogError == nil {
+		opts.LogError = logError
+	}
+	m := &Manager{
+		r:         opts.Registry,
+		rules:     make([]*ruleState, 0, len(opts.Rules)),
+		notifiers: opts.Notifiers,
+		logError:  opts.LogError,
+	}
+	names := make(map[string]bool, len(opts.Rules))
+	for _, rule := range opts.Rules {
+		if rule.Name == "" || names[rule.Name] {
+			return nil, fmt.Errorf("%w: %q", ErrRuleName, rule.Name)
+		}
+		names[rule.Name] = true
+		cond, err := parseExpr(rule.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		m.rules = append(m.rules, &ruleState{rule: rule, cond: cond})
+	}
+	return m, nil
+}
+
+// Eval evaluates all rules and notifies about state transitions.
+// Notifiers are called without evaluation lock, so concurrent Eval calls may deliver events out of order.
+func (m *Manager) Eval(ctx context.Context) {
+	m.lock.Lock()
+	e := newEvalContext(m.r, time.Now())
+	var events []Event
+	for _, rs := range m.rules {
+		if ev, ok := rs.eval(e); ok {
+			events = append(events, ev)
+		}
+	}
+	m.lock.Unlock()
+
+	// slow notifiers don't block Alerts() and next evaluations
+	for _, ev := range events {
+		for _, n := range m.notifiers {
+			if err := n.Notify(ctx, ev); err != nil {
+				m.logError(fmt.Errorf("notify %s %s: %w", ev.Rule, ev.State, err))
+			}
+		}
+	}
+}
+
+// eval evaluates rule and returns event for notification on state transition
+func (rs *ruleState) eval(e *evalContext) (Event, bool) {
+	var active bool
+	rs.value, active = rs.cond.eval(e)
+	rs.evalAt = e.now
+	prev := rs.state
+	if active {
+		if rs.state == StateInactive {
+			rs.activeAt = e.now
+			rs.state = StatePending
+		}
+		if rs.state == StatePending && e.now.Sub(rs.activeAt) >= rs.cond.forDuration {
+			rs.state = StateFiring
+		}
+	} else {
+		rs.state = StateInactive
+	}
+	if rs.state == prev {
+		return Event{}, false
+	}
+	ev := rs.event()
+	if rs.state == StateInactive {
+		if prev != StateFiring {
+			// pending alert is not resolved
+			rs.activeAt = time.Time{}
+			return Event{}, false
+		}
+		ev.State = StateResolved
+		rs.activeAt = time.Time{}
+	}
+	return ev, true
+}
+
+func (rs *ruleState) event() Event {
+	return Event{
+		Rule:     rs.rule.Name,
+		Expr:     rs.rule.Expr,
+		Tags:     rs.rule.Tags,
+		State:    rs.state,
+		Value:    rs.value,
+		ActiveAt: rs.activeAt,
+		Time:     rs.evalAt,
+	}
+}
+
+// Alerts returns the alerts states (from the last evaluation), sorted by rule name.
+func (m *Manager) Alerts() []Event {
+	m.lock.Lock()
+	alerts := make([]Event, 0, len(m.rules))
+	for _, rs := range m.rules {
+		alerts = append(alerts, rs.event())
+	}
+	m.lock.Unlock()
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Rule < alerts[j].Rule })
+	return alerts
+}
+
+// Start evaluates rules immediately and then with interval in background goroutine, until Stop() call or ctx cancellation.
+// Start doesn't wait for the first evaluation. Stopped manager can be started again.
+func (m *Manager) Start(ctx context.Context, interval time.Duration) error {
+	return m.runner.StartContext(ctx, interval, m.Eval)
+}
+
+// Stop stops background evaluations and wait for goroutine exit.
+func (m *Manager) Stop() {
+	m.runner.Stop()
+}
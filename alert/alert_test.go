@@ -0,0 +1,209 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		rules []Rule
+		want  error
+	}{
+		{[]Rule{{Name: "", Expr: "a > 1"}}, ErrRuleName},
+		{[]Rule{{Name: "a", Expr: "a > 1"}, {Name: "a", Expr: "b > 1"}}, ErrRuleName},
+		{[]Rule{{Name: "a", Expr: "a >"}}, ErrSyntax},
+	}
+	for _, tt := range tests {
+		if _, err := New(Options{Registry: metrics.NewRegistry(), Rules: tt.rules}); !errors.Is(err, tt.want) {
+			t.Errorf("New(%v) error = %v, want %v", tt.rules, err, tt.want)
+		}
+	}
+}
+
+func TestRuleTransitions(t *testing.T) {
+	r := metrics.NewRegistry()
+	g := metrics.GetOrRegisterGauge("queue", r)
+	cond, err := parseExpr("queue > 10 for 1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := &ruleState{rule: Rule{Name: "queue"}, cond: cond}
+
+	start := time.Now()
+	tests := []struct {
+		value  int64
+		offset time.Duration
+		state  State
+		event  State // StateInactive for no event
+	}{
+		{5, 0, StateInactive, StateInactive},
+		{20, time.Second, StatePending, StatePending},
+		{20, 30 * time.Second, StatePending, StateInactive},
+		{5, 40 * time.Second, StateInactive, StateInactive}, // pending is not resolved
+		{20, 50 * time.Second, StatePending, StatePending},
+		{20, 110 * time.Second, StateFiring, StateFiring},
+		{30, 120 * time.Second, StateFiring, StateInactive},
+		{5, 130 * time.Second, StateInactive, StateResolved},
+	}
+	for i, tt := range tests {
+		g.Update(tt.value)
+		ev, ok := rs.eval(newEvalContext(r, start.Add(tt.offset)))
+		if rs.state != tt.state {
+			t.Errorf("[%d] state = %s, want %s", i, rs.state, tt.state)
+		}
+		if ok != (tt.event != StateInactive) || ev.State != tt.event {
+			t.Errorf("[%d] event = (%+v, %v), want %s", i, ev, ok, tt.event)
+		}
+		if ok && (ev.ActiveAt.IsZero() || ev.Value != float64(tt.value)) {
+			t.Errorf("[%d] event = %+v", i, ev)
+		}
+	}
+	if !rs.activeAt.IsZero() {
+		t.Errorf("activeAt = %v after resolve", rs.activeAt)
+	}
+}
+
+func TestManager(t *testing.T) {
+	r := metrics.NewRegistry()
+	errs := metrics.GetOrRegisterGauge("errors", r)
+
+	var (
+		lock   sync.Mutex
+		events []Event
+	)
+	notifyErr := errors.New("notify failed")
+	var logged []error
+	m, err := New(Options{
+		Registry: r,
+		Rules: []Rule{
+			{Name: "errors", Expr: "errors > 0", Tags: map[string]string{"severity": "critical"}},
+			{Name: "absent", Expr: "absent > 0"},
+		},
+		Notifiers: []Notifier{
+			NotifierFunc(func(_ context.Context, ev Event) error {
+				lock.Lock()
+				events = append(events, ev)
+				lock.Unlock()
+				return nil
+			}),
+			NotifierFunc(func(context.Context, Event) error { return notifyErr }),
+		},
+		LogError: func(err error) { logged = append(logged, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	m.Eval(ctx)
+	errs.Update(1)
+	m.Eval(ctx)
+	alerts := m.Alerts()
+	if len(alerts) != 2 || alerts[0].Rule != "absent" || alerts[0].State != StateInactive || alerts[1].State != StateFiring {
+		t.Errorf("Alerts() = %+v", alerts)
+	}
+	errs.Update(0)
+	m.Eval(ctx)
+
+	if len(events) != 2 || events[0].State != StateFiring || events[1].State != StateResolved || events[0].Tags["severity"] != "critical" {
+		t.Errorf("events = %+v", events)
+	}
+	if len(logged) != 2 || !errors.Is(logged[0], notifyErr) {
+		t.Errorf("logged errors = %v", logged)
+	}
+
+	if err = m.Start(ctx, 0); err != ErrInterval {
+		t.Errorf("Start() with zero interval = %v, want %v", err, ErrInterval)
+	}
+	if err = m.Start(ctx, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Start(ctx, time.Millisecond); err != ErrStarted {
+		t.Errorf("second Start() = %v, want %v", err, ErrStarted)
+	}
+	m.Stop()
+}
+
+func TestManager_NotifyWithoutLock(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("errors", r).Update(1)
+
+	var m *Manager
+	var alerts []Event
+	m, err := New(Options{
+		Registry: r,
+		Rules:    []Rule{{Name: "errors", Expr: "errors > 0"}},
+		Notifiers: []Notifier{
+			// deadlocks if notifiers are called with evaluation lock
+			NotifierFunc(func(context.Context, Event) error {
+				alerts = m.Alerts()
+				return nil
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Eval(context.Background())
+	if len(alerts) != 1 || alerts[0].State != StateFiring {
+		t.Errorf("Alerts() from notifier = %+v", alerts)
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := LogNotifier(log.New(&buf, "", 0))
+	ev := Event{Rule: "errors", Expr: "errors > 0", Tags: map[string]string{"severity": "critical"}, State: StateFiring, Value: 2}
+	if err := n.Notify(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	want := "alert errors;severity=critical firing: errors > 0 (value 2)\n"
+	if buf.String() != want {
+		t.Errorf("log = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got map[string]interface{}
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("%v: %s", err, body)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w := &Webhook{URL: srv.URL, Timeout: time.Second}
+	ev := Event{Rule: "errors", Expr: "errors > 0", State: StateResolved, Value: 0}
+	if err := w.Notify(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	if got["rule"] != "errors" || got["state"] != "resolved" || got["value"] != 0.0 {
+		t.Errorf("webhook body = %v", got)
+	}
+
+	// NaN value for absent metrics
+	ev.Value = math.NaN()
+	status = http.StatusBadGateway
+	if err := w.Notify(context.Background(), ev); !errors.Is(err, ErrWebhookStatus) {
+		t.Errorf("Notify() error = %v, want %v", err, ErrWebhookStatus)
+	}
+	if v, ok := got["value"]; !ok || v != nil {
+		t.Errorf("webhook value = %v, want null", v)
+	}
+}
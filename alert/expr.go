@@ -0,0 +1,610 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+// series is a registered metric, matched by selector
+type series struct {
+	tags    string
+	tagsMap map[string]string
+	metric  interface{}
+}
+
+// evalContext is a registry metrics, read once per rules evaluation
+type evalContext struct {
+	index map[string][]series // by name
+	now   time.Time
+}
+
+func newEvalContext(r metrics.Registry, now time.Time) *evalContext {
+	e := &evalContext{index: make(map[string][]series), now: now}
+	r.Each(func(name, tags string, tagsMap map[string]string, i interface{}) error {
+		e.index[name] = append(e.index[name], series{tags: tags, tagsMap: tagsMap, metric: i})
+		return nil
+	}, true)
+	return e
+}
+
+// node is an expression node, NaN is returned for absent metrics
+type node interface {
+	eval(e *evalContext) float64
+}
+
+type numberNode float64
+
+func (n numberNode) eval(*evalContext) float64 { return float64(n) }
+
+type negNode struct {
+	n node
+}
+
+func (n negNode) eval(e *evalContext) float64 { return -n.n.eval(e) }
+
+type binaryNode struct {
+	op   byte
+	l, r node
+}
+
+func (n binaryNode) eval(e *evalContext) float64 {
+	l, r := n.l.eval(e), n.r.eval(e)
+	switch n.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	default:
+		if r == 0 {
+			// no requests is not an errors ratio
+			return math.NaN()
+		}
+		return l / r
+	}
+}
+
+// selector matches metrics with name and tags subset (like http.requests{status=5xx})
+type selector struct {
+	name string
+	tags map[string]string
+}
+
+func (s *selector) match(e *evalContext) []series {
+	all := e.index[s.name]
+	if len(s.tags) == 0 {
+		return all
+	}
+	matched := make([]series, 0, len(all))
+LOOP:
+	for _, m := range all {
+		for k, v := range s.tags {
+			if m.tagsMap[k] != v {
+				continue LOOP
+			}
+		}
+		matched = append(matched, m)
+	}
+	return matched
+}
+
+// valueNode is a sum of matched metrics values
+type valueNode struct {
+	sel selector
+}
+
+func (n *valueNode) eval(e *evalContext) float64 {
+	sum := math.NaN()
+	for _, m := range n.sel.match(e) {
+		if v, ok := metricValue(m.metric); ok {
+			if math.IsNaN(sum) {
+				sum = v
+			} else {
+				sum += v
+			}
+		}
+	}
+	return sum
+}
+
+type sample struct {
+	v float64
+	t time.Time
+}
+
+// rateNode is a sum of matched metrics per-second rates (Rate and FRate rates or values changes between evaluations)
+type rateNode struct {
+	sel  selector
+	prev map[string]sample // by tags
+}
+
+func (n *rateNode) eval(e *evalContext) float64 {
+	sum := math.NaN()
+	matched := n.sel.match(e)
+	seen := make(map[string]bool, len(matched))
+	for _, m := range matched {
+		var (
+			rate float64
+			ok   bool
+		)
+		switch r := m.metric.(type) {
+		case metrics.Rate:
+			_, rate = r.Values()
+			ok = true
+		case metrics.FRate:
+			_, rate = r.Values()
+			ok = true
+		default:
+			var v float64
+			if v, ok = metricValue(m.metric); !ok {
+				continue
+			}
+			seen[m.tags] = true
+			prev, exist := n.prev[m.tags]
+			n.prev[m.tags] = sample{v: v, t: e.now}
+			if !exist || !e.now.After(prev.t) {
+				continue
+			}
+			delta := v - prev.v
+			if _, isCounter := m.metric.(metrics.Counter); isCounter && delta < 0 {
+				// counter reset
+				delta = v
+			}
+			rate = delta / e.now.Sub(prev.t).Seconds()
+		}
+		if ok {
+			if math.IsNaN(sum) {
+				sum = rate
+			} else {
+				sum += rate
+			}
+		}
+	}
+	for tags := range n.prev {
+		if !seen[tags] {
+			delete(n.prev, tags)
+		}
+	}
+	return sum
+}
+
+// quantileNode is a maximum of matched histograms q-quantiles (durations histograms quantiles are in seconds)
+type quantileNode struct {
+	sel selector
+	q   float64
+}
+
+func (n *quantileNode) eval(e *evalContext) float64 {
+	max := math.NaN()
+	for _, m := range n.sel.match(e) {
+		var v float64
+		// quantiles of empty histograms are zero, skip them
+		switch h := m.metric.(type) {
+		case metrics.DurationHistogram:
+			s := metrics.SnapshotHistogram(h)
+			if s.Stats().Count == 0 {
+				continue
+			}
+			v = s.Quantile(n.q) * h.Unit().Seconds()
+		case metrics.HistogramInterface:
+			s := metrics.SnapshotHistogram(h)
+			if s.Stats().Count == 0 {
+				continue
+			}
+			v = s.Quantile(n.q)
+		case metrics.HDRHistogram:
+			s := h.Snapshot()
+			if s.Count() == 0 {
+				continue
+			}
+			v = float64(s.Percentile(n.q))
+		case metrics.DDSketch:
+			s := h.Snapshot()
+			if s.Count() == 0 {
+				continue
+			}
+			v = s.Quantile(n.q)
+		default:
+			continue
+		}
+		if math.IsNaN(max) || v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// meanNode is a mean of matched histograms observations (durations histograms means are in seconds)
+type meanNode struct {
+	sel selector
+}
+
+func (n *meanNode) eval(e *evalContext) float64 {
+	var (
+		sum   float64
+		count uint64
+	)
+	for _, m := range n.sel.match(e) {
+		var stats metrics.HistogramStats
+		switch h := m.metric.(type) {
+		case metrics.DurationHistogram:
+			stats = h.Stats()
+			stats.Sum *= h.Unit().Seconds()
+		case metrics.HistogramInterface:
+			stats = h.Stats()
+		case metrics.HDRHistogram:
+			stats = h.Stats()
+		case metrics.DDSketch:
+			stats = h.Stats()
+		default:
+			continue
+		}
+		sum += stats.Sum
+		count += stats.Count
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// metricValue returns metric value (observations count for histograms, status for healthchecks)
+func metricValue(i interface{}) (float64, bool) {
+	switch m := i.(type) {
+	case metrics.Counter:
+		return float64(m.Count()), true
+	case metrics.DownCounter:
+		return float64(m.Count()), true
+	case metrics.Gauge:
+		return float64(m.Value()), true
+	case metrics.UGauge:
+		return float64(m.Value()), true
+	case metrics.FGauge:
+		return m.Value(), true
+	case metrics.Rate:
+		v, _ := m.Values()
+		return float64(v), true
+	case metrics.FRate:
+		v, _ := m.Values()
+		return v, true
+	case metrics.Healthcheck:
+		return float64(m.Status()), true
+	case metrics.HistogramInterface:
+		return float64(m.Stats().Count), true
+	case metrics.HDRHistogram:
+		return float64(m.Count()), true
+	case metrics.DDSketch:
+		return float64(m.Count()), true
+	default:
+		return 0, false
+	}
+}
+
+// condition is a parsed rule expression: L OP R [for DURATION]
+type condition struct {
+	l, r        node
+	op          string
+	forDuration time.Duration
+}
+
+// eval returns left side value and condition result (false if any side is NaN)
+func (c *condition) eval(e *evalContext) (float64, bool) {
+	l, r := c.l.eval(e), c.r.eval(e)
+	if math.IsNaN(l) || math.IsNaN(r) {
+		return l, false
+	}
+	switch c.op {
+	case ">":
+		return l, l > r
+	case ">=":
+		return l, l >= r
+	case "<":
+		return l, l < r
+	case "<=":
+		return l, l <= r
+	case "==":
+		return l, l == r
+	default:
+		return l, l != r
+	}
+}
+
+// parser is a recursive descent rule expression parser:
+//
+//	rule     = expr cmp expr [ "for" duration ]
+//	expr     = term { ("+" | "-") term }
+//	term     = unary { ("*" | "/") unary }
+//	unary    = [ "-" ] primary
+//	primary  = number | duration | selector | func "(" selector ")" | "(" expr ")"
+//	func     = "value" | "rate" | "mean" | "p" digits (like p99 or p999, see parseQuantile)
+//	selector = name [ "{" tag "=" value { "," tag "=" value } "}" ]
+type parser struct {
+	s   string
+	pos int
+}
+
+func parseExpr(s string) (*condition, error) {
+	p := &parser{s: s}
+	l, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	c := &condition{l: l}
+	for _, op := range []string{">=", "<=", "==", "!=", ">", "<"} {
+		if strings.HasPrefix(p.s[p.pos:], op) {
+			c.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if c.op == "" {
+		return nil, p.errorf("comparison operator expected")
+	}
+	if c.r, err = p.expr(); err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		if p.ident() != "for" {
+			return nil, p.errorf("for expected")
+		}
+		p.skipSpaces()
+		start := p.pos
+		v, isDuration, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if !isDuration || v < 0 {
+			p.pos = start
+			return nil, p.errorf("duration expected")
+		}
+		c.forDuration = time.Duration(v * float64(time.Second))
+		p.skipSpaces()
+		if p.pos < len(p.s) {
+			return nil, p.errorf("unexpected %q", p.s[p.pos:])
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at position %d in %q", ErrSyntax, fmt.Sprintf(format, args...), p.pos, p.s)
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+// consume skips spaces and next char, if it's c
+func (p *parser) consume(c byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expr() (node, error) {
+	l, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.s) || (p.s[p.pos] != '+' && p.s[p.pos] != '-') {
+			return l, nil
+		}
+		op := p.s[p.pos]
+		p.pos++
+		r, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *parser) term() (node, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.s) || (p.s[p.pos] != '*' && p.s[p.pos] != '/') {
+			return l, nil
+		}
+		op := p.s[p.pos]
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if p.consume('-') {
+		n, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{n: n}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	p.skipSpaces()
+	if p.pos == len(p.s) {
+		return nil, p.errorf("unexpected end")
+	}
+	c := p.s[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, p.errorf(") expected")
+		}
+		return n, nil
+	case isDigit(c) || c == '.':
+		v, _, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		return numberNode(v), nil
+	case isIdentStart(c):
+		start := p.pos
+		name := p.ident()
+		if !p.consume('(') {
+			sel, err := p.selector(name)
+			if err != nil {
+				return nil, err
+			}
+			return &valueNode{sel: sel}, nil
+		}
+		p.skipSpaces()
+		sel, err := p.selector(p.ident())
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, p.errorf(") expected")
+		}
+		switch {
+		case name == "value":
+			return &valueNode{sel: sel}, nil
+		case name == "rate":
+			return &rateNode{sel: sel, prev: make(map[string]sample)}, nil
+		case name == "mean":
+			return &meanNode{sel: sel}, nil
+		case len(name) > 1 && name[0] == 'p' && strings.Trim(name[1:], "0123456789") == "":
+			q, ok := parseQuantile(name[1:])
+			if !ok {
+				p.pos = start
+				return nil, p.errorf("invalid quantile %s", name)
+			}
+			return &quantileNode{sel: sel, q: q}, nil
+		default:
+			p.pos = start
+			return nil, p.errorf("unknown function %s", name)
+		}
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+// parseQuantile returns quantile for pN function digits: two digits are percentile (p05 is 0.05, p50 is 0.5),
+// p100 is 1 (maximum), three or more digits starting with 9 are quantile fraction digits (p999 is 0.999, p995 is 0.995).
+// Other digits (like p5 or p500) are ambiguous and rejected.
+func parseQuantile(digits string) (float64, bool) {
+	switch {
+	case len(digits) == 2:
+		n, _ := strconv.Atoi(digits)
+		return float64(n) / 100, true
+	case digits == "100":
+		return 1, true
+	case len(digits) > 2 && digits[0] == '9':
+		q, err := strconv.ParseFloat("0."+digits, 64)
+		return q, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// selector parses optional tags after metric name
+func (p *parser) selector(name string) (selector, error) {
+	sel := selector{name: name}
+	if name == "" {
+		return sel, p.errorf("metric name expected")
+	}
+	if !p.consume('{') {
+		return sel, nil
+	}
+	sel.tags = make(map[string]string)
+	for {
+		p.skipSpaces()
+		tag := p.ident()
+		if tag == "" {
+			return sel, p.errorf("tag name expected")
+		}
+		if !p.consume('=') {
+			return sel, p.errorf("= expected")
+		}
+		end := strings.IndexAny(p.s[p.pos:], ",}")
+		if end == -1 {
+			return sel, p.errorf("} expected")
+		}
+		sel.tags[tag] = strings.TrimSpace(p.s[p.pos : p.pos+end])
+		p.pos += end + 1
+		if p.s[p.pos-1] == '}' {
+			return sel, nil
+		}
+	}
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	if p.pos < len(p.s) && isIdentStart(p.s[p.pos]) {
+		p.pos++
+		for p.pos < len(p.s) && (isIdentStart(p.s[p.pos]) || isDigit(p.s[p.pos]) || p.s[p.pos] == '.' || p.s[p.pos] == ':') {
+			p.pos++
+		}
+	}
+	return p.s[start:p.pos]
+}
+
+// number parses number or duration (like 500ms or 1m30s, returned in seconds)
+func (p *parser) number() (float64, bool, error) {
+	start := p.pos
+	for p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+		p.pos++
+	}
+	if p.pos < len(p.s) && (p.s[p.pos] == 'e' || p.s[p.pos] == 'E') &&
+		p.pos+1 < len(p.s) && (isDigit(p.s[p.pos+1]) || p.s[p.pos+1] == '-' || p.s[p.pos+1] == '+') {
+		p.pos += 2
+		for p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+			p.pos++
+		}
+	}
+	if p.pos < len(p.s) && isIdentStart(p.s[p.pos]) {
+		for p.pos < len(p.s) && (isIdentStart(p.s[p.pos]) || isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+			p.pos++
+		}
+		d, err := time.ParseDuration(p.s[start:p.pos])
+		if err != nil {
+			p.pos = start
+			return 0, false, p.errorf("invalid duration")
+		}
+		return d.Seconds(), true, nil
+	}
+	v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return 0, false, p.errorf("invalid number")
+	}
+	return v, false, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
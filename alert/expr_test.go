@@ -0,0 +1,179 @@
+package alert
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+func TestParseExprErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"requests",
+		"requests >",
+		"requests > 1 for",
+		"requests > 1 for 10",
+		"requests > 1 during 1m",
+		"requests > 1 for 1m extra",
+		"avg(requests) > 1",
+		"rate(requests > 1",
+		"rate() > 1",
+		"requests{status} > 1",
+		"requests{status=5xx > 1",
+		"(requests > 1",
+		"requests > 1xs",
+		"requests > 1..2",
+		"p500(duration) < 10ms",
+		"p1000(duration) < 10ms",
+	} {
+		if _, err := parseExpr(expr); !errors.Is(err, ErrSyntax) {
+			t.Errorf("parseExpr(%q) error = %v, want %v", expr, err, ErrSyntax)
+		}
+	}
+}
+
+func TestParseExprEval(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounterT("requests", map[string]string{"status": "2xx", "route": "a"}, r).Add(90)
+	metrics.GetOrRegisterCounterT("requests", map[string]string{"status": "5xx", "route": "a"}, r).Add(6)
+	metrics.GetOrRegisterCounterT("requests", map[string]string{"status": "5xx", "route": "b"}, r).Add(4)
+	metrics.GetOrRegisterGauge("queue", r).Update(-5)
+	metrics.GetOrRegisterFGauge("load", r).Update(1.5)
+	h, err := metrics.GetOrRegisterDurationHistogram("duration", r, []time.Duration{100 * time.Millisecond, time.Second}, nil, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		h.Observe(50 * time.Millisecond)
+	}
+	h.Observe(900 * time.Millisecond)
+	if _, err = metrics.GetOrRegisterDurationHistogram("duration.empty", r, []time.Duration{100 * time.Millisecond, time.Second}, nil, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = metrics.GetOrRegisterDDSketch("sketch.empty", r, 0.01, 0); err != nil {
+		t.Fatal(err)
+	}
+	hc := metrics.NewHealthcheck(func(bool) bool { return false })
+	r.Register("db.health", hc)
+
+	e := newEvalContext(r, time.Now())
+	tests := []struct {
+		expr   string
+		value  float64
+		active bool
+	}{
+		{"requests > 99", 100, true},
+		{"value(requests{status=5xx}) / requests >= 0.1", 0.1, true},
+		{"requests{ status = 5xx , route = b } == 4", 4, true},
+		{"requests{status=4xx} > 0", math.NaN(), false},
+		{"-queue * 2 + 1 != 11", 11, false},
+		{"(load - 0.5) * 2 < 2.1", 2, true},
+		{"1e3 / requests{status=3xx} > 0", math.NaN(), false},
+		{"queue / 0 < 0", math.NaN(), false},
+		{"mean(duration) > 100ms", (10*0.05 + 0.9) / 11, true},
+		{"p99(duration) > 500ms for 1m30s", -1, true},
+		{"p50(duration) < 100ms", -1, true},
+		{"p99(queue) > 0", math.NaN(), false},
+		{"p100(duration) >= 900ms", -1, true},
+		{"p10(duration) < 100ms", -1, true},
+		{"p05(duration) < 100ms", -1, true},
+		{"p995(duration) > 500ms", -1, true},
+		{"p99(duration.empty) < 1", math.NaN(), false},
+		{"p50(sketch.empty) < 1", math.NaN(), false},
+		{"db.health < 1 for 30s", 0, true},
+	}
+	for _, tt := range tests {
+		c, err := parseExpr(tt.expr)
+		if err != nil {
+			t.Errorf("parseExpr(%q) = %v", tt.expr, err)
+			continue
+		}
+		value, active := c.eval(e)
+		if active != tt.active {
+			t.Errorf("%q = %v, want %v (value %g)", tt.expr, active, tt.active, value)
+		}
+		if tt.value == -1 {
+			continue
+		}
+		if math.IsNaN(tt.value) {
+			if !math.IsNaN(value) {
+				t.Errorf("%q value = %g, want NaN", tt.expr, value)
+			}
+		} else if math.Abs(value-tt.value) > 1e-9 {
+			t.Errorf("%q value = %g, want %g", tt.expr, value, tt.value)
+		}
+	}
+
+	c, _ := parseExpr("p99(duration) > 500ms for 1m30s")
+	if c.forDuration != 90*time.Second {
+		t.Errorf("for = %v, want 1m30s", c.forDuration)
+	}
+
+	// quantile functions names
+	for name, want := range map[string]float64{"p05": 0.05, "p50": 0.5, "p99": 0.99, "p100": 1, "p995": 0.995, "p999": 0.999} {
+		c, err := parseExpr(name + "(duration) > 0")
+		if err != nil {
+			t.Errorf("parseExpr(%s) error = %v", name, err)
+			continue
+		}
+		if q := c.l.(*quantileNode).q; q != want {
+			t.Errorf("%s quantile = %v, want %v", name, q, want)
+		}
+	}
+	// ambiguous (p5 is not 0.5)
+	for _, name := range []string{"p5", "p1"} {
+		if _, err := parseExpr(name + "(duration) < 10ms"); !errors.Is(err, ErrSyntax) {
+			t.Errorf("parseExpr(%s) error = %v, want %v", name, err, ErrSyntax)
+		}
+	}
+}
+
+func TestRateEval(t *testing.T) {
+	r := metrics.NewRegistry()
+	requests := metrics.GetOrRegisterCounterT("requests", map[string]string{"status": "2xx"}, r)
+	errs := metrics.GetOrRegisterCounterT("requests", map[string]string{"status": "5xx"}, r)
+	bytes := metrics.GetOrRegisterRate("bytes", r)
+	bytes.UpdateTs(100, 1e9)
+	bytes.UpdateTs(300, 3e9)
+
+	c, err := parseExpr("rate(requests{status=5xx}) / rate(requests) > 0.05")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if v, active := c.eval(newEvalContext(r, now)); active || !math.IsNaN(v) {
+		t.Errorf("first eval = (%g, %v), want (NaN, false)", v, active)
+	}
+
+	requests.Add(180)
+	errs.Add(20)
+	now = now.Add(10 * time.Second)
+	if v, active := c.eval(newEvalContext(r, now)); !active || v != 0.1 {
+		t.Errorf("eval = (%g, %v), want (0.1, true)", v, active)
+	}
+
+	// no requests
+	now = now.Add(10 * time.Second)
+	if v, active := c.eval(newEvalContext(r, now)); active || !math.IsNaN(v) {
+		t.Errorf("eval without requests = (%g, %v), want (NaN, false)", v, active)
+	}
+
+	// counter reset
+	requests.Clear()
+	requests.Add(100)
+	now = now.Add(10 * time.Second)
+	if v, active := c.eval(newEvalContext(r, now)); active || v != 0 {
+		t.Errorf("eval after reset = (%g, %v), want (0, false)", v, active)
+	}
+
+	c, err = parseExpr("rate(bytes) >= 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, active := c.eval(newEvalContext(r, now)); !active || v != 100 {
+		t.Errorf("rate(bytes) = (%g, %v), want (100, true)", v, active)
+	}
+}
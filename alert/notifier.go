@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+var (
+	ErrWebhookStatus = errors.New("webhook unexpected status")
+)
+
+// Notifier receives alerts state transitions (pending, firing and resolved).
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+// NotifierFunc is a callback notifier.
+type NotifierFunc func(ctx context.Context, ev Event) error
+
+// Notify calls f(ctx, ev).
+func (f NotifierFunc) Notify(ctx context.Context, ev Event) error {
+	return f(ctx, ev)
+}
+
+// Logger is a log notifier output (like *log.Logger).
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type logNotifier struct {
+	l Logger
+}
+
+// LogNotifier returns notifier, which writes alerts state transitions to logger l.
+func LogNotifier(l Logger) Notifier {
+	return logNotifier{l: l}
+}
+
+func (n logNotifier) Notify(_ context.Context, ev Event) error {
+	n.l.Printf("alert %s%s %s: %s (value %g)", ev.Rule, metrics.JoinTags(ev.Tags), ev.State, ev.Expr, ev.Value)
+	return nil
+}
+
+// Webhook is a notifier, which sends alerts state transitions (Event in JSON) with POST request to URL (like local HTTP endpoint).
+type Webhook struct {
+	URL     string        `toml:"url" yaml:"url" json:"url"`             // Webhook URL
+	Timeout time.Duration `toml:"timeout" yaml:"timeout" json:"timeout"` // Request timeout, 5s by default
+
+	Client *http.Client `toml:"-" yaml:"-" json:"-"` // HTTP client, http.DefaultClient if nil
+}
+
+// Notify sends event to webhook, returns ErrWebhookStatus (wrapped) error for non-2xx response status.
+func (w *Webhook) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s", ErrWebhookStatus, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// binaryWriter is a helper for snapshots binary encoding.
+type binaryWriter struct {
+	data []byte
+	buf  [binary.MaxVarintLen64]byte
+}
+
+func (w *binaryWriter) uvarint(v uint64) {
+	w.data = append(w.data, w.buf[:binary.PutUvarint(w.buf[:], v)]...)
+}
+
+func (w *binaryWriter) varint(v int64) {
+	w.data = append(w.data, w.buf[:binary.PutVarint(w.buf[:], v)]...)
+}
+
+func (w *binaryWriter) float64(v float64) {
+	binary.LittleEndian.PutUint64(w.buf[:8], math.Float64bits(v))
+	w.data = append(w.data, w.buf[:8]...)
+}
+
+// binaryReader is a helper for snapshots binary decoding, err is set on first decoding failure.
+type binaryReader struct {
+	data       []byte
+	errInvalid error
+	err        error
+}
+
+func (r *binaryReader) fail() {
+	if r.err == nil {
+		r.err = r.errInvalid
+	}
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) float64() float64 {
+	if len(r.data) < 8 {
+		r.fail()
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(r.data))
+	r.data = r.data[8:]
+	return v
+}
@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrBucketsCount    = errors.New("buckets count must be positive")
+	ErrBucketsStart    = errors.New("exponential buckets start must be positive")
+	ErrBucketsFactor   = errors.New("exponential buckets factor must be greater than 1")
+	ErrBucketsWidth    = errors.New("linear buckets width must be positive")
+	ErrBucketsRange    = errors.New("buckets range must be 0 < min < max")
+	ErrBucketsRounding = errors.New("buckets weights are not unique after rounding to integer")
+	ErrBucketsOverflow = errors.New("buckets weights overflow")
+)
+
+// Bucket generators return sorted weights for variable-size histograms (NewVHistogram, NewVSumHistogram, NewVUHistogram, etc.),
+// last inf bucket is appended by histogram constructors.
+
+// ExponentialBuckets returns n weights, where the first is start and each next is previous multiplied by factor.
+func ExponentialBuckets(start, factor float64, n int) ([]float64, error) {
+	if n < 1 {
+		return nil, ErrBucketsCount
+	}
+	if !(start > 0) || math.IsInf(start, 1) {
+		return nil, ErrBucketsStart
+	}
+	if !(factor > 1) || math.IsInf(factor, 1) {
+		return nil, ErrBucketsFactor
+	}
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = start
+		start *= factor
+	}
+	if math.IsInf(w[n-1], 1) {
+		return nil, ErrBucketsOverflow
+	}
+	return w, nil
+}
+
+// LinearBuckets returns n weights, where the first is start and each next is previous plus width.
+func LinearBuckets(start, width float64, n int) ([]float64, error) {
+	if n < 1 {
+		return nil, ErrBucketsCount
+	}
+	if !(width > 0) || math.IsInf(width, 1) {
+		return nil, ErrBucketsWidth
+	}
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = start + float64(i)*width
+	}
+	if math.IsInf(w[n-1], 0) || math.IsNaN(w[n-1]) {
+		return nil, ErrBucketsOverflow
+	}
+	return w, nil
+}
+
+// ExponentialBucketsRange returns n exponential weights from min to max (inclusive).
+func ExponentialBucketsRange(min, max float64, n int) ([]float64, error) {
+	if n < 1 {
+		return nil, ErrBucketsCount
+	}
+	if !(min > 0 && max > min) || math.IsInf(max, 1) {
+		return nil, ErrBucketsRange
+	}
+	if n == 1 {
+		return []float64{min}, nil
+	}
+	factor := math.Pow(max/min, 1/float64(n-1))
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = min * math.Pow(factor, float64(i))
+	}
+	w[n-1] = max
+	return w, nil
+}
+
+// LogLinearBuckets returns HDR-like weights from min to max (inclusive): each power of two range [2^k, 2^(k+1))
+// is divided into steps linear buckets, so relative error is bounded by 1/steps.
+func LogLinearBuckets(min, max float64, steps int) ([]float64, error) {
+	if steps < 1 {
+		return nil, ErrBucketsCount
+	}
+	if !(min > 0 && max > min) || math.IsInf(max, 1) {
+		return nil, ErrBucketsRange
+	}
+	var w []float64
+	for base := math.Exp2(math.Floor(math.Log2(min))); base <= max; base *= 2 {
+		step := base / float64(steps)
+		for i := 0; i < steps; i++ {
+			v := base + float64(i)*step
+			if v > max {
+				break
+			}
+			if v > min {
+				if len(w) == 0 {
+					w = append(w, min)
+				}
+				w = append(w, v)
+			} else if v == min {
+				w = append(w, v)
+			}
+		}
+	}
+	if len(w) == 0 || w[len(w)-1] < max {
+		w = append(w, max)
+	}
+	return w, nil
+}
+
+// ExponentialBucketsInt64 is ExponentialBuckets, rounded to integer weights.
+func ExponentialBucketsInt64(start int64, factor float64, n int) ([]int64, error) {
+	w, err := ExponentialBuckets(float64(start), factor, n)
+	if err != nil {
+		return nil, err
+	}
+	return roundInt64Weights(w, false)
+}
+
+// LinearBucketsInt64 is LinearBuckets for integer weights.
+func LinearBucketsInt64(start, width int64, n int) ([]int64, error) {
+	if n < 1 {
+		return nil, ErrBucketsCount
+	}
+	if width <= 0 {
+		return nil, ErrBucketsWidth
+	}
+	if float64(start)+float64(n-1)*float64(width) >= math.MaxInt64 {
+		return nil, ErrBucketsOverflow
+	}
+	w := make([]int64, n)
+	for i := range w {
+		w[i] = start + int64(i)*width
+	}
+	return w, nil
+}
+
+// ExponentialBucketsRangeInt64 is ExponentialBucketsRange, rounded to integer weights.
+func ExponentialBucketsRangeInt64(min, max int64, n int) ([]int64, error) {
+	w, err := ExponentialBucketsRange(float64(min), float64(max), n)
+	if err != nil {
+		return nil, err
+	}
+	return roundInt64Weights(w, false)
+}
+
+// LogLinearBucketsInt64 is LogLinearBuckets, rounded to integer weights.
+// Duplicated weights after rounding (for small powers of two with many steps) are skipped.
+func LogLinearBucketsInt64(min, max int64, steps int) ([]int64, error) {
+	w, err := LogLinearBuckets(float64(min), float64(max), steps)
+	if err != nil {
+		return nil, err
+	}
+	return roundInt64Weights(w, true)
+}
+
+// ExponentialBucketsUint64 is ExponentialBuckets, rounded to integer weights.
+func ExponentialBucketsUint64(start uint64, factor float64, n int) ([]uint64, error) {
+	w, err := ExponentialBuckets(float64(start), factor, n)
+	if err != nil {
+		return nil, err
+	}
+	return roundUint64Weights(w, false)
+}
+
+// LinearBucketsUint64 is LinearBuckets for unsigned integer weights.
+func LinearBucketsUint64(start, width uint64, n int) ([]uint64, error) {
+	if n < 1 {
+		return nil, ErrBucketsCount
+	}
+	if width == 0 {
+		return nil, ErrBucketsWidth
+	}
+	if float64(start)+float64(n-1)*float64(width) >= math.MaxUint64 {
+		return nil, ErrBucketsOverflow
+	}
+	w := make([]uint64, n)
+	for i := range w {
+		w[i] = start + uint64(i)*width
+	}
+	return w, nil
+}
+
+// ExponentialBucketsRangeUint64 is ExponentialBucketsRange, rounded to integer weights.
+func ExponentialBucketsRangeUint64(min, max uint64, n int) ([]uint64, error) {
+	w, err := ExponentialBucketsRange(float64(min), float64(max), n)
+	if err != nil {
+		return nil, err
+	}
+	return roundUint64Weights(w, false)
+}
+
+// LogLinearBucketsUint64 is LogLinearBuckets, rounded to integer weights.
+// Duplicated weights after rounding (for small powers of two with many steps) are skipped.
+func LogLinearBucketsUint64(min, max uint64, steps int) ([]uint64, error) {
+	w, err := LogLinearBuckets(float64(min), float64(max), steps)
+	if err != nil {
+		return nil, err
+	}
+	return roundUint64Weights(w, true)
+}
+
+func roundInt64Weights(w []float64, skipDup bool) ([]int64, error) {
+	weights := make([]int64, 0, len(w))
+	for _, v := range w {
+		v = math.Round(v)
+		if v >= math.MaxInt64 || v < math.MinInt64 {
+			return nil, ErrBucketsOverflow
+		}
+		n := int64(v)
+		if len(weights) > 0 && n <= weights[len(weights)-1] {
+			if skipDup {
+				continue
+			}
+			return nil, ErrBucketsRounding
+		}
+		weights = append(weights, n)
+	}
+	return weights, nil
+}
+
+func roundUint64Weights(w []float64, skipDup bool) ([]uint64, error) {
+	weights := make([]uint64, 0, len(w))
+	for _, v := range w {
+		v = math.Round(v)
+		if v >= math.MaxUint64 || v < 0 {
+			return nil, ErrBucketsOverflow
+		}
+		n := uint64(v)
+		if len(weights) > 0 && n <= weights[len(weights)-1] {
+			if skipDup {
+				continue
+			}
+			return nil, ErrBucketsRounding
+		}
+		weights = append(weights, n)
+	}
+	return weights, nil
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestBucketsFloat64(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     func() ([]float64, error)
+		want    []float64
+		wantErr error
+	}{
+		{name: "exponential", gen: func() ([]float64, error) { return ExponentialBuckets(1, 2, 5) }, want: []float64{1, 2, 4, 8, 16}},
+		{name: "exponential zero count", gen: func() ([]float64, error) { return ExponentialBuckets(1, 2, 0) }, wantErr: ErrBucketsCount},
+		{name: "exponential zero start", gen: func() ([]float64, error) { return ExponentialBuckets(0, 2, 5) }, wantErr: ErrBucketsStart},
+		{name: "exponential factor", gen: func() ([]float64, error) { return ExponentialBuckets(1, 1, 5) }, wantErr: ErrBucketsFactor},
+		{name: "exponential overflow", gen: func() ([]float64, error) { return ExponentialBuckets(1e300, 1e10, 5) }, wantErr: ErrBucketsOverflow},
+		{name: "linear", gen: func() ([]float64, error) { return LinearBuckets(-1, 0.5, 4) }, want: []float64{-1, -0.5, 0, 0.5}},
+		{name: "linear width", gen: func() ([]float64, error) { return LinearBuckets(1, 0, 4) }, wantErr: ErrBucketsWidth},
+		{name: "exponential range", gen: func() ([]float64, error) { return ExponentialBucketsRange(1, 1000, 4) }, want: []float64{1, 10, 100, 1000}},
+		{name: "exponential range one", gen: func() ([]float64, error) { return ExponentialBucketsRange(1, 1000, 1) }, want: []float64{1}},
+		{name: "exponential range invalid", gen: func() ([]float64, error) { return ExponentialBucketsRange(10, 10, 4) }, wantErr: ErrBucketsRange},
+		{name: "log-linear", gen: func() ([]float64, error) { return LogLinearBuckets(1, 8, 4) }, want: []float64{1, 1.25, 1.5, 1.75, 2, 2.5, 3, 3.5, 4, 5, 6, 7, 8}},
+		{name: "log-linear not aligned", gen: func() ([]float64, error) { return LogLinearBuckets(3, 9, 2) }, want: []float64{3, 4, 6, 8, 9}},
+		{name: "log-linear invalid", gen: func() ([]float64, error) { return LogLinearBuckets(0, 9, 2) }, wantErr: ErrBucketsRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.gen()
+			if err != tt.wantErr {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9*math.Abs(tt.want[i]) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+			if err == nil {
+				if _, err = NewFUHistogram(got, nil); err != nil {
+					t.Errorf("NewFUHistogram() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBucketsInt64(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     func() ([]int64, error)
+		want    []int64
+		wantErr error
+	}{
+		{name: "exponential", gen: func() ([]int64, error) { return ExponentialBucketsInt64(10, 2.5, 4) }, want: []int64{10, 25, 63, 156}},
+		{name: "exponential rounding", gen: func() ([]int64, error) { return ExponentialBucketsInt64(1, 1.1, 4) }, wantErr: ErrBucketsRounding},
+		{name: "exponential overflow", gen: func() ([]int64, error) { return ExponentialBucketsInt64(1, 10, 20) }, wantErr: ErrBucketsOverflow},
+		{name: "linear", gen: func() ([]int64, error) { return LinearBucketsInt64(-10, 5, 4) }, want: []int64{-10, -5, 0, 5}},
+		{name: "linear width", gen: func() ([]int64, error) { return LinearBucketsInt64(0, -5, 4) }, wantErr: ErrBucketsWidth},
+		{name: "linear overflow", gen: func() ([]int64, error) { return LinearBucketsInt64(math.MaxInt64-1, 1, 4) }, wantErr: ErrBucketsOverflow},
+		{name: "exponential range", gen: func() ([]int64, error) { return ExponentialBucketsRangeInt64(1, 1000, 4) }, want: []int64{1, 10, 100, 1000}},
+		{name: "log-linear", gen: func() ([]int64, error) { return LogLinearBucketsInt64(1, 16, 4) }, want: []int64{1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 14, 16}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.gen()
+			if err != tt.wantErr {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if err == nil {
+				if _, err = NewVHistogram(got, nil); err != nil {
+					t.Errorf("NewVHistogram() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBucketsUint64(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     func() ([]uint64, error)
+		want    []uint64
+		wantErr error
+	}{
+		{name: "exponential", gen: func() ([]uint64, error) { return ExponentialBucketsUint64(1, 10, 3) }, want: []uint64{1, 10, 100}},
+		{name: "linear", gen: func() ([]uint64, error) { return LinearBucketsUint64(0, 10, 3) }, want: []uint64{0, 10, 20}},
+		{name: "linear width", gen: func() ([]uint64, error) { return LinearBucketsUint64(0, 0, 3) }, wantErr: ErrBucketsWidth},
+		{name: "exponential range", gen: func() ([]uint64, error) { return ExponentialBucketsRangeUint64(2, 32, 5) }, want: []uint64{2, 4, 8, 16, 32}},
+		{name: "exponential range invalid", gen: func() ([]uint64, error) { return ExponentialBucketsRangeUint64(0, 32, 5) }, wantErr: ErrBucketsRange},
+		{name: "log-linear", gen: func() ([]uint64, error) { return LogLinearBucketsUint64(100, 200, 2) }, want: []uint64{100, 128, 192, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.gen()
+			if err != tt.wantErr {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if err == nil {
+				if _, err = NewVUHistogram(got, nil); err != nil {
+					t.Errorf("NewVUHistogram() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+var (
+	ErrBuildInfoNotAvailable = errors.New("build info is not available (binary is not built with module support)")
+
+	// BuildInfoName is a build info gauge name
+	BuildInfoName = "build_info"
+)
+
+// RegisterBuildInfo registers constant gauge (with value 1) BuildInfoName, tagged with build information (see RegisterBuildInfoT).
+func RegisterBuildInfo(r Registry) (Gauge, error) {
+	return RegisterBuildInfoT(r, nil)
+}
+
+// RegisterBuildInfoT registers constant gauge (with value 1) BuildInfoName, tagged with build information from debug.ReadBuildInfo():
+//
+//	go_version - Go version
+//	path - main module path
+//	version - main module version
+//	vcs, vcs_revision, vcs_time, vcs_modified - VCS information (if stamped with Go 1.18+)
+//
+// Versions of selected dependencies modules are tagged with module path (like github.com/msaf1980/go-metrics=v0.1.0).
+// Extra application tags (like env or dc) are merged with build info tags, build info tags are preferred on conflict.
+// Tags names and values (including application tags) are sanitized for safe pass through JoinTags (; and spaces are replaced with _).
+func RegisterBuildInfoT(r Registry, tags map[string]string, deps ...string) (Gauge, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, ErrBuildInfoNotAvailable
+	}
+	appTags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		appTags[buildInfoTag(k)] = buildInfoTag(v)
+	}
+	g := NewFunctionalGauge(func() int64 { return 1 })
+	if err := r.RegisterT(BuildInfoName, MergeTags(buildInfoTags(bi, deps), appTags), g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func buildInfoTags(bi *debug.BuildInfo, deps []string) map[string]string {
+	tags := map[string]string{
+		"go_version": buildInfoTag(runtime.Version()),
+		"path":       buildInfoTag(bi.Main.Path),
+		"version":    buildInfoTag(bi.Main.Version),
+	}
+	buildInfoSettings(bi, tags)
+	for _, dep := range deps {
+		for _, m := range bi.Deps {
+			if m.Path != dep {
+				continue
+			}
+			version := m.Version
+			if m.Replace != nil && m.Replace.Version != "" {
+				version = m.Replace.Version
+			}
+			tags[buildInfoTag(dep)] = buildInfoTag(version)
+			break
+		}
+	}
+	return tags
+}
+
+var buildInfoReplacer = strings.NewReplacer(";", "_", " ", "_", "\t", "_", "\n", "_", "=", "_", "!", "_", "^", "_")
+
+// buildInfoTag sanitizes tag name or value for Graphite tags (unknown if empty)
+func buildInfoTag(s string) string {
+	s = strings.TrimLeft(buildInfoReplacer.Replace(s), "~")
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,9 @@
+//go:build !go1.18
+// +build !go1.18
+
+package metrics
+
+import "runtime/debug"
+
+// buildInfoSettings is a no-op, VCS information is stamped since Go 1.18
+func buildInfoSettings(bi *debug.BuildInfo, tags map[string]string) {}
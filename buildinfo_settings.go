@@ -0,0 +1,19 @@
+//go:build go1.18
+// +build go1.18
+
+package metrics
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// buildInfoSettings adds VCS information tags (vcs, vcs_revision, vcs_time, vcs_modified)
+func buildInfoSettings(bi *debug.BuildInfo, tags map[string]string) {
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs", "vcs.revision", "vcs.time", "vcs.modified":
+			tags[strings.Replace(s.Key, ".", "_", 1)] = buildInfoTag(s.Value)
+		}
+	}
+}
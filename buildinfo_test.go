@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestBuildInfoTags(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/msaf1980/go-metrics", Version: "v0.1.0"},
+			{Path: "github.com/stretchr/testify", Version: "v1.7.0", Replace: &debug.Module{Path: "../testify", Version: "v1.7.1"}},
+			{Path: "example.com/other", Version: "v1.0.0"},
+		},
+	}
+	want := map[string]string{
+		"go_version":                     runtime.Version(),
+		"path":                           "example.com/app",
+		"version":                        "(devel)",
+		"github.com/msaf1980/go-metrics": "v0.1.0",
+		"github.com/stretchr/testify":    "v1.7.1",
+	}
+	got := buildInfoTags(bi, []string{"github.com/msaf1980/go-metrics", "github.com/stretchr/testify", "example.com/missing"})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildInfoTags() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildInfoTag(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"v1.2.3", "v1.2.3"},
+		{"", "unknown"},
+		{"go1.18 X:boringcrypto", "go1.18_X:boringcrypto"},
+		{"~a;b=c", "a_b_c"},
+	}
+	for _, tt := range tests {
+		if got := buildInfoTag(tt.in); got != tt.want {
+			t.Errorf("buildInfoTag(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterBuildInfo(t *testing.T) {
+	r := NewRegistry()
+	appTags := map[string]string{"env": "test", "path": "overwritten", "dc name": "dc;1 a=b"}
+	g, err := RegisterBuildInfoT(r, appTags)
+	if err == ErrBuildInfoNotAvailable {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if g.Value() != 1 {
+		t.Errorf("%s = %d, want 1", BuildInfoName, g.Value())
+	}
+
+	var tags map[string]string
+	r.Each(func(name, tagsStr string, tagsMap map[string]string, i interface{}) error {
+		if name == BuildInfoName {
+			tags = tagsMap
+			if strings.Count(tagsStr, ";") != len(tagsMap) {
+				t.Errorf("%s tags %q are not safe for JoinTags", BuildInfoName, tagsStr)
+			}
+		}
+		return nil
+	}, false)
+	if tags["go_version"] != runtime.Version() {
+		t.Errorf("go_version tag = %q, want %q", tags["go_version"], runtime.Version())
+	}
+	if tags["env"] != "test" {
+		t.Errorf("env tag = %q, want %q", tags["env"], "test")
+	}
+	if tags["dc_name"] != "dc_1_a_b" {
+		t.Errorf("dc_name tag = %q, want %q", tags["dc_name"], "dc_1_a_b")
+	}
+	if tags["path"] == "overwritten" {
+		t.Errorf("path tag is overwritten by application tags")
+	}
+
+	if _, err = RegisterBuildInfoT(r, appTags); err == nil {
+		t.Error("RegisterBuildInfoT() with duplicate metrics must fail")
+	}
+}
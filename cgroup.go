@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+var (
+	ErrCgroupNotSupported = errors.New("cgroup metrics are not supported on this platform")
+	ErrCgroupNotFound     = errors.New("cgroup v1 or v2 hierarchy is not found")
+	ErrCgroupFormat       = errors.New("invalid cgroup statistics format")
+
+	CgroupNames struct {
+		MemoryUsage      string
+		MemoryLimit      string
+		OOMKills         string
+		CPUUsage         string
+		CPUQuota         string
+		CPUPeriod        string
+		CPUPeriods       string
+		CPUThrottled     string
+		CPUThrottledTime string
+		PidsCurrent      string
+		PidsMax          string
+	}
+)
+
+func init() {
+	CgroupNames.MemoryUsage = "cgroup.memory.usage_bytes"
+	CgroupNames.MemoryLimit = "cgroup.memory.limit_bytes"
+	CgroupNames.OOMKills = "cgroup.memory.oom_kills"
+	CgroupNames.CPUUsage = "cgroup.cpu.usage_seconds"
+	CgroupNames.CPUQuota = "cgroup.cpu.quota_us"
+	CgroupNames.CPUPeriod = "cgroup.cpu.period_us"
+	CgroupNames.CPUPeriods = "cgroup.cpu.periods"
+	CgroupNames.CPUThrottled = "cgroup.cpu.throttled_periods"
+	CgroupNames.CPUThrottledTime = "cgroup.cpu.throttled_seconds"
+	CgroupNames.PidsCurrent = "cgroup.pids.current"
+	CgroupNames.PidsMax = "cgroup.pids.max"
+}
+
+// CgroupCollectorOptions is a CgroupCollector naming options.
+type CgroupCollectorOptions struct {
+	// Prefix replaces cgroup prefix in default metric names (like cgroup.memory.usage_bytes)
+	Prefix string
+	// Tags for tagged metrics registration (not tagged if empty)
+	Tags map[string]string
+	// Root is a cgroup filesystem mount point, /sys/fs/cgroup by default.
+	// In containers it's a container cgroup (with cgroup namespace or container runtime mounts).
+	Root string
+}
+
+// CgroupCollector captures cgroup v1 or v2 (detected on construction) resource usage and limits into metrics, registered in one registry.
+//
+// Limits are -1 if unlimited. Controllers files, not available in cgroup (like pids.max in root cgroup), are skipped.
+type CgroupCollector struct {
+	r       Registry
+	tags    map[string]string
+	metrics []collectorMetric // registered metrics
+
+	MemoryUsage      Gauge
+	MemoryLimit      Gauge
+	OOMKills         Rate
+	CPUUsage         FRate
+	CPUQuota         Gauge // -1 if unlimited
+	CPUPeriod        Gauge
+	CPUPeriods       Rate // elapsed enforcement periods
+	CPUThrottled     Rate // throttled periods
+	CPUThrottledTime FRate
+	PidsCurrent      Gauge
+	PidsMax          Gauge
+
+	root    string
+	version int // cgroup version, 1 or 2
+	sources []cgroupSource
+	lock    sync.Mutex
+	runner  runner.Runner
+}
+
+// NewCgroupCollector detects cgroup version, constructs and registers cgroup metrics in registry.
+// Returns ErrCgroupNotFound if cgroup hierarchy is not found
+// or DuplicateMetric error (and unregister already registered metrics) if metric with the same name exists.
+func NewCgroupCollector(r Registry, opts CgroupCollectorOptions) (*CgroupCollector, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	c := &CgroupCollector{
+		r:                r,
+		tags:             opts.Tags,
+		MemoryUsage:      NewGauge(),
+		MemoryLimit:      NewGauge(),
+		OOMKills:         NewRate(),
+		CPUUsage:         NewFRate(),
+		CPUQuota:         NewGauge(),
+		CPUPeriod:        NewGauge(),
+		CPUPeriods:       NewRate(),
+		CPUThrottled:     NewRate(),
+		CPUThrottledTime: NewFRate(),
+		PidsCurrent:      NewGauge(),
+		PidsMax:          NewGauge(),
+		root:             opts.Root,
+	}
+	if c.root == "" {
+		c.root = "/sys/fs/cgroup"
+	}
+	var err error
+	if c.version, err = c.detect(); err != nil {
+		return nil, err
+	}
+	for _, m := range c.list() {
+		if opts.Prefix != "" {
+			m.name = opts.Prefix + strings.TrimPrefix(m.name, "cgroup")
+		}
+		c.metrics = append(c.metrics, m)
+	}
+	if err := registerCollectorMetrics(r, c.metrics, c.tags); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// list returns metrics with default names (from CgroupNames)
+func (c *CgroupCollector) list() []collectorMetric {
+	return []collectorMetric{
+		{CgroupNames.MemoryUsage, c.MemoryUsage},
+		{CgroupNames.MemoryLimit, c.MemoryLimit},
+		{CgroupNames.OOMKills, c.OOMKills},
+		{CgroupNames.CPUUsage, c.CPUUsage},
+		{CgroupNames.CPUQuota, c.CPUQuota},
+		{CgroupNames.CPUPeriod, c.CPUPeriod},
+		{CgroupNames.CPUPeriods, c.CPUPeriods},
+		{CgroupNames.CPUThrottled, c.CPUThrottled},
+		{CgroupNames.CPUThrottledTime, c.CPUThrottledTime},
+		{CgroupNames.PidsCurrent, c.PidsCurrent},
+		{CgroupNames.PidsMax, c.PidsMax},
+	}
+}
+
+// Version returns detected cgroup version (1 or 2).
+func (c *CgroupCollector) Version() int {
+	return c.version
+}
+
+// Capture new values for the cgroup statistics.
+// Missing controllers files are skipped, first error is returned.
+func (c *CgroupCollector) Capture() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.capture(time.Now().UnixNano())
+}
+
+// Start captures the cgroup statistics with interval in background goroutine, until Stop() call or ctx cancellation.
+// Stopped collector can be started again.
+func (c *CgroupCollector) Start(ctx context.Context, interval time.Duration) error {
+	return c.runner.Start(ctx, interval, func() { _ = c.Capture() })
+}
+
+// Stop stops background captures and wait for goroutine exit.
+func (c *CgroupCollector) Stop() {
+	c.runner.Stop()
+}
+
+// Unregister stops collector and unregisters it's metrics.
+func (c *CgroupCollector) Unregister() {
+	c.Stop()
+	unregisterCollectorMetrics(c.r, c.metrics, c.tags)
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroupV1Unlimited is a minimal cgroup v1 unlimited memory limit (PAGE_COUNTER_MAX multiplied to page size)
+const cgroupV1Unlimited = 1 << 62
+
+// errCgroupKeyNotFound is returned by cgroupValue for missing key in flat keyed file
+var errCgroupKeyNotFound = errors.New("cgroup statistics key not found")
+
+// cgroupSource is a value, read from cgroup file
+type cgroupSource struct {
+	file     string
+	key      string // key in flat keyed file (like cpu.stat), if empty, read field from single line file
+	optional bool   // key may be missing (not supported by kernel), metric is not updated
+	field    int    // field in single line file (like cpu.max)
+	update   func(v, t int64)
+}
+
+// detect returns cgroup version and init sources
+func (c *CgroupCollector) detect() (int, error) {
+	if _, err := os.Stat(filepath.Join(c.root, "cgroup.controllers")); err == nil {
+		c.sources = c.sourcesV2()
+		return 2, nil
+	}
+	for _, controller := range []string{"memory", "cpu", "pids"} {
+		if fi, err := os.Stat(filepath.Join(c.root, controller)); err == nil && fi.IsDir() {
+			c.sources = c.sourcesV1()
+			return 1, nil
+		}
+	}
+	return 0, ErrCgroupNotFound
+}
+
+func (c *CgroupCollector) sourcesV2() []cgroupSource {
+	return []cgroupSource{
+		{file: "memory.current", update: func(v, _ int64) { c.MemoryUsage.Update(v) }},
+		{file: "memory.max", update: func(v, _ int64) { c.MemoryLimit.Update(v) }},
+		{file: "memory.events", key: "oom_kill", optional: true, update: c.OOMKills.UpdateTs},
+		{file: "cpu.stat", key: "usage_usec", update: func(v, t int64) { c.CPUUsage.UpdateTs(float64(v)/1e6, t) }},
+		{file: "cpu.stat", key: "nr_periods", update: c.CPUPeriods.UpdateTs},
+		{file: "cpu.stat", key: "nr_throttled", update: c.CPUThrottled.UpdateTs},
+		{file: "cpu.stat", key: "throttled_usec", update: func(v, t int64) { c.CPUThrottledTime.UpdateTs(float64(v)/1e6, t) }},
+		{file: "cpu.max", field: 0, update: func(v, _ int64) { c.CPUQuota.Update(v) }},
+		{file: "cpu.max", field: 1, update: func(v, _ int64) { c.CPUPeriod.Update(v) }},
+		{file: "pids.current", update: func(v, _ int64) { c.PidsCurrent.Update(v) }},
+		{file: "pids.max", update: func(v, _ int64) { c.PidsMax.Update(v) }},
+	}
+}
+
+func (c *CgroupCollector) sourcesV1() []cgroupSource {
+	return []cgroupSource{
+		{file: "memory/memory.usage_in_bytes", update: func(v, _ int64) { c.MemoryUsage.Update(v) }},
+		{file: "memory/memory.limit_in_bytes", update: func(v, _ int64) {
+			if v >= cgroupV1Unlimited {
+				v = -1
+			}
+			c.MemoryLimit.Update(v)
+		}},
+		{file: "memory/memory.oom_control", key: "oom_kill", optional: true, update: c.OOMKills.UpdateTs},
+		{file: "cpuacct/cpuacct.usage", update: func(v, t int64) { c.CPUUsage.UpdateTs(float64(v)/1e9, t) }},
+		{file: "cpu/cpu.stat", key: "nr_periods", update: c.CPUPeriods.UpdateTs},
+		{file: "cpu/cpu.stat", key: "nr_throttled", update: c.CPUThrottled.UpdateTs},
+		{file: "cpu/cpu.stat", key: "throttled_time", update: func(v, t int64) { c.CPUThrottledTime.UpdateTs(float64(v)/1e9, t) }},
+		{file: "cpu/cpu.cfs_quota_us", update: func(v, _ int64) { c.CPUQuota.Update(v) }},
+		{file: "cpu/cpu.cfs_period_us", update: func(v, _ int64) { c.CPUPeriod.Update(v) }},
+		{file: "pids/pids.current", update: func(v, _ int64) { c.PidsCurrent.Update(v) }},
+		{file: "pids/pids.max", update: func(v, _ int64) { c.PidsMax.Update(v) }},
+	}
+}
+
+func (c *CgroupCollector) capture(t int64) error {
+	var firstErr error
+	files := make(map[string][]byte)
+	for _, s := range c.sources {
+		data, ok := files[s.file]
+		if !ok {
+			var err error
+			if data, err = os.ReadFile(filepath.Join(c.root, s.file)); err != nil {
+				if !os.IsNotExist(err) && firstErr == nil {
+					firstErr = err
+				}
+				data = nil
+			}
+			files[s.file] = data
+		}
+		if data == nil {
+			continue
+		}
+		v, err := cgroupValue(data, s.key, s.field)
+		if err == errCgroupKeyNotFound {
+			if s.optional {
+				// metric unavailable (like oom_kill in memory.oom_control before linux 4.13)
+				continue
+			}
+			err = ErrCgroupFormat
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		s.update(v, t)
+	}
+	return firstErr
+}
+
+// cgroupValue returns value for key from flat keyed file or field from single line file, max is returned as -1.
+// errCgroupKeyNotFound is returned for missing key.
+func cgroupValue(data []byte, key string, field int) (int64, error) {
+	if key == "" {
+		fields := strings.Fields(string(data))
+		if len(fields) <= field {
+			return 0, ErrCgroupFormat
+		}
+		return parseCgroupValue(fields[field])
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			return parseCgroupValue(fields[1])
+		}
+	}
+	return 0, errCgroupKeyNotFound
+}
+
+func parseCgroupValue(s string) (int64, error) {
+	if s == "max" {
+		return -1, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, ErrCgroupFormat
+	}
+	return v, nil
+}
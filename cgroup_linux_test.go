@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCgroupCollector_V2(t *testing.T) {
+	dir := t.TempDir()
+	writeProcFiles(t, dir, map[string]string{
+		"cgroup.controllers": "cpuset cpu io memory pids\n",
+		"memory.current":     "104857600\n",
+		"memory.max":         "268435456\n",
+		"memory.events":      "low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\noom_group_kill 0\n",
+		"cpu.stat": "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n" +
+			"nr_periods 100\nnr_throttled 10\nthrottled_usec 500000\n",
+		"cpu.max":      "50000 100000\n",
+		"pids.current": "12\n",
+		"pids.max":     "max\n",
+	})
+
+	r := NewRegistry()
+	tags := map[string]string{"pod": "a"}
+	c, err := NewCgroupCollector(r, CgroupCollectorOptions{Prefix: "app.cgroup", Tags: tags, Root: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != 2 {
+		t.Errorf("Version() = %d, want 2", c.Version())
+	}
+	if err = c.capture(1e9); err != nil {
+		t.Fatal(err)
+	}
+
+	gauges := map[string]int64{
+		"app.cgroup.memory.usage_bytes": 104857600,
+		"app.cgroup.memory.limit_bytes": 268435456,
+		"app.cgroup.cpu.quota_us":       50000,
+		"app.cgroup.cpu.period_us":      100000,
+		"app.cgroup.pids.current":       12,
+		"app.cgroup.pids.max":           -1,
+	}
+	for name, want := range gauges {
+		if got := r.GetT(name, tags).(Gauge).Value(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	rates := map[string]int64{
+		"app.cgroup.memory.oom_kills":      2,
+		"app.cgroup.cpu.periods":           100,
+		"app.cgroup.cpu.throttled_periods": 10,
+	}
+	for name, want := range rates {
+		if got, _ := r.GetT(name, tags).(Rate).Values(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	fRates := map[string]float64{
+		"app.cgroup.cpu.usage_seconds":     2.5,
+		"app.cgroup.cpu.throttled_seconds": 0.5,
+	}
+	for name, want := range fRates {
+		if got, _ := r.GetT(name, tags).(FRate).Values(); got != want {
+			t.Errorf("%s = %f, want %f", name, got, want)
+		}
+	}
+
+	// rates
+	writeProcFiles(t, dir, map[string]string{
+		"cpu.stat": "usage_usec 4500000\nnr_periods 120\nnr_throttled 20\nthrottled_usec 1500000\n",
+		"cpu.max":  "max 100000\n",
+	})
+	if err = c.capture(3e9); err != nil {
+		t.Fatal(err)
+	}
+	if _, rate := c.CPUUsage.Values(); rate != 1 {
+		t.Errorf("%s rate = %f, want 1", CgroupNames.CPUUsage, rate)
+	}
+	if _, rate := c.CPUThrottled.Values(); rate != 5 {
+		t.Errorf("%s rate = %f, want 5", CgroupNames.CPUThrottled, rate)
+	}
+	if v := c.CPUQuota.Value(); v != -1 {
+		t.Errorf("%s = %d, want -1", CgroupNames.CPUQuota, v)
+	}
+
+	// missing controllers files are skipped
+	for _, name := range []string{"pids.current", "pids.max"} {
+		if err = os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = c.capture(4e9); err != nil {
+		t.Errorf("capture() error = %v", err)
+	}
+	if v := c.PidsCurrent.Value(); v != 12 {
+		t.Errorf("%s = %d, want 12", CgroupNames.PidsCurrent, v)
+	}
+
+	// invalid format
+	writeProcFiles(t, dir, map[string]string{"memory.current": "unknown\n"})
+	if err = c.capture(5e9); err != ErrCgroupFormat {
+		t.Errorf("capture() error = %v, want %v", err, ErrCgroupFormat)
+	}
+
+	c.Unregister()
+	if m := r.GetT("app.cgroup.memory.usage_bytes", tags); m != nil {
+		t.Errorf("app.cgroup.memory.usage_bytes is registered after Unregister()")
+	}
+}
+
+func TestCgroupCollector_V1(t *testing.T) {
+	dir := t.TempDir()
+	writeProcFiles(t, dir, map[string]string{
+		"memory/memory.usage_in_bytes": "104857600\n",
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		"memory/memory.oom_control":    "oom_kill_disable 0\nunder_oom 0\noom_kill 1\n",
+		"cpuacct/cpuacct.usage":        "2500000000\n",
+		"cpu/cpu.stat":                 "nr_periods 100\nnr_throttled 10\nthrottled_time 500000000\n",
+		"cpu/cpu.cfs_quota_us":         "-1\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"pids/pids.current":            "12\n",
+		"pids/pids.max":                "1024\n",
+	})
+
+	r := NewRegistry()
+	c, err := NewCgroupCollector(r, CgroupCollectorOptions{Root: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version() != 1 {
+		t.Errorf("Version() = %d, want 1", c.Version())
+	}
+	if err = c.capture(1e9); err != nil {
+		t.Fatal(err)
+	}
+
+	gauges := map[string]int64{
+		CgroupNames.MemoryUsage: 104857600,
+		CgroupNames.MemoryLimit: -1,
+		CgroupNames.CPUQuota:    -1,
+		CgroupNames.CPUPeriod:   100000,
+		CgroupNames.PidsCurrent: 12,
+		CgroupNames.PidsMax:     1024,
+	}
+	for name, want := range gauges {
+		if got := r.Get(name).(Gauge).Value(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	rates := map[string]int64{
+		CgroupNames.OOMKills:     1,
+		CgroupNames.CPUPeriods:   100,
+		CgroupNames.CPUThrottled: 10,
+	}
+	for name, want := range rates {
+		if got, _ := r.Get(name).(Rate).Values(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	fRates := map[string]float64{
+		CgroupNames.CPUUsage:         2.5,
+		CgroupNames.CPUThrottledTime: 0.5,
+	}
+	for name, want := range fRates {
+		if got, _ := r.Get(name).(FRate).Values(); got != want {
+			t.Errorf("%s = %f, want %f", name, got, want)
+		}
+	}
+
+	// duplicate metrics in the same registry
+	if _, err = NewCgroupCollector(r, CgroupCollectorOptions{Root: dir}); err == nil {
+		t.Error("NewCgroupCollector() with duplicate metrics must fail")
+	}
+
+	// old kernels without oom_kill in memory.oom_control
+	writeProcFiles(t, dir, map[string]string{
+		"memory/memory.oom_control": "oom_kill_disable 0\nunder_oom 0\n",
+		"cpu/cpu.stat":              "nr_periods 200\nnr_throttled 20\nthrottled_time 500000000\n",
+	})
+	if err = c.capture(2e9); err != nil {
+		t.Errorf("capture() without oom_kill error = %v", err)
+	}
+	if got, _ := r.Get(CgroupNames.OOMKills).(Rate).Values(); got != 1 {
+		t.Errorf("%s = %d without oom_kill, want 1", CgroupNames.OOMKills, got)
+	}
+	if got, _ := r.Get(CgroupNames.CPUPeriods).(Rate).Values(); got != 200 {
+		t.Errorf("%s = %d, want 200", CgroupNames.CPUPeriods, got)
+	}
+
+	// required keys
+	writeProcFiles(t, dir, map[string]string{"cpu/cpu.stat": "nr_periods 300\n"})
+	if err = c.capture(3e9); err != ErrCgroupFormat {
+		t.Errorf("capture() without nr_throttled error = %v, want %v", err, ErrCgroupFormat)
+	}
+}
+
+func TestCgroupCollector_NotFound(t *testing.T) {
+	if _, err := NewCgroupCollector(NewRegistry(), CgroupCollectorOptions{Root: t.TempDir()}); err != ErrCgroupNotFound {
+		t.Errorf("NewCgroupCollector() error = %v, want %v", err, ErrCgroupNotFound)
+	}
+}
+
+func TestCgroupCollector(t *testing.T) {
+	r := NewRegistry()
+	c, err := NewCgroupCollector(r, CgroupCollectorOptions{})
+	if err == ErrCgroupNotFound {
+		t.Skip("cgroup filesystem is not mounted")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Capture(); err != nil {
+		t.Errorf("Capture() error = %v", err)
+	}
+	c.Unregister()
+}
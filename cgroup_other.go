@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package metrics
+
+// cgroupSource is a value, read from cgroup file
+type cgroupSource struct{}
+
+func (c *CgroupCollector) detect() (int, error) {
+	return 0, ErrCgroupNotSupported
+}
+
+func (c *CgroupCollector) capture(t int64) error {
+	return ErrCgroupNotSupported
+}
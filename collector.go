@@ -0,0 +1,41 @@
+package metrics
+
+import "github.com/msaf1980/go-metrics/internal/runner"
+
+var (
+	ErrCollectorStarted  = runner.ErrStarted
+	ErrCollectorInterval = runner.ErrInterval
+)
+
+// collectorMetric is a metric with name for collectors registration
+type collectorMetric struct {
+	name   string
+	metric interface{}
+}
+
+// registerCollectorMetrics registers metrics, already registered metrics are unregistered on error.
+func registerCollectorMetrics(r Registry, metrics []collectorMetric, tags map[string]string) error {
+	for i, m := range metrics {
+		var err error
+		if len(tags) == 0 {
+			err = r.Register(m.name, m.metric)
+		} else {
+			err = r.RegisterT(m.name, tags, m.metric)
+		}
+		if err != nil {
+			unregisterCollectorMetrics(r, metrics[:i], tags)
+			return err
+		}
+	}
+	return nil
+}
+
+func unregisterCollectorMetrics(r Registry, metrics []collectorMetric, tags map[string]string) {
+	for _, m := range metrics {
+		if len(tags) == 0 {
+			r.Unregister(m.name)
+		} else {
+			r.UnregisterT(m.name, tags)
+		}
+	}
+}
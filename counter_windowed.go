@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// GetOrRegisterWindowedCounter returns an existing Counter or constructs and registers
+// a new WindowedCounter.
+func GetOrRegisterWindowedCounter(name string, r Registry, window time.Duration, slices int) (Counter, error) {
+	if err := checkWindow(window, slices); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		c, _ := NewWindowedCounter(window, slices)
+		return c
+	}).(Counter), nil
+}
+
+// GetOrRegisterWindowedCounterT returns an existing Counter or constructs and registers
+// a new WindowedCounter.
+func GetOrRegisterWindowedCounterT(name string, tagsMap map[string]string, r Registry, window time.Duration, slices int) (Counter, error) {
+	if err := checkWindow(window, slices); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		c, _ := NewWindowedCounter(window, slices)
+		return c
+	}).(Counter), nil
+}
+
+// NewWindowedCounter constructs a new WindowedCounter.
+func NewWindowedCounter(window time.Duration, slices int) (Counter, error) {
+	if UseNilMetrics {
+		return NilCounter{}, nil
+	}
+	w, err := newWindow(window, slices)
+	if err != nil {
+		return nil, err
+	}
+	return &WindowedCounter{
+		window: w,
+		counts: make([]uint64, len(w.epochs)),
+	}, nil
+}
+
+// NewRegisteredWindowedCounter constructs and registers a new WindowedCounter.
+func NewRegisteredWindowedCounter(name string, r Registry, window time.Duration, slices int) (Counter, error) {
+	c, err := NewWindowedCounter(window, slices)
+	if err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c, nil
+}
+
+// NewRegisteredWindowedCounterT constructs and registers a new WindowedCounter.
+func NewRegisteredWindowedCounterT(name string, tagsMap map[string]string, r Registry, window time.Duration, slices int) (Counter, error) {
+	c, err := NewWindowedCounter(window, slices)
+	if err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.RegisterT(name, tagsMap, c)
+	return c, nil
+}
+
+// WindowedCounter is a Counter over sliding time window.
+// Increments are stored in a ring of window/slices sub-intervals, rotated by wall clock,
+// so Count returns the sum over the last window (with slice granularity) and don't need destructive Clear.
+type WindowedCounter struct {
+	window
+	counts []uint64
+	lock   sync.Mutex
+}
+
+// Clear sets the Counter to zero and returns the count over the last window.
+func (c *WindowedCounter) Clear() uint64 {
+	c.lock.Lock()
+	count := c.count(c.now())
+	for i := range c.counts {
+		c.counts[i] = 0
+	}
+	c.reset()
+	c.lock.Unlock()
+	return count
+}
+
+// Count returns the count over the last window.
+func (c *WindowedCounter) Count() uint64 {
+	c.lock.Lock()
+	count := c.count(c.now())
+	c.lock.Unlock()
+	return count
+}
+
+func (c *WindowedCounter) count(ts int64) uint64 {
+	var count uint64
+	for i, v := range c.counts {
+		if c.live(i, ts) {
+			count += v
+		}
+	}
+	return count
+}
+
+// Add increments the Counter by the given amount.
+func (c *WindowedCounter) Add(i uint64) {
+	c.lock.Lock()
+	n, stale := c.rotate(c.now())
+	if stale {
+		c.counts[n] = i
+	} else {
+		c.counts[n] += i
+	}
+	c.lock.Unlock()
+}
+
+// Snapshot returns a read-only copy of the count over the last window.
+func (c *WindowedCounter) Snapshot() Counter {
+	return CounterSnapshot(c.Count())
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func BenchmarkWindowedCounter(b *testing.B) {
+	c, err := NewWindowedCounter(time.Minute, 6)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Add(1)
+	}
+}
+
+func TestWindowedCounter(t *testing.T) {
+	counter, err := NewWindowedCounter(time.Minute, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := counter.(*WindowedCounter)
+	if c.Window() != time.Minute {
+		t.Errorf("c.Window(): %v != %v\n", time.Minute, c.Window())
+	}
+	var ts int64 = 1e12
+	c.now = func() int64 { return ts }
+
+	tests := []struct {
+		step      time.Duration // clock shift before add
+		add       uint64
+		wantCount uint64
+	}{
+		{step: 0, add: 1, wantCount: 1},
+		{step: 5 * time.Second, add: 2, wantCount: 3},
+		{step: 10 * time.Second, add: 3, wantCount: 6},
+		{step: 40 * time.Second, add: 0, wantCount: 6},
+		// first slice (with 1 and 2) is out of window
+		{step: 10 * time.Second, add: 4, wantCount: 7},
+		{step: 10 * time.Second, add: 0, wantCount: 4},
+		// window is expired
+		{step: time.Hour, add: 0, wantCount: 0},
+		{step: 0, add: 5, wantCount: 5},
+	}
+	for i, tt := range tests {
+		ts += int64(tt.step)
+		if tt.add > 0 {
+			c.Add(tt.add)
+		}
+		if count := c.Count(); count != tt.wantCount {
+			t.Errorf("[%d] c.Count(): %v != %v\n", i, tt.wantCount, count)
+		}
+	}
+	if count := c.Snapshot().Count(); count != 5 {
+		t.Errorf("c.Snapshot().Count(): 5 != %v\n", count)
+	}
+	if n := c.Clear(); n != 5 {
+		t.Errorf("c.Clear(): 5 != %v\n", n)
+	}
+	if count := c.Count(); count != 0 {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+}
+
+func TestWindowedCounterInvalid(t *testing.T) {
+	for _, slices := range []int{0, -1, 100} {
+		if _, err := NewWindowedCounter(10, slices); err != ErrInvalidWindow {
+			t.Errorf("NewWindowedCounter(10ns, %d) error = %v, want %v", slices, err, ErrInvalidWindow)
+		}
+	}
+}
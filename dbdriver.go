@@ -0,0 +1,316 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSQLNamedArgs      = errors.New("driver does not support named arguments")
+	ErrSQLIsolationLevel = errors.New("driver does not support non-default isolation level")
+	ErrSQLReadOnly       = errors.New("driver does not support read-only transactions")
+
+	// SQLDurationWeights is a database operations duration histograms (in milliseconds) weights, must be sorted.
+	SQLDurationWeights = []time.Duration{
+		time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond,
+		50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second,
+		2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+	}
+)
+
+// sqlOpMetrics is a database operation metrics
+type sqlOpMetrics struct {
+	duration DurationHistogram
+	errors   Counter
+}
+
+// observe records operation duration and error (driver.ErrSkip is not an operation)
+func (m *sqlOpMetrics) observe(start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+	m.duration.UpdateSince(start)
+	if err != nil {
+		m.errors.Add(1)
+	}
+}
+
+type sqlDriverMetrics struct {
+	prepare  sqlOpMetrics
+	exec     sqlOpMetrics
+	query    sqlOpMetrics
+	begin    sqlOpMetrics
+	commit   sqlOpMetrics
+	rollback sqlOpMetrics
+}
+
+// WrapDriver returns driver.Driver, which records database operations durations and errors, by operation
+// (op tag is prepare, exec, query, begin, commit or rollback):
+//
+//	NAME.duration;op=OP - operation duration histogram (with SQLDurationWeights, in milliseconds)
+//	NAME.errors;op=OP - operation errors counter
+//
+// Metrics are shared between wrappers with the same name and tags. Register wrapped driver with sql.Register.
+func WrapDriver(d driver.Driver, r Registry, name string, tags map[string]string) (driver.Driver, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	m := &sqlDriverMetrics{}
+	for _, op := range []struct {
+		name string
+		m    *sqlOpMetrics
+	}{
+		{"prepare", &m.prepare}, {"exec", &m.exec}, {"query", &m.query},
+		{"begin", &m.begin}, {"commit", &m.commit}, {"rollback", &m.rollback},
+	} {
+		opTags := MergeTags(map[string]string{"op": op.name}, tags)
+		var err error
+		if op.m.duration, err = GetOrRegisterDurationHistogramT(name+".duration", opTags, r, SQLDurationWeights, nil, time.Millisecond); err != nil {
+			return nil, err
+		}
+		op.m.errors = GetOrRegisterCounterT(name+".errors", opTags, r)
+	}
+	return &sqlDriver{d: d, m: m}, nil
+}
+
+type sqlDriver struct {
+	d driver.Driver
+	m *sqlDriverMetrics
+}
+
+func (d *sqlDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.d.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{conn: conn, m: d.m}, nil
+}
+
+// sqlConn wraps driver.Conn and implements optional interfaces with fallback to underlying connection capabilities
+type sqlConn struct {
+	conn driver.Conn
+	m    *sqlDriverMetrics
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	start := time.Now()
+	if cpc, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = cpc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	c.m.prepare.observe(start, err)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := stmt.(driver.ColumnConverter); ok {
+		return &sqlConverterStmt{sqlStmt{stmt: stmt, conn: c}}, nil
+	}
+	return &sqlStmt{stmt: stmt, conn: c}, nil
+}
+
+func (c *sqlConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
+	cbt, ok := c.conn.(driver.ConnBeginTx)
+	if !ok {
+		// fallback for drivers without BeginTx can't apply options, like database/sql does
+		if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+			return nil, ErrSQLIsolationLevel
+		}
+		if opts.ReadOnly {
+			return nil, ErrSQLReadOnly
+		}
+	}
+	start := time.Now()
+	if ok {
+		tx, err = cbt.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.conn.Begin()
+	}
+	c.m.begin.observe(start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{tx: tx, m: c.m}, nil
+}
+
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (res driver.Result, err error) {
+	start := time.Now()
+	if e, ok := c.conn.(driver.ExecerContext); ok {
+		res, err = e.ExecContext(ctx, query, args)
+	} else if e, ok := c.conn.(driver.Execer); ok {
+		var values []driver.Value
+		if values, err = sqlNamedValues(args); err == nil {
+			res, err = e.Exec(query, values)
+		}
+	} else {
+		// database/sql prepares statement
+		return nil, driver.ErrSkip
+	}
+	c.m.exec.observe(start, err)
+	return res, err
+}
+
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	start := time.Now()
+	if q, ok := c.conn.(driver.QueryerContext); ok {
+		rows, err = q.QueryContext(ctx, query, args)
+	} else if q, ok := c.conn.(driver.Queryer); ok {
+		var values []driver.Value
+		if values, err = sqlNamedValues(args); err == nil {
+			rows, err = q.Query(query, values)
+		}
+	} else {
+		// database/sql prepares statement
+		return nil, driver.ErrSkip
+	}
+	c.m.query.observe(start, err)
+	return rows, err
+}
+
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	// database/sql default conversion
+	return driver.ErrSkip
+}
+
+type sqlStmt struct {
+	stmt driver.Stmt
+	conn *sqlConn
+}
+
+func (s *sqlStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *sqlStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.stmt.Exec(args)
+	s.conn.m.exec.observe(start, err)
+	return res, err
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.stmt.Query(args)
+	s.conn.m.query.observe(start, err)
+	return rows, err
+}
+
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := s.stmt.(driver.StmtExecContext); ok {
+		start := time.Now()
+		res, err := e.ExecContext(ctx, args)
+		s.conn.m.exec.observe(start, err)
+		return res, err
+	}
+	values, err := sqlNamedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Exec(values)
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := s.stmt.(driver.StmtQueryContext); ok {
+		start := time.Now()
+		rows, err := q.QueryContext(ctx, args)
+		s.conn.m.query.observe(start, err)
+		return rows, err
+	}
+	values, err := sqlNamedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Query(values)
+}
+
+func (s *sqlStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	// conn checker or database/sql conversion (with statement ColumnConverter, see sqlConverterStmt)
+	return s.conn.CheckNamedValue(nv)
+}
+
+// sqlConverterStmt is a statement with driver.ColumnConverter, database/sql applies it
+// (with driver.Valuer and NumInput() handling) if CheckNamedValue returns driver.ErrSkip.
+type sqlConverterStmt struct {
+	sqlStmt
+}
+
+func (s *sqlConverterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.stmt.(driver.ColumnConverter).ColumnConverter(idx)
+}
+
+type sqlTx struct {
+	tx driver.Tx
+	m  *sqlDriverMetrics
+}
+
+func (t *sqlTx) Commit() error {
+	start := time.Now()
+	err := t.tx.Commit()
+	t.m.commit.observe(start, err)
+	return err
+}
+
+func (t *sqlTx) Rollback() error {
+	start := time.Now()
+	err := t.tx.Rollback()
+	t.m.rollback.observe(start, err)
+	return err
+}
+
+// sqlNamedValues converts arguments for drivers without context methods
+func sqlNamedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, ErrSQLNamedArgs
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var errTestSQL = errors.New("test sql error")
+
+// testSQLDriver is a fake in-process driver, queries and statements fail on "fail" query
+type testSQLDriver struct{}
+
+func (testSQLDriver) Open(string) (driver.Conn, error) { return &testSQLConn{}, nil }
+
+type testSQLConn struct {
+	args []driver.Value // last converter statement arguments
+}
+
+func (c *testSQLConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "convert" {
+		return &testSQLConverterStmt{testSQLStmt: testSQLStmt{query: query}, conn: c}, nil
+	}
+	return &testSQLStmt{query: query}, nil
+}
+
+func (c *testSQLConn) Close() error { return nil }
+
+func (c *testSQLConn) Begin() (driver.Tx, error) { return testSQLTx{}, nil }
+
+func (c *testSQLConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query == "fail" {
+		return nil, errTestSQL
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *testSQLConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if query == "fail" {
+		return nil, errTestSQL
+	}
+	return &testSQLRows{}, nil
+}
+
+// testSQLStmt has no context methods (for fallback)
+type testSQLStmt struct {
+	query string
+}
+
+func (s *testSQLStmt) Close() error  { return nil }
+func (s *testSQLStmt) NumInput() int { return -1 }
+
+func (s *testSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.query == "fail" {
+		return nil, errTestSQL
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query == "fail" {
+		return nil, errTestSQL
+	}
+	return &testSQLRows{}, nil
+}
+
+// testSQLConverterStmt converts arguments to int32 and saves them in conn
+type testSQLConverterStmt struct {
+	testSQLStmt
+	conn *testSQLConn
+}
+
+func (s *testSQLConverterStmt) NumInput() int { return 2 }
+
+func (s *testSQLConverterStmt) ColumnConverter(int) driver.ValueConverter { return driver.Int32 }
+
+func (s *testSQLConverterStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.args = args
+	return driver.RowsAffected(1), nil
+}
+
+// testSQLValuer is a driver.Valuer, can't be converted without Value() call
+type testSQLValuer struct {
+	v string
+}
+
+func (v testSQLValuer) Value() (driver.Value, error) { return v.v, nil }
+
+type testSQLTx struct{}
+
+func (testSQLTx) Commit() error   { return nil }
+func (testSQLTx) Rollback() error { return errTestSQL }
+
+type testSQLRows struct {
+	done bool
+}
+
+func (r *testSQLRows) Columns() []string { return []string{"v"} }
+func (r *testSQLRows) Close() error      { return nil }
+
+func (r *testSQLRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+type testSQLConnector struct {
+	d driver.Driver
+}
+
+func (c testSQLConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c testSQLConnector) Driver() driver.Driver                        { return c.d }
+
+func TestWrapDriver(t *testing.T) {
+	r := NewRegistry()
+	tags := map[string]string{"db": "users"}
+	d, err := WrapDriver(testSQLDriver{}, r, "sql", tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(testSQLConnector{d})
+	defer db.Close()
+
+	if _, err = db.Exec("insert", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec("fail"); err != errTestSQL {
+		t.Errorf("Exec() error = %v, want %v", err, errTestSQL)
+	}
+	var v int
+	if err = db.QueryRow("select", 1).Scan(&v); err != nil || v != 1 {
+		t.Errorf("QueryRow() = (%d, %v), want 1", v, err)
+	}
+	stmt, err := db.Prepare("fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stmt.Query(); err != errTestSQL {
+		t.Errorf("Stmt.Query() error = %v, want %v", err, errTestSQL)
+	}
+	stmt.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if tx, err = db.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Rollback(); err != errTestSQL {
+		t.Errorf("Rollback() error = %v, want %v", err, errTestSQL)
+	}
+
+	tests := []struct {
+		op     string
+		count  uint64
+		errors uint64
+	}{
+		{"prepare", 1, 0},
+		{"exec", 2, 1},
+		{"query", 2, 1},
+		{"begin", 2, 0},
+		{"commit", 1, 0},
+		{"rollback", 1, 1},
+	}
+	for _, tt := range tests {
+		opTags := map[string]string{"db": "users", "op": tt.op}
+		if got := r.GetT("sql.duration", opTags).(DurationHistogram).Stats().Count; got != tt.count {
+			t.Errorf("sql.duration%s count = %d, want %d", JoinTags(opTags), got, tt.count)
+		}
+		if got := r.GetT("sql.errors", opTags).(Counter).Count(); got != tt.errors {
+			t.Errorf("sql.errors%s = %d, want %d", JoinTags(opTags), got, tt.errors)
+		}
+	}
+
+	// shared metrics
+	if _, err = WrapDriver(testSQLDriver{}, r, "sql", tags); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWrapDriver_LegacyDriver(t *testing.T) {
+	d, err := WrapDriver(testSQLDriver{}, NewRegistry(), "sql", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(testSQLConnector{d})
+	defer db.Close()
+	ctx := context.Background()
+
+	// transaction options can't be applied with driver.Conn.Begin()
+	if _, err = db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}); err != ErrSQLIsolationLevel {
+		t.Errorf("BeginTx(serializable) error = %v, want %v", err, ErrSQLIsolationLevel)
+	}
+	if _, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != ErrSQLReadOnly {
+		t.Errorf("BeginTx(read-only) error = %v, want %v", err, ErrSQLReadOnly)
+	}
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// statement ColumnConverter is applied by database/sql (with driver.Valuer and NumInput)
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, "convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err = stmt.Exec("12", testSQLValuer{"5"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stmt.Exec(1); err == nil {
+		t.Error("Stmt.Exec() with wrong arguments count must fail")
+	}
+	if _, err = stmt.Exec("a", 1); err == nil {
+		t.Error("Stmt.Exec() with invalid argument must fail")
+	}
+	var args []driver.Value
+	if err = conn.Raw(func(dc interface{}) error {
+		args = dc.(*sqlConn).conn.(*testSQLConn).args
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []driver.Value{int64(12), int64(5)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("converted arguments = %#v, want %#v", args, want)
+	}
+}
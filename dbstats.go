@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+// DBStatsCollector captures database/sql connection pool statistics (sql.DBStats) into metrics, registered in one registry.
+type DBStatsCollector struct {
+	r       Registry
+	tags    map[string]string
+	metrics []collectorMetric // registered metrics
+	db      *sql.DB
+
+	MaxOpen           Gauge
+	Open              Gauge
+	InUse             Gauge
+	Idle              Gauge
+	WaitCount         Rate
+	WaitDuration      FRate // in seconds
+	MaxIdleClosed     Rate
+	MaxIdleTimeClosed Rate
+	MaxLifetimeClosed Rate
+
+	lock   sync.Mutex
+	runner runner.Runner
+}
+
+// RegisterDBStats registers db connection pool metrics with name prefix (like db.users.open), see RegisterDBStatsT.
+func RegisterDBStats(r Registry, name string, db *sql.DB) (*DBStatsCollector, error) {
+	return RegisterDBStatsT(r, name, nil, db)
+}
+
+// RegisterDBStatsT registers tagged db connection pool metrics with name prefix:
+//
+//	NAME.max_open - maximum number of open connections
+//	NAME.open, NAME.in_use, NAME.idle - open, in use and idle connections
+//	NAME.wait_count, NAME.wait_seconds - waited for connection count and total time
+//	NAME.max_idle_closed, NAME.max_idle_time_closed, NAME.max_lifetime_closed - closed connections due to pool limits
+//
+// Values are updated on Capture() calls (or periodically after Start()).
+// Returns DuplicateMetric error (and unregister already registered metrics) if metric with the same name exists.
+func RegisterDBStatsT(r Registry, name string, tags map[string]string, db *sql.DB) (*DBStatsCollector, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	c := &DBStatsCollector{
+		r:                 r,
+		tags:              tags,
+		db:                db,
+		MaxOpen:           NewGauge(),
+		Open:              NewGauge(),
+		InUse:             NewGauge(),
+		Idle:              NewGauge(),
+		WaitCount:         NewRate(),
+		WaitDuration:      NewFRate(),
+		MaxIdleClosed:     NewRate(),
+		MaxIdleTimeClosed: NewRate(),
+		MaxLifetimeClosed: NewRate(),
+	}
+	c.metrics = []collectorMetric{
+		{name + ".max_open", c.MaxOpen},
+		{name + ".open", c.Open},
+		{name + ".in_use", c.InUse},
+		{name + ".idle", c.Idle},
+		{name + ".wait_count", c.WaitCount},
+		{name + ".wait_seconds", c.WaitDuration},
+		{name + ".max_idle_closed", c.MaxIdleClosed},
+		{name + ".max_idle_time_closed", c.MaxIdleTimeClosed},
+		{name + ".max_lifetime_closed", c.MaxLifetimeClosed},
+	}
+	if err := registerCollectorMetrics(r, c.metrics, c.tags); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Capture new values for the db connection pool statistics.
+func (c *DBStatsCollector) Capture() {
+	c.lock.Lock()
+	c.capture(c.db.Stats(), time.Now().UnixNano())
+	c.lock.Unlock()
+}
+
+func (c *DBStatsCollector) capture(stats sql.DBStats, t int64) {
+	c.MaxOpen.Update(int64(stats.MaxOpenConnections))
+	c.Open.Update(int64(stats.OpenConnections))
+	c.InUse.Update(int64(stats.InUse))
+	c.Idle.Update(int64(stats.Idle))
+	c.WaitCount.UpdateTs(stats.WaitCount, t)
+	c.WaitDuration.UpdateTs(stats.WaitDuration.Seconds(), t)
+	c.MaxIdleClosed.UpdateTs(stats.MaxIdleClosed, t)
+	c.MaxIdleTimeClosed.UpdateTs(stats.MaxIdleTimeClosed, t)
+	c.MaxLifetimeClosed.UpdateTs(stats.MaxLifetimeClosed, t)
+}
+
+// Start captures the db connection pool statistics with interval in background goroutine, until Stop() call or ctx cancellation.
+// Stopped collector can be started again.
+func (c *DBStatsCollector) Start(ctx context.Context, interval time.Duration) error {
+	return c.runner.Start(ctx, interval, c.Capture)
+}
+
+// Stop stops background captures and wait for goroutine exit.
+func (c *DBStatsCollector) Stop() {
+	c.runner.Stop()
+}
+
+// Unregister stops collector and unregisters it's metrics.
+func (c *DBStatsCollector) Unregister() {
+	c.Stop()
+	unregisterCollectorMetrics(c.r, c.metrics, c.tags)
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestRegisterDBStats(t *testing.T) {
+	db := sql.OpenDB(testSQLConnector{testSQLDriver{}})
+	defer db.Close()
+	db.SetMaxOpenConns(2)
+
+	r := NewRegistry()
+	c, err := RegisterDBStatsT(r, "db.users", map[string]string{"app": "test"}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RegisterDBStatsT(r, "db.users", map[string]string{"app": "test"}, db); err == nil {
+		t.Error("RegisterDBStatsT() with duplicate metrics must fail")
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Capture()
+	if v := c.MaxOpen.Value(); v != 2 {
+		t.Errorf("max_open = %d, want 2", v)
+	}
+	if v := c.Open.Value(); v != 1 {
+		t.Errorf("open = %d, want 1", v)
+	}
+	if v := c.InUse.Value(); v != 1 {
+		t.Errorf("in_use = %d, want 1", v)
+	}
+	conn.Close()
+	c.Capture()
+	if v := c.InUse.Value(); v != 0 {
+		t.Errorf("in_use = %d, want 0", v)
+	}
+	if v := r.GetT("db.users.idle", map[string]string{"app": "test"}).(Gauge).Value(); v != 1 {
+		t.Errorf("idle = %d, want 1", v)
+	}
+
+	// rates
+	c.capture(sql.DBStats{WaitCount: 10, WaitDuration: time.Second}, 1e9)
+	c.capture(sql.DBStats{WaitCount: 30, WaitDuration: 3 * time.Second}, 3e9)
+	if v, rate := c.WaitCount.Values(); v != 30 || rate != 10 {
+		t.Errorf("wait_count = (%d, %f), want (30, 10)", v, rate)
+	}
+	if v, rate := c.WaitDuration.Values(); v != 3 || rate != 1 {
+		t.Errorf("wait_seconds = (%f, %f), want (3, 1)", v, rate)
+	}
+
+	if err = c.Start(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	c.Unregister()
+	if m := r.GetT("db.users.open", map[string]string{"app": "test"}); m != nil {
+		t.Error("db.users.open is registered after Unregister()")
+	}
+}
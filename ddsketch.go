@@ -0,0 +1,628 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+var (
+	ErrDDSketchRelativeAccuracy = errors.New("relative accuracy must be in range (0, 1)")
+	ErrDDSketchMergeAccuracy    = errors.New("can't merge sketches with different relative accuracy")
+	ErrDDSketchEncoding         = errors.New("invalid DDSketch snapshot encoding")
+)
+
+const ddSketchEncodingVersion = 1
+
+// A DDSketch is a mergeable quantile sketch with relative accuracy guarantee (see https://arxiv.org/abs/1908.10693):
+// estimated quantile value v' of value v satisfies |v' - v| <= relativeAccuracy * |v|.
+//
+// Sketches (and decoded snapshots from other processes) with the same relative accuracy can be merged without accuracy loss,
+// so quantiles can be aggregated across instances.
+// If maxBins limit is reached, lowest (by absolute value) bins are collapsed, so accuracy is lost only for values near zero.
+type DDSketch interface {
+	Add(v float64)
+	Clear()
+	Snapshot() DDSketch
+	// Merge adds observations from other sketch with the same relative accuracy
+	Merge(DDSketch) error
+	// Observations count
+	Count() uint64
+	// Observations sum
+	Sum() float64
+	// Minimal observation (or zero without observations)
+	Min() float64
+	// Maximal observation (or zero without observations)
+	Max() float64
+	// Arithmetic mean of observations (or zero without observations)
+	Mean() float64
+	// Observations summary (count, sum, min, max)
+	Stats() HistogramStats
+	// Quantile returns estimated q-quantile (0 <= q <= 1) value (or zero without observations)
+	Quantile(q float64) float64
+	// Quantiles returns estimated quantiles (0 <= q <= 1) values, calculated from one consistent read
+	Quantiles(qs []float64) []float64
+	RelativeAccuracy() float64
+	// MarshalBinary encodes sketch snapshot (decode with DDSketchSnapshot.UnmarshalBinary)
+	MarshalBinary() ([]byte, error)
+}
+
+// GetOrRegisterDDSketch returns an existing DDSketch or constructs and registers
+// a new StandardDDSketch.
+func GetOrRegisterDDSketch(name string, r Registry, relativeAccuracy float64, maxBins int) (DDSketch, error) {
+	if _, err := newDDSketch(relativeAccuracy, maxBins); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		s, _ := NewDDSketch(relativeAccuracy, maxBins)
+		return s
+	}).(DDSketch), nil
+}
+
+// GetOrRegisterDDSketchT returns an existing DDSketch or constructs and registers
+// a new StandardDDSketch.
+func GetOrRegisterDDSketchT(name string, tagsMap map[string]string, r Registry, relativeAccuracy float64, maxBins int) (DDSketch, error) {
+	if _, err := newDDSketch(relativeAccuracy, maxBins); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		s, _ := NewDDSketch(relativeAccuracy, maxBins)
+		return s
+	}).(DDSketch), nil
+}
+
+// NewRegisteredDDSketch constructs and registers a new StandardDDSketch.
+func NewRegisteredDDSketch(name string, r Registry, relativeAccuracy float64, maxBins int) (DDSketch, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	s, err := NewDDSketch(relativeAccuracy, maxBins)
+	if err != nil {
+		return nil, err
+	}
+	r.Register(name, s)
+	return s, nil
+}
+
+// NewRegisteredDDSketchT constructs and registers a new StandardDDSketch.
+func NewRegisteredDDSketchT(name string, tagsMap map[string]string, r Registry, relativeAccuracy float64, maxBins int) (DDSketch, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	s, err := NewDDSketch(relativeAccuracy, maxBins)
+	if err != nil {
+		return nil, err
+	}
+	r.RegisterT(name, tagsMap, s)
+	return s, nil
+}
+
+// NewDDSketch constructs a new StandardDDSketch with relativeAccuracy (for example, 0.01 for 1%) and maxBins limit
+// for positive and negative values bins (unlimited if maxBins <= 0).
+//
+// Returns ErrDDSketchRelativeAccuracy if relativeAccuracy not in range (0, 1).
+func NewDDSketch(relativeAccuracy float64, maxBins int) (DDSketch, error) {
+	s, err := newDDSketch(relativeAccuracy, maxBins)
+	if err != nil {
+		return nil, err
+	}
+	if UseNilMetrics {
+		return NilDDSketch{}, nil
+	}
+	return &StandardDDSketch{sketch: s}, nil
+}
+
+// ddStore is a dense bins store, bins[i] counts values with index (offset + i).
+type ddStore struct {
+	bins   []uint64
+	offset int
+}
+
+// add adds n to bin with index idx. Lowest bins are collapsed, if bins count exceed maxBins (if maxBins > 0).
+func (s *ddStore) add(idx int, n uint64, maxBins int) {
+	if len(s.bins) == 0 {
+		s.bins = append(s.bins, 0)
+		s.offset = idx
+	}
+	if idx < s.offset {
+		// extend to front, but not over maxBins
+		offset := idx
+		if maxBins > 0 && s.offset+len(s.bins)-offset > maxBins {
+			offset = s.offset + len(s.bins) - maxBins
+		}
+		if offset < s.offset {
+			bins := make([]uint64, s.offset+len(s.bins)-offset)
+			copy(bins[s.offset-offset:], s.bins)
+			s.bins = bins
+			s.offset = offset
+		}
+		if idx < s.offset {
+			idx = s.offset
+		}
+	} else if end := s.offset + len(s.bins); idx >= end {
+		s.bins = append(s.bins, make([]uint64, idx-end+1)...)
+		if maxBins > 0 && len(s.bins) > maxBins {
+			// collapse lowest bins
+			k := len(s.bins) - maxBins
+			for i := 0; i < k; i++ {
+				s.bins[k] += s.bins[i]
+			}
+			s.bins = append(s.bins[:0], s.bins[k:]...)
+			s.offset += k
+		}
+	}
+	s.bins[idx-s.offset] += n
+}
+
+func (s *ddStore) merge(o *ddStore, maxBins int) {
+	if len(o.bins) == 0 {
+		return
+	}
+	// preallocate bins for bounds
+	s.add(o.offset+len(o.bins)-1, 0, maxBins)
+	s.add(o.offset, 0, maxBins)
+	for i, c := range o.bins {
+		if c > 0 {
+			s.add(o.offset+i, c, maxBins)
+		}
+	}
+}
+
+func (s *ddStore) copy() ddStore {
+	bins := make([]uint64, len(s.bins))
+	copy(bins, s.bins)
+	return ddStore{bins: bins, offset: s.offset}
+}
+
+func (s *ddStore) count() (n uint64) {
+	for _, c := range s.bins {
+		n += c
+	}
+	return
+}
+
+// ddSketch is a DDSketch state without locking.
+type ddSketch struct {
+	relativeAccuracy float64
+	maxBins          int
+	gamma            float64
+	multiplier       float64 // 1 / ln(gamma)
+	minIndexable     float64 // values with lower absolute value counted as zero
+
+	positive  ddStore
+	negative  ddStore // indexes of absolute values
+	zeroCount uint64
+
+	count uint64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+func newDDSketch(relativeAccuracy float64, maxBins int) (ddSketch, error) {
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) {
+		return ddSketch{}, ErrDDSketchRelativeAccuracy
+	}
+	if maxBins < 0 {
+		maxBins = 0
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return ddSketch{
+		relativeAccuracy: relativeAccuracy,
+		maxBins:          maxBins,
+		gamma:            gamma,
+		multiplier:       1 / math.Log(gamma),
+		minIndexable:     2.2250738585072014e-308 * gamma, // min normal float64
+		min:              math.Inf(1),
+		max:              math.Inf(-1),
+	}, nil
+}
+
+// index returns bin index for positive value: gamma^(index-1) < v <= gamma^index
+func (s *ddSketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) * s.multiplier))
+}
+
+// validIndexes checks that bins [offset, offset+n) are in range of indexes for values [minIndexable, math.MaxFloat64]
+func (s *ddSketch) validIndexes(offset int64, n uint64) bool {
+	if n == 0 {
+		return true
+	}
+	minIdx, maxIdx := int64(s.index(s.minIndexable)), int64(s.index(math.MaxFloat64))
+	return offset >= minIdx && offset <= maxIdx && n <= uint64(maxIdx-offset+1)
+}
+
+// value returns bin value, relative error for all values in bin is not greater than relativeAccuracy
+func (s *ddSketch) value(idx int) float64 {
+	return math.Exp(float64(idx)/s.multiplier) * 2 / (1 + s.gamma)
+}
+
+func (s *ddSketch) add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	if v >= s.minIndexable {
+		s.positive.add(s.index(v), 1, s.maxBins)
+	} else if v <= -s.minIndexable {
+		s.negative.add(s.index(-v), 1, s.maxBins)
+	} else {
+		s.zeroCount++
+	}
+	s.count++
+	s.sum += v
+	if v < s.min {
+		s.min = v
+	}
+	if v > s.max {
+		s.max = v
+	}
+}
+
+func (s *ddSketch) merge(o *ddSketch) error {
+	if o.count == 0 {
+		return nil
+	}
+	if s.gamma != o.gamma {
+		return ErrDDSketchMergeAccuracy
+	}
+	s.positive.merge(&o.positive, s.maxBins)
+	s.negative.merge(&o.negative, s.maxBins)
+	s.zeroCount += o.zeroCount
+	s.count += o.count
+	s.sum += o.sum
+	if o.min < s.min {
+		s.min = o.min
+	}
+	if o.max > s.max {
+		s.max = o.max
+	}
+	return nil
+}
+
+func (s *ddSketch) copy() ddSketch {
+	c := *s
+	c.positive = s.positive.copy()
+	c.negative = s.negative.copy()
+	return c
+}
+
+func (s *ddSketch) reset() {
+	s.positive = ddStore{}
+	s.negative = ddStore{}
+	s.zeroCount = 0
+	s.count = 0
+	s.sum = 0
+	s.min = math.Inf(1)
+	s.max = math.Inf(-1)
+}
+
+func (s *ddSketch) stats() HistogramStats {
+	if s.count == 0 {
+		return HistogramStats{}
+	}
+	return HistogramStats{Count: s.count, Sum: s.sum, Min: s.min, Max: s.max}
+}
+
+func (s *ddSketch) quantile(q float64) float64 {
+	if math.IsNaN(q) {
+		return math.NaN()
+	}
+	if s.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return s.min
+	}
+	if q >= 1 {
+		return s.max
+	}
+
+	rank := q * float64(s.count-1)
+	var (
+		cum float64
+		v   float64
+	)
+	found := false
+	// negative values from lowest (highest absolute value)
+	for i := len(s.negative.bins) - 1; i >= 0; i-- {
+		cum += float64(s.negative.bins[i])
+		if cum > rank {
+			v = -s.value(s.negative.offset + i)
+			found = true
+			break
+		}
+	}
+	if !found {
+		cum += float64(s.zeroCount)
+		if cum > rank {
+			found = true
+		}
+	}
+	if !found {
+		v = s.max
+		for i, c := range s.positive.bins {
+			cum += float64(c)
+			if cum > rank {
+				v = s.value(s.positive.offset + i)
+				break
+			}
+		}
+	}
+
+	// exact bounds are known
+	if v < s.min {
+		return s.min
+	}
+	if v > s.max {
+		return s.max
+	}
+	return v
+}
+
+func (s *ddSketch) quantiles(qs []float64) []float64 {
+	res := make([]float64, len(qs))
+	for i, q := range qs {
+		res[i] = s.quantile(q)
+	}
+	return res
+}
+
+// NilDDSketch is a no-op DDSketch.
+type NilDDSketch struct{}
+
+func (NilDDSketch) Add(v float64) {}
+
+func (NilDDSketch) Clear() {}
+
+func (NilDDSketch) Snapshot() DDSketch { return NilDDSketch{} }
+
+func (NilDDSketch) Merge(DDSketch) error { return nil }
+
+func (NilDDSketch) Count() uint64 { return 0 }
+
+func (NilDDSketch) Sum() float64 { return 0 }
+
+func (NilDDSketch) Min() float64 { return 0 }
+
+func (NilDDSketch) Max() float64 { return 0 }
+
+func (NilDDSketch) Mean() float64 { return 0 }
+
+func (NilDDSketch) Stats() HistogramStats { return HistogramStats{} }
+
+func (NilDDSketch) Quantile(float64) float64 { return 0 }
+
+func (NilDDSketch) Quantiles(qs []float64) []float64 { return make([]float64, len(qs)) }
+
+func (NilDDSketch) RelativeAccuracy() float64 { return 0 }
+
+func (NilDDSketch) MarshalBinary() ([]byte, error) { return nil, nil }
+
+// DDSketchSnapshot is a read-only copy of DDSketch.
+type DDSketchSnapshot struct {
+	sketch ddSketch
+}
+
+func (*DDSketchSnapshot) Add(float64) {
+	panic("Add called on a DDSketchSnapshot")
+}
+
+func (*DDSketchSnapshot) Clear() {
+	panic("Clear called on a DDSketchSnapshot")
+}
+
+func (*DDSketchSnapshot) Merge(DDSketch) error {
+	panic("Merge called on a DDSketchSnapshot")
+}
+
+func (s *DDSketchSnapshot) Snapshot() DDSketch {
+	return s
+}
+
+func (s *DDSketchSnapshot) Count() uint64 {
+	return s.sketch.count
+}
+
+func (s *DDSketchSnapshot) Sum() float64 {
+	return s.sketch.sum
+}
+
+func (s *DDSketchSnapshot) Min() float64 {
+	return s.sketch.stats().Min
+}
+
+func (s *DDSketchSnapshot) Max() float64 {
+	return s.sketch.stats().Max
+}
+
+func (s *DDSketchSnapshot) Mean() float64 {
+	return s.sketch.stats().Mean()
+}
+
+func (s *DDSketchSnapshot) Stats() HistogramStats {
+	return s.sketch.stats()
+}
+
+func (s *DDSketchSnapshot) Quantile(q float64) float64 {
+	return s.sketch.quantile(q)
+}
+
+func (s *DDSketchSnapshot) Quantiles(qs []float64) []float64 {
+	return s.sketch.quantiles(qs)
+}
+
+func (s *DDSketchSnapshot) RelativeAccuracy() float64 {
+	return s.sketch.relativeAccuracy
+}
+
+// MarshalBinary encodes snapshot: version, relative accuracy, max bins, stats, zero count and negative/positive stores
+func (s *DDSketchSnapshot) MarshalBinary() ([]byte, error) {
+	w := binaryWriter{data: make([]byte, 0, 64+len(s.sketch.positive.bins)+len(s.sketch.negative.bins))}
+
+	w.uvarint(ddSketchEncodingVersion)
+	w.float64(s.sketch.relativeAccuracy)
+	w.uvarint(uint64(s.sketch.maxBins))
+	w.uvarint(s.sketch.count)
+	w.float64(s.sketch.sum)
+	w.float64(s.sketch.min)
+	w.float64(s.sketch.max)
+	w.uvarint(s.sketch.zeroCount)
+	for _, store := range []*ddStore{&s.sketch.negative, &s.sketch.positive} {
+		w.varint(int64(store.offset))
+		w.uvarint(uint64(len(store.bins)))
+		for _, c := range store.bins {
+			w.uvarint(c)
+		}
+	}
+
+	return w.data, nil
+}
+
+// UnmarshalBinary decodes snapshot, encoded with MarshalBinary.
+func (s *DDSketchSnapshot) UnmarshalBinary(data []byte) error {
+	r := binaryReader{data: data, errInvalid: ErrDDSketchEncoding}
+
+	if r.uvarint() != ddSketchEncodingVersion || r.err != nil {
+		return ErrDDSketchEncoding
+	}
+	relativeAccuracy := r.float64()
+	maxBins := r.uvarint()
+	if r.err != nil {
+		return r.err
+	}
+	if maxBins > math.MaxInt32 {
+		return ErrDDSketchEncoding
+	}
+	sketch, err := newDDSketch(relativeAccuracy, int(maxBins))
+	if err != nil {
+		return err
+	}
+	sketch.count = r.uvarint()
+	sketch.sum = r.float64()
+	sketch.min = r.float64()
+	sketch.max = r.float64()
+	sketch.zeroCount = r.uvarint()
+	total := sketch.zeroCount
+	for _, store := range []*ddStore{&sketch.negative, &sketch.positive} {
+		offset := r.varint()
+		n := r.uvarint()
+		if r.err != nil {
+			return r.err
+		}
+		if !sketch.validIndexes(offset, n) {
+			// out of sketch indexes range (also can't be merged or queried)
+			return ErrDDSketchEncoding
+		}
+		store.offset = int(offset)
+		if n > uint64(len(r.data)) {
+			// each bin encoded at least with one byte
+			return ErrDDSketchEncoding
+		}
+		if n > 0 {
+			store.bins = make([]uint64, n)
+			for i := range store.bins {
+				store.bins[i] = r.uvarint()
+				total += store.bins[i]
+			}
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) > 0 || total != sketch.count {
+		return ErrDDSketchEncoding
+	}
+
+	s.sketch = sketch
+	return nil
+}
+
+// StandardDDSketch is the standard implementation of DDSketch.
+type StandardDDSketch struct {
+	sketch ddSketch
+	lock   sync.Mutex
+}
+
+func (s *StandardDDSketch) Add(v float64) {
+	s.lock.Lock()
+	s.sketch.add(v)
+	s.lock.Unlock()
+}
+
+func (s *StandardDDSketch) Clear() {
+	s.lock.Lock()
+	s.sketch.reset()
+	s.lock.Unlock()
+}
+
+func (s *StandardDDSketch) Snapshot() DDSketch {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return &DDSketchSnapshot{sketch: s.sketch.copy()}
+}
+
+func (s *StandardDDSketch) Merge(other DDSketch) error {
+	o, ok := other.Snapshot().(*DDSketchSnapshot)
+	if !ok {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sketch.merge(&o.sketch)
+}
+
+func (s *StandardDDSketch) Count() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sketch.count
+}
+
+func (s *StandardDDSketch) Sum() float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sketch.sum
+}
+
+func (s *StandardDDSketch) Min() float64 {
+	return s.Stats().Min
+}
+
+func (s *StandardDDSketch) Max() float64 {
+	return s.Stats().Max
+}
+
+func (s *StandardDDSketch) Mean() float64 {
+	return s.Stats().Mean()
+}
+
+func (s *StandardDDSketch) Stats() HistogramStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sketch.stats()
+}
+
+func (s *StandardDDSketch) Quantile(q float64) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sketch.quantile(q)
+}
+
+func (s *StandardDDSketch) Quantiles(qs []float64) []float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sketch.quantiles(qs)
+}
+
+func (s *StandardDDSketch) RelativeAccuracy() float64 {
+	return s.sketch.relativeAccuracy
+}
+
+func (s *StandardDDSketch) MarshalBinary() ([]byte, error) {
+	return s.Snapshot().MarshalBinary()
+}
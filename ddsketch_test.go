@@ -0,0 +1,286 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func checkDDSketchAccuracy(t *testing.T, s DDSketch, values []float64, accuracy float64) {
+	t.Helper()
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	qs := []float64{0, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99, 0.999, 1}
+	for i, got := range s.Quantiles(qs) {
+		want := sorted[int(qs[i]*float64(len(sorted)-1))]
+		if math.Abs(got-want) > accuracy*math.Abs(want)+1e-12 {
+			t.Errorf("Quantile(%v) = %v, want %v with %v relative accuracy", qs[i], got, want, accuracy)
+		}
+	}
+}
+
+func TestNewDDSketch_Errors(t *testing.T) {
+	for _, accuracy := range []float64{0, -0.1, 1, math.NaN()} {
+		if s, err := NewDDSketch(accuracy, 0); err != ErrDDSketchRelativeAccuracy || s != nil {
+			t.Errorf("NewDDSketch(%v) = (%v, %v), want (nil, %v)", accuracy, s, err, ErrDDSketchRelativeAccuracy)
+		}
+		if _, err := GetOrRegisterDDSketch("sketch", NewRegistry(), accuracy, 0); err != ErrDDSketchRelativeAccuracy {
+			t.Errorf("GetOrRegisterDDSketch(%v) error = %v, want %v", accuracy, err, ErrDDSketchRelativeAccuracy)
+		}
+	}
+}
+
+func TestDDSketch(t *testing.T) {
+	tests := []struct {
+		name     string
+		accuracy float64
+		values   func(r *rand.Rand) float64
+	}{
+		{name: "uniform", accuracy: 0.01, values: func(r *rand.Rand) float64 { return r.Float64() * 1000 }},
+		{name: "exponential", accuracy: 0.02, values: func(r *rand.Rand) float64 { return r.ExpFloat64() * 1e-3 }},
+		{name: "lognormal", accuracy: 0.001, values: func(r *rand.Rand) float64 { return math.Exp(r.NormFloat64() * 5) }},
+		{name: "normal", accuracy: 0.01, values: func(r *rand.Rand) float64 { return r.NormFloat64() * 100 }},
+		{name: "integers with zeroes", accuracy: 0.01, values: func(r *rand.Rand) float64 { return float64(r.Intn(21) - 10) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			s := newTestDDSketch(t, tt.accuracy, 0)
+			if got := s.Quantiles([]float64{0.5, 0.99}); !reflect.DeepEqual(got, []float64{0, 0}) {
+				t.Errorf("Quantiles() without observations = %v, want zeroes", got)
+			}
+			values := make([]float64, 10000)
+			var sum float64
+			for i := range values {
+				values[i] = tt.values(r)
+				sum += values[i]
+				s.Add(values[i])
+			}
+			if s.Count() != uint64(len(values)) || math.Abs(s.Sum()-sum) > 1e-6*math.Abs(sum) {
+				t.Errorf("Count() = %d, Sum() = %v, want %d, %v", s.Count(), s.Sum(), len(values), sum)
+			}
+			checkDDSketchAccuracy(t, s, values, tt.accuracy)
+			checkDDSketchAccuracy(t, s.Snapshot(), values, tt.accuracy)
+
+			s.Clear()
+			if got := s.Stats(); got != (HistogramStats{}) || s.Quantile(0.5) != 0 {
+				t.Errorf("Stats() after Clear() = %+v, want zero", got)
+			}
+		})
+	}
+}
+
+func TestDDSketch_MaxBins(t *testing.T) {
+	s := newTestDDSketch(t, 0.01, 100)
+	values := make([]float64, 0, 10000)
+	for i := 1; i <= 10000; i++ {
+		values = append(values, float64(i))
+		s.Add(float64(i))
+	}
+	// reversed order also collapse lowest bins
+	r := newTestDDSketch(t, 0.01, 100)
+	for i := 10000; i >= 1; i-- {
+		r.Add(float64(i))
+	}
+	for _, s := range []DDSketch{s, r} {
+		snap := s.Snapshot().(*DDSketchSnapshot)
+		if n := len(snap.sketch.positive.bins); n != 100 {
+			t.Errorf("bins = %d, want 100", n)
+		}
+		if s.Count() != 10000 || snap.sketch.positive.count() != 10000 {
+			t.Errorf("Count() = %d, bins count = %d, want 10000", s.Count(), snap.sketch.positive.count())
+		}
+		// upper quantiles are accurate
+		for _, q := range []float64{0.7, 0.9, 0.99} {
+			want := values[int(q*float64(len(values)-1))]
+			if got := s.Quantile(q); math.Abs(got-want) > 0.01*want {
+				t.Errorf("Quantile(%v) = %v, want %v", q, got, want)
+			}
+		}
+	}
+}
+
+func TestDDSketch_Merge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	a := newTestDDSketch(t, 0.01, 0)
+	b := newTestDDSketch(t, 0.01, 0)
+	all := newTestDDSketch(t, 0.01, 0)
+	values := make([]float64, 10000)
+	for i := range values {
+		values[i] = r.NormFloat64() * 1000
+		if i%2 == 0 {
+			a.Add(values[i])
+		} else {
+			b.Add(values[i])
+		}
+		all.Add(values[i])
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Merge(NilDDSketch{}); err != nil {
+		t.Fatal(err)
+	}
+	qs := []float64{0.1, 0.5, 0.9, 0.99}
+	if got, want := a.Quantiles(qs), all.Quantiles(qs); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() Quantiles() = %v, want %v", got, want)
+	}
+	if a.Count() != all.Count() || a.Min() != all.Min() || a.Max() != all.Max() {
+		t.Errorf("Merge() Stats() = %+v, want %+v", a.Stats(), all.Stats())
+	}
+	checkDDSketchAccuracy(t, a, values, 0.01)
+
+	if err := a.Merge(all); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count() != 2*all.Count() {
+		t.Errorf("Merge() Count() = %d, want %d", a.Count(), 2*all.Count())
+	}
+
+	c := newTestDDSketch(t, 0.02, 0)
+	c.Add(1)
+	if err := a.Merge(c); err != ErrDDSketchMergeAccuracy {
+		t.Errorf("Merge() error = %v, want %v", err, ErrDDSketchMergeAccuracy)
+	}
+}
+
+func TestDDSketch_MarshalBinary(t *testing.T) {
+	s := newTestDDSketch(t, 0.01, 1024)
+	for i := -1000; i <= 10000; i++ {
+		s.Add(float64(i) * 1.5)
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var snap DDSketchSnapshot
+	if err = snap.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if !reflect.DeepEqual(s.Snapshot(), &snap) {
+		t.Errorf("UnmarshalBinary() = %+v, want %+v", snap.Stats(), s.Stats())
+	}
+
+	// merge decoded snapshot (for example, received from other process)
+	m := newTestDDSketch(t, 0.01, 1024)
+	if err = m.Merge(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Quantiles([]float64{0.5, 0.99}), s.Quantiles([]float64{0.5, 0.99}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge(decoded) Quantiles() = %v, want %v", got, want)
+	}
+
+	for _, bad := range [][]byte{nil, {2}, data[:len(data)-1], append(append([]byte{}, data...), 1)} {
+		if err = snap.UnmarshalBinary(bad); err != ErrDDSketchEncoding {
+			t.Errorf("UnmarshalBinary(%v) error = %v, want %v", bad, err, ErrDDSketchEncoding)
+		}
+	}
+
+	empty, _ := newTestDDSketch(t, 0.05, 0).MarshalBinary()
+	if err = snap.UnmarshalBinary(empty); err != nil || snap.Count() != 0 || snap.RelativeAccuracy() != 0.05 {
+		t.Errorf("UnmarshalBinary(empty) count = %d, accuracy = %v, error = %v", snap.Count(), snap.RelativeAccuracy(), err)
+	}
+}
+
+func TestDDSketchSnapshot_UnmarshalBinaryOffset(t *testing.T) {
+	sketch, _ := newDDSketch(0.01, 0)
+	minIdx, maxIdx := int64(sketch.index(sketch.minIndexable)), int64(sketch.index(math.MaxFloat64))
+
+	tests := []struct {
+		name   string
+		offset int64
+		bins   int
+		want   error
+	}{
+		{name: "lowest", offset: minIdx, bins: 1},
+		{name: "highest", offset: maxIdx, bins: 1},
+		{name: "full range", offset: minIdx, bins: int(maxIdx - minIdx + 1)},
+		{name: "empty store", offset: math.MaxInt64, bins: 0},
+		{name: "below lowest", offset: minIdx - 1, bins: 1, want: ErrDDSketchEncoding},
+		{name: "above highest", offset: maxIdx + 1, bins: 1, want: ErrDDSketchEncoding},
+		{name: "bins over highest", offset: maxIdx, bins: 2, want: ErrDDSketchEncoding},
+		{name: "MinInt64", offset: math.MinInt64, bins: 1, want: ErrDDSketchEncoding},
+		{name: "MaxInt64", offset: math.MaxInt64, bins: 1, want: ErrDDSketchEncoding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := binaryWriter{}
+			w.uvarint(ddSketchEncodingVersion)
+			w.float64(0.01)
+			w.uvarint(0)
+			w.uvarint(uint64(tt.bins))
+			w.float64(1)
+			w.float64(1)
+			w.float64(1)
+			w.uvarint(0) // zero count
+			// empty negative store
+			w.varint(0)
+			w.uvarint(0)
+			// positive store
+			w.varint(tt.offset)
+			w.uvarint(uint64(tt.bins))
+			for i := 0; i < tt.bins; i++ {
+				w.uvarint(1)
+			}
+
+			var snap DDSketchSnapshot
+			if err := snap.UnmarshalBinary(w.data); err != tt.want {
+				t.Errorf("UnmarshalBinary() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDDSketch_Concurrent(t *testing.T) {
+	s := newTestDDSketch(t, 0.01, 0)
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s.Add(float64(i))
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		s.Quantile(0.5)
+	}
+	wg.Wait()
+	if s.Count() != 8000 || s.Sum() != 8*999*500 {
+		t.Errorf("Count() = %d, Sum() = %v, want 8000, %d", s.Count(), s.Sum(), 8*999*500)
+	}
+}
+
+func BenchmarkDDSketch(b *testing.B) {
+	s := newTestDDSketch(b, 0.01, 2048)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Add(float64(i))
+	}
+}
+
+func BenchmarkDDSketchParallel(b *testing.B) {
+	s := newTestDDSketch(b, 0.01, 2048)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i float64
+		for pb.Next() {
+			s.Add(i)
+			i++
+		}
+	})
+}
+
+func newTestDDSketch(tb testing.TB, relativeAccuracy float64, maxBins int) DDSketch {
+	s, err := NewDDSketch(relativeAccuracy, maxBins)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return s
+}
@@ -11,8 +11,10 @@ import (
 )
 
 type exp struct {
-	registry metrics.Registry
-	minLock  bool
+	registry        metrics.Registry
+	minLock         bool
+	percentiles     []float64
+	percentilesKeys []string
 }
 
 func (exp *exp) expHandler(w http.ResponseWriter, r *http.Request) {
@@ -27,6 +29,10 @@ func (exp *exp) expHandler(w http.ResponseWriter, r *http.Request) {
 		} else {
 			fmt.Fprint(w, ",")
 		}
+		if h, ok := i.(metrics.HistogramInterface); ok {
+			// buckets, stats and quantiles are read once (exp histogram buckets layout may change with scale)
+			i = metrics.SnapshotHistogram(h)
+		}
 		switch metric := i.(type) {
 		case metrics.Counter:
 			fmt.Fprintf(w, "\n  \"%s%s\": %d", name, tags, metric.Count())
@@ -43,40 +49,58 @@ func (exp *exp) expHandler(w http.ResponseWriter, r *http.Request) {
 		case metrics.HistogramInterface:
 			vals := metric.Values()
 			leAliases := metric.WeightsAliases()
-			if metric.IsSummed() {
-				for i, label := range metric.Labels() {
-					if i > 0 {
-						fmt.Fprint(w, ",")
-					}
-					if tags == "" {
-						fmt.Fprintf(w, "\n  \"%s%s%s\": %d", name, label, tags, vals[i])
-					} else {
-						fmt.Fprintf(w, "\n  \"%s%s%s\": %d", name, label, tags+";le="+leAliases[i], vals[i])
-					}
-				}
-				if tags == "" {
-					fmt.Fprintf(w, ",\n  \"%s%s%s\": %d", name, metric.NameTotal(), tags, vals[0])
+			// empty histograms (like exp histogram without observations) has no labels
+			first := true
+			var total uint64
+			for i, label := range metric.Labels() {
+				if first {
+					first = false
 				} else {
-					fmt.Fprintf(w, ",\n  \"%s%s%s\": %d", name, metric.NameTotal(), tags, vals[0])
-				}
-			} else {
-				var total uint64
-				for i, label := range metric.Labels() {
-					if i > 0 {
-						fmt.Fprint(w, ",")
-					}
-					if tags == "" {
-						fmt.Fprintf(w, "\n  \"%s%s%s\": %d", name, label, tags, vals[i])
-					} else {
-						fmt.Fprintf(w, "\n  \"%s%s%s\": %d", name, label, tags+";le="+leAliases[i], vals[i])
-					}
-					total += vals[i]
+					fmt.Fprint(w, ",")
 				}
 				if tags == "" {
-					fmt.Fprintf(w, ",\n  \"%s%s%s\": %d", name, metric.NameTotal(), tags, total)
+					fmt.Fprintf(w, "\n  \"%s%s%s\": %d", name, label, tags, vals[i])
 				} else {
-					fmt.Fprintf(w, ",\n  \"%s%s%s\": %d", name, metric.NameTotal(), tags, total)
+					fmt.Fprintf(w, "\n  \"%s%s%s\": %d", name, label, tags+";le="+leAliases[i], vals[i])
 				}
+				total += vals[i]
+			}
+			if metric.IsSummed() && len(vals) > 0 {
+				total = vals[0]
+			}
+			if !first {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, "\n  \"%s%s%s\": %d", name, metric.NameTotal(), tags, total)
+			stats := metric.Stats()
+			fmt.Fprintf(w, ",\n  \"%s.sum%s\": %f", name, tags, stats.Sum)
+			fmt.Fprintf(w, ",\n  \"%s.min%s\": %f", name, tags, stats.Min)
+			fmt.Fprintf(w, ",\n  \"%s.max%s\": %f", name, tags, stats.Max)
+			fmt.Fprintf(w, ",\n  \"%s.mean%s\": %f", name, tags, stats.Mean())
+			// estimated from the same snapshot buckets
+			ps := metric.Quantiles(exp.percentiles)
+			for i, key := range exp.percentilesKeys {
+				fmt.Fprintf(w, ",\n  \"%s%s%s\": %f", name, key, tags, ps[i])
+			}
+		case metrics.HDRHistogram:
+			h := metric.Snapshot()
+			ps := h.Percentiles(exp.percentiles)
+			fmt.Fprintf(w, "\n  \"%s.count%s\": %d", name, tags, h.Count())
+			fmt.Fprintf(w, ",\n  \"%s.min%s\": %d", name, tags, h.Min())
+			fmt.Fprintf(w, ",\n  \"%s.max%s\": %d", name, tags, h.Max())
+			fmt.Fprintf(w, ",\n  \"%s.mean%s\": %f", name, tags, h.Mean())
+			for i, key := range exp.percentilesKeys {
+				fmt.Fprintf(w, ",\n  \"%s%s%s\": %d", name, key, tags, ps[i])
+			}
+		case metrics.DDSketch:
+			s := metric.Snapshot()
+			ps := s.Quantiles(exp.percentiles)
+			fmt.Fprintf(w, "\n  \"%s.count%s\": %d", name, tags, s.Count())
+			fmt.Fprintf(w, ",\n  \"%s.min%s\": %f", name, tags, s.Min())
+			fmt.Fprintf(w, ",\n  \"%s.max%s\": %f", name, tags, s.Max())
+			fmt.Fprintf(w, ",\n  \"%s.mean%s\": %f", name, tags, s.Mean())
+			for i, key := range exp.percentilesKeys {
+				fmt.Fprintf(w, ",\n  \"%s%s%s\": %f", name, key, tags, ps[i])
 			}
 		case metrics.Rate:
 			v, rate := metric.Values()
@@ -100,8 +124,8 @@ func (exp *exp) expHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // Exp will register an expvar powered metrics handler with http.DefaultServeMux on "/debug/vars"
-func Exp(r metrics.Registry, minLock bool) {
-	h := ExpHandler(r, minLock)
+func Exp(r metrics.Registry, minLock bool, percentiles ...float64) {
+	h := ExpHandler(r, minLock, percentiles...)
 	// this would cause a panic:
 	// panic: http: multiple registrations for /debug/vars
 	// http.HandleFunc("/debug/vars", e.expHandler)
@@ -110,7 +134,14 @@ func Exp(r metrics.Registry, minLock bool) {
 }
 
 // ExpHandler will return an expvar powered metrics handler.
-func ExpHandler(r metrics.Registry, minLock bool) http.Handler {
-	e := exp{registry: r, minLock: minLock}
+// Histograms percentiles are metrics.DefaultPercentiles, if not set.
+func ExpHandler(r metrics.Registry, minLock bool, percentiles ...float64) http.Handler {
+	if len(percentiles) == 0 {
+		percentiles = metrics.DefaultPercentiles
+	}
+	e := exp{registry: r, minLock: minLock, percentiles: percentiles, percentilesKeys: make([]string, len(percentiles))}
+	for i, p := range percentiles {
+		e.percentilesKeys[i] = "." + metrics.PercentileKey(p)
+	}
 	return http.HandlerFunc(e.expHandler)
 }
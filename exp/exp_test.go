@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
@@ -49,13 +50,33 @@ func TestExp(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	ht := metrics.NewVHistogram([]int64{1, 2, 5, 8, 20}, nil).AddLabelPrefix("req_")
+	ht, err := metrics.NewVHistogram([]int64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.AddLabelPrefix("req_")
 	ht.Add(2)
 	ht.Add(6)
 	if err := r.RegisterT("histogram", map[string]string{"tag1": "value1", "tag21": "value21"}, ht); err != nil {
 		t.Fatal(err)
 	}
 
+	hdr, err := metrics.GetOrRegisterHDRHistogram("hdr", r, 1, 1000000, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []int64{100, 200, 300, 400} {
+		hdr.Add(v)
+	}
+
+	sketch, err := metrics.GetOrRegisterDDSketch("sketch", r, 0.01, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []float64{100, 200, 300, 400} {
+		sketch.Add(v)
+	}
+
 	rate := metrics.GetOrRegisterRate("ratefoo", r).SetName("_value").SetRateName("_rate")
 	rate.UpdateTs(1, 1e9)
 	rate.UpdateTs(7, 3e9)
@@ -111,6 +132,10 @@ func TestExp(t *testing.T) {
 		"histogram.req_3":                 0,
 		"histogram.req_inf":               1,
 		"histogram.total":                 2,
+		"histogram.sum":                   8,
+		"histogram.min":                   2,
+		"histogram.max":                   6,
+		"histogram.mean":                  4,
 		"histogram.req_1;tag1=value1;tag21=value21;le=1":     0,
 		"histogram.req_2;tag1=value1;tag21=value21;le=2":     1,
 		"histogram.req_5;tag1=value1;tag21=value21;le=5":     0,
@@ -118,19 +143,103 @@ func TestExp(t *testing.T) {
 		"histogram.req_20;tag1=value1;tag21=value21;le=20":   0,
 		"histogram.req_inf;tag1=value1;tag21=value21;le=inf": 0,
 		"histogram.total;tag1=value1;tag21=value21":          2,
+		"histogram.sum;tag1=value1;tag21=value21":            8,
+		"histogram.min;tag1=value1;tag21=value21":            2,
+		"histogram.max;tag1=value1;tag21=value21":            6,
+		"histogram.mean;tag1=value1;tag21=value21":           4,
 		"shistogram.req_1":   1,
 		"shistogram.req_2":   1,
 		"shistogram.req_3":   0,
 		"shistogram.req_inf": 0,
 		"shistogram.total":   1,
+		"shistogram.sum":     2,
+		"shistogram.min":     2,
+		"shistogram.max":     2,
+		"shistogram.mean":    2,
+		"hdr.count":          4,
+		"hdr.min":            100,
+		"hdr.max":            400,
+		"hdr.mean":           250,
+		"hdr.50-percentile":  200,
+		"hdr.75-percentile":  300,
+		"hdr.95-percentile":  400,
+		"hdr.99-percentile":  400,
+		"hdr.999-percentile": 400,
+		"sketch.count":       4,
+		"sketch.min":         100,
+		"sketch.max":         400,
+		"sketch.mean":        250,
 		"ratefoo_value":      7,
 		"ratefoo_rate":       3,
 		"ratefoo2.value":     8,
 		"ratefoo2.rate":      3,
 	}
 	if err = json.Unmarshal(body, &got); err == nil {
+		// quantiles estimated with relative accuracy
+		for key, v := range map[string]float64{
+			"sketch.50-percentile":  200,
+			"sketch.75-percentile":  300,
+			"sketch.95-percentile":  300,
+			"sketch.99-percentile":  300,
+			"sketch.999-percentile": 300,
+			// estimated from buckets (linear interpolation)
+			"histogram.50-percentile":                            2,
+			"histogram.75-percentile":                            4.5,
+			"histogram.95-percentile":                            5.7,
+			"histogram.99-percentile":                            5.94,
+			"histogram.999-percentile":                           5.994,
+			"histogram.50-percentile;tag1=value1;tag21=value21":  2,
+			"histogram.75-percentile;tag1=value1;tag21=value21":  5.5,
+			"histogram.95-percentile;tag1=value1;tag21=value21":  5.9,
+			"histogram.99-percentile;tag1=value1;tag21=value21":  5.98,
+			"histogram.999-percentile;tag1=value1;tag21=value21": 5.998,
+			"shistogram.50-percentile":                           2,
+			"shistogram.75-percentile":                           2,
+			"shistogram.95-percentile":                           2,
+			"shistogram.99-percentile":                           2,
+			"shistogram.999-percentile":                          2,
+		} {
+			assert.InDelta(t, v, got[key], v*0.01, key)
+			delete(got, key)
+		}
 		assert.Equal(t, want, got)
 	} else {
 		t.Fatal(err, "\n", string(body))
 	}
 }
+
+func TestExp_EmptyExpHistogram(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("a", r).Add(1)
+	metrics.GetOrRegisterExpHistogram("b", r, 160, 0)
+
+	w := httptest.NewRecorder()
+	ExpHandler(r, false).ServeHTTP(w, httptest.NewRequest("GET", "/debug/metrics", nil))
+	if body := w.Body.Bytes(); !json.Valid(body) {
+		t.Errorf("invalid json:\n%s", body)
+	}
+}
+
+func TestExp_Percentiles(t *testing.T) {
+	r := metrics.NewRegistry()
+	hdr, err := metrics.GetOrRegisterHDRHistogram("hdr", r, 1, 1000, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr.Add(100)
+
+	w := httptest.NewRecorder()
+	ExpHandler(r, false, 0.9).ServeHTTP(w, httptest.NewRequest("GET", "/debug/metrics", nil))
+	var got map[string]float64
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err, "\n", w.Body.String())
+	}
+	want := map[string]float64{
+		"hdr.count":         1,
+		"hdr.min":           100,
+		"hdr.max":           100,
+		"hdr.mean":          100,
+		"hdr.90-percentile": 100,
+	}
+	assert.Equal(t, want, got)
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -28,7 +26,7 @@ type Config struct {
 
 	MinLock bool `toml:"min_lock" yaml:"min_lock" json:"min_lock"` // Minimize time of read-locking of metric registry (but with some costs), set if application do dynamic metrics register/unregister
 
-	Percentiles []float64 `toml:"percentiles" yaml:"percentiles" json:"percentiles"` // Percentiles to export from timers and histograms
+	Percentiles []float64 `toml:"percentiles" yaml:"percentiles" json:"percentiles"` // Percentiles to export from histograms (estimated from buckets for bucket histograms)
 	percentiles []string  `toml:"-" yaml:"-" json:"-"`                               // Percentiles keys (pregenerated)
 
 	Tags map[string]string `toml:"tags" yaml:"tags" json:"tags"` // Tags for sended metrics (not used directky in graphite client, merge it with  metric individual tags)
@@ -55,8 +53,7 @@ func setDefaults(c *Config) {
 	}
 	c.percentiles = make([]string, 0, len(c.Percentiles))
 	for _, p := range c.Percentiles {
-		key := strings.Replace(strconv.FormatFloat(p*100.0, 'f', -1, 64), ".", "", 1)
-		c.percentiles = append(c.percentiles, "."+key+"-percentile")
+		c.percentiles = append(c.percentiles, "."+metrics.PercentileKey(p))
 	}
 }
 
@@ -76,6 +73,8 @@ type Graphite struct {
 	loggerSuccess func()
 	loggerError   func(error)
 
+	connMetrics *metrics.NetMetrics
+
 	stop chan struct{}
 	wg   sync.WaitGroup
 }
@@ -89,7 +88,7 @@ func New(flushInterval time.Duration, prefix string, host string, timeout time.D
 		FlushInterval:  flushInterval,
 		DurationUnit:   time.Nanosecond,
 		Prefix:         prefix,
-		Percentiles:    []float64{0.5, 0.75, 0.95, 0.99, 0.999},
+		Percentiles:    metrics.DefaultPercentiles,
 		Timeout:        timeout,
 		ConnectTimeout: timeout,
 	})
@@ -131,6 +130,11 @@ func (g *Graphite) SetLoggerError(f func(error)) {
 	g.loggerError = f
 }
 
+// SetConnMetrics instruments graphite connections with m (see metrics.NewNetMetrics), must be called before Start.
+func (g *Graphite) SetConnMetrics(m *metrics.NetMetrics) {
+	g.connMetrics = m
+}
+
 func (g *Graphite) Start(r metrics.Registry) {
 	g.wg.Add(1)
 	g.stop = make(chan struct{})
@@ -176,10 +180,21 @@ func (g *Graphite) Close() error {
 }
 
 func (g *Graphite) connect() error {
-	var err error
+	if g.conn != nil {
+		g.conn.Close()
+		g.conn = nil
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
 	for i := 0; i < g.c.Retry; i++ {
-		g.conn, err = net.DialTimeout("tcp", g.c.Host, g.c.ConnectTimeout)
+		conn, err = net.DialTimeout("tcp", g.c.Host, g.c.ConnectTimeout)
 		if nil == err {
+			if g.connMetrics != nil {
+				conn = metrics.WrapConn(conn, g.connMetrics)
+			}
+			g.conn = conn
 			return nil
 		}
 		time.Sleep(10 * time.Millisecond)
@@ -295,6 +310,19 @@ func (g *Graphite) writeFloatMetric(name, postfix string, tags string, v float64
 	return nil
 }
 
+func (g *Graphite) writeHistogramStats(name, tags string, stats metrics.HistogramStats, ts int64) (err error) {
+	if err = g.writeFloatMetric(name, ".sum", tags, stats.Sum, ts); err != nil {
+		return
+	}
+	if err = g.writeFloatMetric(name, ".min", tags, stats.Min, ts); err != nil {
+		return
+	}
+	if err = g.writeFloatMetric(name, ".max", tags, stats.Max, ts); err != nil {
+		return
+	}
+	return g.writeFloatMetric(name, ".mean", tags, stats.Mean(), ts)
+}
+
 func (g *Graphite) flush() (err error) {
 	if g.buf.Len() > 0 {
 		if g.conn == nil {
@@ -326,8 +354,6 @@ func (g *Graphite) send(r metrics.Registry) error {
 	var err error
 
 	now := time.Now().Unix()
-	// du := float64(g.c.DurationUnit)
-	// flushSeconds := float64(g.c.FlushInterval) / float64(time.Second)
 
 	if g.conn == nil {
 		if err = g.connect(); err != nil {
@@ -340,21 +366,20 @@ func (g *Graphite) send(r metrics.Registry) error {
 	}
 
 	r.Each(func(name, tags string, tagsMap map[string]string, i interface{}) error {
+		if h, ok := i.(metrics.HistogramInterface); ok {
+			// buckets, stats and quantiles are read once (exp histogram buckets layout may change with scale)
+			i = metrics.SnapshotHistogram(h)
+		}
 		switch metric := i.(type) {
 		case metrics.Counter:
-			// count := metric.Count()
-			// fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, count, now)
 			if err = g.writeUintMetric(name, "", tags, metric.Count(), now); err != nil {
 				return err
 			}
 		case metrics.DownCounter:
-			// count := metric.Count()
-			// fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, count, now)
 			if err = g.writeIntMetric(name, "", tags, metric.Count(), now); err != nil {
 				return err
 			}
 		case metrics.Gauge:
-			// fmt.Fprintf(w, "%s.%s.value %d %d\n", c.Prefix, name, metric.Value(), now)
 			if err = g.writeIntMetric(name, "", tags, metric.Value(), now); err != nil {
 				return err
 			}
@@ -363,7 +388,6 @@ func (g *Graphite) send(r metrics.Registry) error {
 				return err
 			}
 		case metrics.FGauge:
-			// fmt.Fprintf(w, "%s.%s.value %f %d\n", c.Prefix, name, metric.Value(), now)
 			if err = g.writeFloatMetric(name, "", tags, metric.Value(), now); err != nil {
 				return err
 			}
@@ -395,6 +419,57 @@ func (g *Graphite) send(r metrics.Registry) error {
 					return err
 				}
 			}
+			if err = g.writeHistogramStats(name, tags, metric.Stats(), now); err != nil {
+				return err
+			}
+			if len(g.c.Percentiles) > 0 {
+				ps := metric.Quantiles(g.c.Percentiles)
+				for psIdx, psKey := range g.c.percentiles {
+					if err = g.writeFloatMetric(name, psKey, tags, ps[psIdx], now); err != nil {
+						return err
+					}
+				}
+			}
+		case metrics.HDRHistogram:
+			h := metric.Snapshot()
+			if err = g.writeUintMetric(name, ".count", tags, h.Count(), now); err != nil {
+				return err
+			}
+			if err = g.writeIntMetric(name, ".min", tags, h.Min(), now); err != nil {
+				return err
+			}
+			if err = g.writeIntMetric(name, ".max", tags, h.Max(), now); err != nil {
+				return err
+			}
+			if err = g.writeFloatMetric(name, ".mean", tags, h.Mean(), now); err != nil {
+				return err
+			}
+			ps := h.Percentiles(g.c.Percentiles)
+			for psIdx, psKey := range g.c.percentiles {
+				if err = g.writeIntMetric(name, psKey, tags, ps[psIdx], now); err != nil {
+					return err
+				}
+			}
+		case metrics.DDSketch:
+			s := metric.Snapshot()
+			if err = g.writeUintMetric(name, ".count", tags, s.Count(), now); err != nil {
+				return err
+			}
+			if err = g.writeFloatMetric(name, ".min", tags, s.Min(), now); err != nil {
+				return err
+			}
+			if err = g.writeFloatMetric(name, ".max", tags, s.Max(), now); err != nil {
+				return err
+			}
+			if err = g.writeFloatMetric(name, ".mean", tags, s.Mean(), now); err != nil {
+				return err
+			}
+			ps := s.Quantiles(g.c.Percentiles)
+			for psIdx, psKey := range g.c.percentiles {
+				if err = g.writeFloatMetric(name, psKey, tags, ps[psIdx], now); err != nil {
+					return err
+				}
+			}
 		case metrics.Rate:
 			v, rate := metric.Values()
 			if err = g.writeIntMetric(name, metric.Name(), tags, v, now); err != nil {
@@ -411,65 +486,6 @@ func (g *Graphite) send(r metrics.Registry) error {
 			if err = g.writeFloatMetric(name, metric.RateName(), tags, rate, now); err != nil {
 				return err
 			}
-		// case metrics.Histogram:
-		// 	h := metric.Snapshot()
-		// 	ps := h.Percentiles(g.c.Percentiles)
-		// 	// fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, h.Count(), now)
-		// 	g.writeIntMetric(name, ".count", tags, h.Count(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.min %d %d\n", c.Prefix, name, h.Min(), now)
-		// 	g.writeIntMetric(name, ".min", tags, h.Min(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.max %d %d\n", c.Prefix, name, h.Max(), now)
-		// 	g.writeIntMetric(name, ".max", tags, h.Max(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, h.Mean(), now)
-		// 	g.writeFloatMetric(name, ".mean", tags, h.Mean(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.std-dev %.2f %d\n", c.Prefix, name, h.StdDev(), now)
-		// 	g.writeFloatMetric(name, ".std-dev", tags, h.StdDev(), now)
-		// 	for psIdx, psKey := range g.c.percentiles {
-		// 		// key := strings.Replace(strconv.FormatFloat(psKey*100.0, 'f', -1, 64), ".", "", 1)
-		// 		// fmt.Fprintf(w, "%s.%s.%s-percentile %.2f %d\n", c.Prefix, name, key, ps[psIdx], now)
-		// 		g.writeFloatMetric(name, psKey, tags, ps[psIdx], now)
-		// 	}
-		// case metrics.Meter:
-		// 	m := metric.Snapshot()
-		// 	// fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, m.Count(), now)
-		// 	g.writeIntMetric(name, ".count", tags, m.Count(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.one-minute %.2f %d\n", c.Prefix, name, m.Rate1(), now)
-		// 	g.writeFloatMetric(name, ".one-minute", tags, m.Rate1(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.five-minute %.2f %d\n", c.Prefix, name, m.Rate5(), now)
-		// 	g.writeFloatMetric(name, ".five-minute", tags, m.Rate5(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.fifteen-minute %.2f %d\n", c.Prefix, name, m.Rate15(), now)
-		// 	g.writeFloatMetric(name, ".fifteen-minute", tags, m.Rate15(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, m.RateMean(), now)
-		// 	g.writeFloatMetric(name, ".mean", tags, m.RateMean(), now)
-		// case metrics.Timer:
-		// 	t := metric.Snapshot()
-		// 	ps := t.Percentiles(g.c.Percentiles)
-		// 	count := t.Count()
-		// 	// fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, count, now)
-		// 	g.writeIntMetric(name, ".count", tags, count, now)
-		// 	// fmt.Fprintf(w, "%s.%s.count_ps %.2f %d\n", c.Prefix, name, float64(count)/flushSeconds, now)
-		// 	g.writeFloatMetric(name, ".count_ps", tags, float64(count)/flushSeconds, now)
-		// 	// fmt.Fprintf(w, "%s.%s.min %d %d\n", c.Prefix, name, t.Min()/int64(du), now)
-		// 	g.writeIntMetric(name, ".min", tags, t.Min()/int64(du), now)
-		// 	// fmt.Fprintf(w, "%s.%s.max %d %d\n", c.Prefix, name, t.Max()/int64(du), now)
-		// 	g.writeIntMetric(name, ".max", tags, t.Max()/int64(du), now)
-		// 	// fmt.Fprintf(w, "%s.%s.mean %.2f %d\n", c.Prefix, name, t.Mean()/du, now)
-		// 	g.writeFloatMetric(name, ".mean", tags, t.Mean()/du, now)
-		// 	// fmt.Fprintf(w, "%s.%s.std-dev %.2f %d\n", c.Prefix, name, t.StdDev()/du, now)
-		// 	g.writeFloatMetric(name, ".std-dev", tags, t.StdDev()/du, now)
-		// 	for psIdx, psKey := range g.c.percentiles {
-		// 		// key := strings.Replace(strconv.FormatFloat(psKey*100.0, 'f', -1, 64), ".", "", 1)
-		// 		// fmt.Fprintf(w, "%s.%s.%s-percentile %.2f %d\n", c.Prefix, name, key, ps[psIdx]/du, now)
-		// 		g.writeFloatMetric(name, psKey, tags, ps[psIdx]/du, now)
-		// 	}
-		// 	// fmt.Fprintf(w, "%s.%s.one-minute %.2f %d\n", c.Prefix, name, t.Rate1(), now)
-		// 	g.writeFloatMetric(name, ".one-minute", tags, t.Rate1(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.five-minute %.2f %d\n", c.Prefix, name, t.Rate5(), now)
-		// 	g.writeFloatMetric(name, ".five-minute", tags, t.Rate5(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.fifteen-minute %.2f %d\n", c.Prefix, name, t.Rate15(), now)
-		// 	g.writeFloatMetric(name, ".fifteen-minute", tags, t.Rate15(), now)
-		// 	// fmt.Fprintf(w, "%s.%s.mean-rate %.2f %d\n", c.Prefix, name, t.RateMean(), now)
-		// 	g.writeFloatMetric(name, ".mean-rate", tags, t.RateMean(), now)
 		default:
 			g.loggerError(fmt.Errorf("unable to record metric of type %T", i))
 		}
@@ -186,7 +186,10 @@ func BenchmarkFGaugeT(b *testing.B) {
 func BenchmarkVHistogram(b *testing.B) {
 	_, l, r, cfg, wg := newBenchServer(b, "foobar")
 
-	h := metrics.NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	h, err := metrics.NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	if err := r.Register("histogram", h); err != nil {
 		l.Close()
 		wg.Wait()
@@ -212,7 +215,10 @@ func BenchmarkVHistogram(b *testing.B) {
 func BenchmarkVHistogramT(b *testing.B) {
 	_, l, r, cfg, wg := newBenchServer(b, "foobar")
 
-	h := metrics.NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	h, err := metrics.NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	if err := r.RegisterT("histogram", map[string]string{"tag1": "value1", "tag21": "value21"}, h); err != nil {
 		l.Close()
 		wg.Wait()
@@ -238,7 +244,10 @@ func BenchmarkVHistogramT(b *testing.B) {
 func BenchmarkFUHistogram(b *testing.B) {
 	_, l, r, cfg, wg := newBenchServer(b, "foobar")
 
-	h := metrics.NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	h, err := metrics.NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	if err := r.Register("histogram", h); err != nil {
 		l.Close()
 		wg.Wait()
@@ -264,7 +273,10 @@ func BenchmarkFUHistogram(b *testing.B) {
 func BenchmarkFUHistogramT(b *testing.B) {
 	_, l, r, cfg, wg := newBenchServer(b, "foobar")
 
-	h := metrics.NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	h, err := metrics.NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	if err := r.RegisterT("histogram", map[string]string{"tag1": "value1", "tag21": "value21"}, h); err != nil {
 		l.Close()
 		wg.Wait()
@@ -23,7 +23,10 @@ func TestWritesT(t *testing.T) {
 	metrics.GetOrRegisterGaugeT("gauge", map[string]string{"tag1": "value1", "tag21": "value21"}, r).Update(3)
 	metrics.GetOrRegisterFGaugeT("gauge_float", map[string]string{"tag1": "value1", "tag21": "value21"}, r).Update(2.1)
 
-	h := metrics.NewVHistogram([]int64{1, 2, 5, 8, 20}, nil)
+	h, err := metrics.NewVHistogram([]int64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.Add(2)
 	h.Add(6)
 	if err := r.RegisterT("histogram", map[string]string{"tag1": "value1", "tag21": "value21"}, h); err != nil {
@@ -36,6 +39,26 @@ func TestWritesT(t *testing.T) {
 	sh.Add(2)
 	sh.Add(6)
 
+	hdr, err := metrics.GetOrRegisterHDRHistogramT("hdr", map[string]string{"tag1": "value1", "tag21": "value21"}, r, 1, 1000000, 3)
+	if err != nil {
+		l.Close()
+		wg.Wait()
+		t.Fatal(err)
+	}
+	for _, v := range []int64{100, 200, 300, 400} {
+		hdr.Add(v)
+	}
+
+	sketch, err := metrics.GetOrRegisterDDSketchT("sketch", map[string]string{"tag1": "value1", "tag21": "value21"}, r, 0.01, 0)
+	if err != nil {
+		l.Close()
+		wg.Wait()
+		t.Fatal(err)
+	}
+	for _, v := range []float64{100, 200, 300, 400} {
+		sketch.Add(v)
+	}
+
 	rate := metrics.GetOrRegisterRateT("ratefoo", map[string]string{"tag1": "value1", "tag21": "value21"}, r).SetName("_value").SetRateName("_rate")
 	rate.UpdateTs(1, 1e9)
 	rate.UpdateTs(7, 3e9)
@@ -83,19 +106,53 @@ func TestWritesT(t *testing.T) {
 		"footag.gauge;tag1=value1;tag21=value21":       {V: 3.0},
 		"footag.gauge_float;tag1=value1;tag21=value21": {V: 2.1},
 		// histogram
-		"footag.histogram.1;tag1=value1;tag21=value21;le=1":     {V: 0},
-		"footag.histogram.2;tag1=value1;tag21=value21;le=2":     {V: 1},
-		"footag.histogram.5;tag1=value1;tag21=value21;le=5":     {V: 0},
-		"footag.histogram.8;tag1=value1;tag21=value21;le=8":     {V: 1},
-		"footag.histogram.20;tag1=value1;tag21=value21;le=20":   {V: 0},
-		"footag.histogram.inf;tag1=value1;tag21=value21;le=inf": {V: 0},
-		"footag.histogram.total;tag1=value1;tag21=value21":      {V: 2},
+		"footag.histogram.1;tag1=value1;tag21=value21;le=1":         {V: 0},
+		"footag.histogram.2;tag1=value1;tag21=value21;le=2":         {V: 1},
+		"footag.histogram.5;tag1=value1;tag21=value21;le=5":         {V: 0},
+		"footag.histogram.8;tag1=value1;tag21=value21;le=8":         {V: 1},
+		"footag.histogram.20;tag1=value1;tag21=value21;le=20":       {V: 0},
+		"footag.histogram.inf;tag1=value1;tag21=value21;le=inf":     {V: 0},
+		"footag.histogram.total;tag1=value1;tag21=value21":          {V: 2},
+		"footag.histogram.sum;tag1=value1;tag21=value21":            {V: 8},
+		"footag.histogram.min;tag1=value1;tag21=value21":            {V: 2},
+		"footag.histogram.max;tag1=value1;tag21=value21":            {V: 6},
+		"footag.histogram.mean;tag1=value1;tag21=value21":           {V: 4},
+		"footag.histogram.50-percentile;tag1=value1;tag21=value21":  {V: 2},
+		"footag.histogram.75-percentile;tag1=value1;tag21=value21":  {V: 5.5},
+		"footag.histogram.99-percentile;tag1=value1;tag21=value21":  {V: 5.98},
+		"footag.histogram.999-percentile;tag1=value1;tag21=value21": {V: 6},
 		// shistogram
 		"footag.shistogram.req_1;tag1=value1;tag21=value21;le=1":     {V: 2.0},
 		"footag.shistogram.req_2;tag1=value1;tag21=value21;le=2":     {V: 2.0},
 		"footag.shistogram.req_3;tag1=value1;tag21=value21;le=3":     {V: 1.0},
 		"footag.shistogram.req_inf;tag1=value1;tag21=value21;le=inf": {V: 1.0},
 		"footag.shistogram.total;tag1=value1;tag21=value21":          {V: 2.0},
+		"footag.shistogram.sum;tag1=value1;tag21=value21":            {V: 8},
+		"footag.shistogram.min;tag1=value1;tag21=value21":            {V: 2},
+		"footag.shistogram.max;tag1=value1;tag21=value21":            {V: 6},
+		"footag.shistogram.mean;tag1=value1;tag21=value21":           {V: 4},
+		"footag.shistogram.50-percentile;tag1=value1;tag21=value21":  {V: 2},
+		"footag.shistogram.75-percentile;tag1=value1;tag21=value21":  {V: 4.5},
+		"footag.shistogram.99-percentile;tag1=value1;tag21=value21":  {V: 5.94},
+		"footag.shistogram.999-percentile;tag1=value1;tag21=value21": {V: 5.99},
+		// hdr histogram
+		"footag.hdr.count;tag1=value1;tag21=value21":          {V: 4},
+		"footag.hdr.min;tag1=value1;tag21=value21":            {V: 100},
+		"footag.hdr.max;tag1=value1;tag21=value21":            {V: 400},
+		"footag.hdr.mean;tag1=value1;tag21=value21":           {V: 250},
+		"footag.hdr.50-percentile;tag1=value1;tag21=value21":  {V: 200},
+		"footag.hdr.75-percentile;tag1=value1;tag21=value21":  {V: 300},
+		"footag.hdr.99-percentile;tag1=value1;tag21=value21":  {V: 400},
+		"footag.hdr.999-percentile;tag1=value1;tag21=value21": {V: 400},
+		// ddsketch
+		"footag.sketch.count;tag1=value1;tag21=value21":          {V: 4},
+		"footag.sketch.min;tag1=value1;tag21=value21":            {V: 100},
+		"footag.sketch.max;tag1=value1;tag21=value21":            {V: 400},
+		"footag.sketch.mean;tag1=value1;tag21=value21":           {V: 250},
+		"footag.sketch.50-percentile;tag1=value1;tag21=value21":  {V: 200, Dev: 2},
+		"footag.sketch.75-percentile;tag1=value1;tag21=value21":  {V: 300, Dev: 3},
+		"footag.sketch.99-percentile;tag1=value1;tag21=value21":  {V: 300, Dev: 3},
+		"footag.sketch.999-percentile;tag1=value1;tag21=value21": {V: 300, Dev: 3},
 		// rate
 		"footag.ratefoo_value;tag1=value1;tag21=value21":  {V: 7},
 		"footag.ratefoo_rate;tag1=value1;tag21=value21":   {V: 3},
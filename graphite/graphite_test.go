@@ -98,7 +98,10 @@ func TestWrites(t *testing.T) {
 	metrics.GetOrRegisterUGauge("ugauge", r).Update(1)
 	metrics.GetOrRegisterFGauge("gauge_float", r).Update(2.1)
 
-	h := metrics.GetOrRegisterVHistogram("histogram", r, []int64{1, 2, 5, 8, 20}, nil)
+	h, err := metrics.GetOrRegisterVHistogram("histogram", r, []int64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.Add(2)
 	h.Add(6)
 
@@ -109,6 +112,27 @@ func TestWrites(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	hdr, err := metrics.GetOrRegisterHDRHistogram("hdr", r, 1, 1000000, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []int64{100, 200, 300, 400} {
+		hdr.Add(v)
+	}
+
+	exph := metrics.GetOrRegisterExpHistogram("exphist", r, 160, 0)
+	for _, v := range []float64{1, 2, 3, 6} {
+		exph.Add(v)
+	}
+
+	sketch, err := metrics.GetOrRegisterDDSketch("sketch", r, 0.01, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []float64{100, 200, 300, 400} {
+		sketch.Add(v)
+	}
+
 	rate := metrics.GetOrRegisterRate("ratefoo", r).SetName("_value").SetRateName("_rate")
 	rate.UpdateTs(1, 1e9)
 	rate.UpdateTs(7, 3e9)
@@ -148,19 +172,67 @@ func TestWrites(t *testing.T) {
 		"foobar.ugauge":      {V: 1.0},
 		"foobar.gauge_float": {V: 2.1},
 		// histogram
-		"foobar.histogram.1":     {V: 0},
-		"foobar.histogram.2":     {V: 1},
-		"foobar.histogram.20":    {V: 0},
-		"foobar.histogram.5":     {V: 0},
-		"foobar.histogram.8":     {V: 1},
-		"foobar.histogram.inf":   {V: 0},
-		"foobar.histogram.total": {V: 2},
+		"foobar.histogram.1":              {V: 0},
+		"foobar.histogram.2":              {V: 1},
+		"foobar.histogram.20":             {V: 0},
+		"foobar.histogram.5":              {V: 0},
+		"foobar.histogram.8":              {V: 1},
+		"foobar.histogram.inf":            {V: 0},
+		"foobar.histogram.total":          {V: 2},
+		"foobar.histogram.sum":            {V: 8},
+		"foobar.histogram.min":            {V: 2},
+		"foobar.histogram.max":            {V: 6},
+		"foobar.histogram.mean":           {V: 4},
+		"foobar.histogram.50-percentile":  {V: 2},
+		"foobar.histogram.75-percentile":  {V: 5.5},
+		"foobar.histogram.99-percentile":  {V: 5.98},
+		"foobar.histogram.999-percentile": {V: 6},
 		// shistogram
-		"foobar.shistogram.req_1":   {V: 2},
-		"foobar.shistogram.req_2":   {V: 2},
-		"foobar.shistogram.req_3":   {V: 1},
-		"foobar.shistogram.req_inf": {V: 1},
-		"foobar.shistogram.total":   {V: 2},
+		"foobar.shistogram.req_1":          {V: 2},
+		"foobar.shistogram.req_2":          {V: 2},
+		"foobar.shistogram.req_3":          {V: 1},
+		"foobar.shistogram.req_inf":        {V: 1},
+		"foobar.shistogram.total":          {V: 2},
+		"foobar.shistogram.sum":            {V: 8},
+		"foobar.shistogram.min":            {V: 2},
+		"foobar.shistogram.max":            {V: 6},
+		"foobar.shistogram.mean":           {V: 4},
+		"foobar.shistogram.50-percentile":  {V: 2},
+		"foobar.shistogram.75-percentile":  {V: 4.5},
+		"foobar.shistogram.99-percentile":  {V: 5.94},
+		"foobar.shistogram.999-percentile": {V: 5.99},
+		// hdr histogram
+		"foobar.hdr.count":          {V: 4},
+		"foobar.hdr.min":            {V: 100},
+		"foobar.hdr.max":            {V: 400},
+		"foobar.hdr.mean":           {V: 250},
+		"foobar.hdr.50-percentile":  {V: 200},
+		"foobar.hdr.75-percentile":  {V: 300},
+		"foobar.hdr.99-percentile":  {V: 400},
+		"foobar.hdr.999-percentile": {V: 400},
+		// exponential histogram
+		"foobar.exphist.1":              {V: 1},
+		"foobar.exphist.2":              {V: 1},
+		"foobar.exphist.4":              {V: 1},
+		"foobar.exphist.8":              {V: 1},
+		"foobar.exphist.total":          {V: 4},
+		"foobar.exphist.sum":            {V: 12},
+		"foobar.exphist.min":            {V: 1},
+		"foobar.exphist.max":            {V: 6},
+		"foobar.exphist.mean":           {V: 3},
+		"foobar.exphist.50-percentile":  {V: 2},
+		"foobar.exphist.75-percentile":  {V: 4},
+		"foobar.exphist.99-percentile":  {V: 5.92},
+		"foobar.exphist.999-percentile": {V: 5.99},
+		// ddsketch
+		"foobar.sketch.count":          {V: 4},
+		"foobar.sketch.min":            {V: 100},
+		"foobar.sketch.max":            {V: 400},
+		"foobar.sketch.mean":           {V: 250},
+		"foobar.sketch.50-percentile":  {V: 200, Dev: 2},
+		"foobar.sketch.75-percentile":  {V: 300, Dev: 3},
+		"foobar.sketch.99-percentile":  {V: 300, Dev: 3},
+		"foobar.sketch.999-percentile": {V: 300, Dev: 3},
 		// rate
 		"foobar.ratefoo_value":  {V: 7},
 		"foobar.ratefoo_rate":   {V: 3},
@@ -193,3 +265,36 @@ func TestWrites(t *testing.T) {
 		t.Error(sb.String())
 	}
 }
+
+func TestConnMetrics(t *testing.T) {
+	sb, _, l, r, c, wg := newTestServer(t, "foobar", "")
+	defer r.UnregisterAll()
+
+	metrics.GetOrRegisterCounter("counter", r).Add(2)
+
+	m, err := metrics.NewNetMetrics(r, "graphite.conn", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := WithConfig(c)
+	g.SetConnMetrics(m)
+	if err = g.send(r); err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	wg.Wait()
+
+	m.Capture()
+	if n := m.Connections.Count(); n != 1 {
+		t.Errorf("connections = %d, want 1", n)
+	}
+	if n := m.Active.Count(); n != 0 {
+		t.Errorf("active = %d, want 0", n)
+	}
+	if n, _ := m.WriteBytes.Values(); n != int64(sb.Len()) {
+		t.Errorf("write_bytes = %d, want %d", n, sb.Len())
+	}
+}
@@ -0,0 +1,256 @@
+// Package health provides /healthz and /readyz HTTP handlers for go-metrics registered healthchecks.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+const (
+	// StatusUp is a healthy check (and aggregate) status
+	StatusUp = "up"
+	// StatusDown is a unhealthy check (and aggregate) status
+	StatusDown = "down"
+
+	// GroupTag is a healthcheck tag for checks groups (like health=ready for /readyz)
+	GroupTag = "health"
+
+	// DefaultTimeout is a default per-check timeout
+	DefaultTimeout = 5 * time.Second
+)
+
+var (
+	ErrUnhealthy = errors.New("unhealthy")
+	ErrTimeout   = errors.New("check timed out")
+)
+
+// ErrorReporter is implemented by healthchecks, which hold the last check error (reported instead of ErrUnhealthy).
+type ErrorReporter interface {
+	Err() error
+}
+
+// Options is a checker options.
+type Options struct {
+	// Registry with healthchecks, metrics.DefaultRegistry if nil
+	Registry metrics.Registry
+	// Timeout is a per-check timeout, DefaultTimeout if zero
+	Timeout time.Duration
+	// Group selects healthchecks with GroupTag=Group tag (like ready for /readyz), all healthchecks are selected if empty
+	Group string
+}
+
+// CheckResult is a healthcheck result.
+type CheckResult struct {
+	Name      string            `json:"name"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Status    string            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	LastCheck time.Time         `json:"last_check"`
+	Duration  string            `json:"duration"`
+
+	tags string
+}
+
+// Report is an aggregated healthchecks result, status is down if any check is down.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// checkState is a healthcheck last result (and in-flight check, shared by concurrent requests)
+type checkState struct {
+	h metrics.Healthcheck
+
+	done    chan struct{} // closed on in-flight check end, nil if check is not running
+	started time.Time     // in-flight check start
+
+	err      error // nil if up
+	last     time.Time
+	duration time.Duration
+}
+
+// Checker runs registered healthchecks concurrently with per-check timeouts and serves JSON report over HTTP:
+// 200 status code if all checks are up and 503 if not. Failed checks are reported (all checks with ?verbose query parameter).
+//
+// A check, which still running after timeout, is not restarted by next runs until finish.
+type Checker struct {
+	r       metrics.Registry
+	timeout time.Duration
+	group   string
+
+	lock   sync.Mutex
+	states map[metrics.NameTagged]*checkState
+}
+
+// New constructs a healthchecks checker.
+func New(opts Options) *Checker {
+	if opts.Registry == nil {
+		opts.Registry = metrics.DefaultRegistry
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	return &Checker{
+		r:       opts.Registry,
+		timeout: opts.Timeout,
+		group:   opts.Group,
+		states:  make(map[metrics.NameTagged]*checkState),
+	}
+}
+
+// Check runs selected healthchecks and returns report with all checks results (sorted by name and tags).
+func (c *Checker) Check(ctx context.Context) Report {
+	results := c.run(ctx)
+	report := Report{Status: StatusUp, Checks: results}
+	for i := range results {
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+			break
+		}
+	}
+	return report
+}
+
+type checkTarget struct {
+	key     metrics.NameTagged
+	tagsMap map[string]string
+	state   *checkState
+}
+
+func (c *Checker) run(ctx context.Context) []CheckResult {
+	var targets []checkTarget
+	c.lock.Lock()
+	seen := make(map[metrics.NameTagged]bool, len(c.states))
+	c.r.Each(func(name, tags string, tagsMap map[string]string, i interface{}) error {
+		h, ok := i.(metrics.Healthcheck)
+		if !ok || (c.group != "" && tagsMap[GroupTag] != c.group) {
+			return nil
+		}
+		key := metrics.NameTagged{Name: name, Tags: tags}
+		s, ok := c.states[key]
+		if !ok || s.h != h {
+			s = &checkState{h: h}
+			c.states[key] = s
+		}
+		seen[key] = true
+		targets = append(targets, checkTarget{key: key, tagsMap: tagsMap, state: s})
+		return nil
+	}, true)
+	for key := range c.states {
+		if !seen[key] {
+			delete(c.states, key)
+		}
+	}
+	c.lock.Unlock()
+
+	results := make([]CheckResult, len(targets))
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	for i := range targets {
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.check(ctx, targets[i])
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name == results[j].Name {
+			return results[i].tags < results[j].tags
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// check starts healthcheck (or joins to in-flight check) and waits for result until timeout
+func (c *Checker) check(ctx context.Context, t checkTarget) CheckResult {
+	s := t.state
+	c.lock.Lock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+		s.started = time.Now()
+		go c.exec(s, s.done, s.started)
+	}
+	done, started := s.done, s.started
+	c.lock.Unlock()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	result := CheckResult{Name: t.key.Name, Tags: t.tagsMap, tags: t.key.Tags}
+	var err error
+	select {
+	case <-done:
+		c.lock.Lock()
+		err = s.err
+		result.LastCheck = s.last
+		result.Duration = s.duration.String()
+		c.lock.Unlock()
+	case <-timer.C:
+		err = ErrTimeout
+		result.LastCheck = started
+		result.Duration = time.Since(started).String()
+	case <-ctx.Done():
+		err = ctx.Err()
+		result.LastCheck = started
+		result.Duration = time.Since(started).String()
+	}
+	if err == nil {
+		result.Status = StatusUp
+	} else {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (c *Checker) exec(s *checkState, done chan struct{}, started time.Time) {
+	var err error
+	if s.h.Check() <= 0 {
+		err = ErrUnhealthy
+		if e, ok := s.h.(ErrorReporter); ok {
+			if hErr := e.Err(); hErr != nil {
+				err = hErr
+			}
+		}
+	}
+	c.lock.Lock()
+	s.err = err
+	s.last = started
+	s.duration = time.Since(started)
+	s.done = nil
+	c.lock.Unlock()
+	close(done)
+}
+
+// ServeHTTP runs healthchecks and writes JSON report.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := c.Check(req.Context())
+	if _, verbose := req.URL.Query()["verbose"]; !verbose {
+		failed := report.Checks[:0]
+		for _, r := range report.Checks {
+			if r.Status != StatusUp {
+				failed = append(failed, r)
+			}
+		}
+		report.Checks = failed
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if report.Status == StatusUp {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if req.Method != http.MethodHead {
+		json.NewEncoder(w).Encode(report)
+	}
+}
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+// errHealthcheck is always down with err
+type errHealthcheck struct {
+	metrics.NilHealthcheck
+	err error
+}
+
+func (h *errHealthcheck) Check() int32 { return 0 }
+
+func (h *errHealthcheck) Err() error { return h.err }
+
+func newHealthcheck(up bool) metrics.Healthcheck {
+	return metrics.NewHealthcheck(func(bool) bool { return up })
+}
+
+func serve(t *testing.T, c *Checker, target string) (int, Report) {
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("%v: %q", err, rec.Body.String())
+	}
+	return rec.Code, report
+}
+
+func checksStatus(report Report) map[string]string {
+	statuses := make(map[string]string)
+	for _, r := range report.Checks {
+		name := r.Name
+		if len(r.Tags) > 0 {
+			name += metrics.JoinTags(r.Tags)
+		}
+		statuses[name] = r.Status + ":" + r.Error
+	}
+	return statuses
+}
+
+func TestChecker(t *testing.T) {
+	r := metrics.NewRegistry()
+	if err := r.Register("db", newHealthcheck(true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterT("cache", map[string]string{GroupTag: "ready"}, newHealthcheck(true)); err != nil {
+		t.Fatal(err)
+	}
+	r.Register("requests", metrics.NewCounter())
+
+	healthz := New(Options{Registry: r})
+	readyz := New(Options{Registry: r, Group: "ready"})
+
+	code, report := serve(t, healthz, "/healthz")
+	if code != http.StatusOK || report.Status != StatusUp || len(report.Checks) != 0 {
+		t.Errorf("/healthz = %d %+v, want 200 up without checks", code, report)
+	}
+	code, report = serve(t, healthz, "/healthz?verbose")
+	want := map[string]string{"cache;health=ready": "up:", "db": "up:"}
+	if code != http.StatusOK || !reflect.DeepEqual(checksStatus(report), want) {
+		t.Errorf("/healthz?verbose = %d %v, want 200 %v", code, checksStatus(report), want)
+	}
+	if report.Checks[0].Name != "cache" || report.Checks[0].LastCheck.IsZero() || report.Checks[0].Duration == "" {
+		t.Errorf("/healthz?verbose checks = %+v", report.Checks)
+	}
+
+	r.Register("queue", &errHealthcheck{err: context.Canceled})
+	r.RegisterT("disk", map[string]string{GroupTag: "ready"}, newHealthcheck(false))
+
+	code, report = serve(t, healthz, "/healthz")
+	want = map[string]string{"disk;health=ready": "down:unhealthy", "queue": "down:" + context.Canceled.Error()}
+	if code != http.StatusServiceUnavailable || report.Status != StatusDown || !reflect.DeepEqual(checksStatus(report), want) {
+		t.Errorf("/healthz = %d %s %v, want 503 down %v", code, report.Status, checksStatus(report), want)
+	}
+	code, report = serve(t, readyz, "/readyz?verbose")
+	want = map[string]string{"cache;health=ready": "up:", "disk;health=ready": "down:unhealthy"}
+	if code != http.StatusServiceUnavailable || !reflect.DeepEqual(checksStatus(report), want) {
+		t.Errorf("/readyz?verbose = %d %v, want 503 %v", code, checksStatus(report), want)
+	}
+
+	r.UnregisterT("disk", map[string]string{GroupTag: "ready"})
+	if code, report = serve(t, readyz, "/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz = %d %+v, want 200", code, report)
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	r := metrics.NewRegistry()
+	var calls int32
+	release := make(chan struct{})
+	r.Register("slow", metrics.NewHealthcheck(func(bool) bool {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return true
+	}))
+	c := New(Options{Registry: r, Timeout: 10 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		report := c.Check(context.Background())
+		if report.Status != StatusDown || report.Checks[0].Error != ErrTimeout.Error() {
+			t.Fatalf("Check() = %+v, want timeout", report)
+		}
+	}
+	// in-flight check is not restarted
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+
+	close(release)
+	c.timeout = time.Second
+	if report := c.Check(context.Background()); report.Status != StatusUp {
+		t.Errorf("Check() = %+v, want up", report)
+	}
+}
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+// AsyncHealthcheckOptions is an asynchronous healthcheck options.
+type AsyncHealthcheckOptions struct {
+	// Timeout is a check function context timeout, interval is used if zero
+	Timeout time.Duration
+	// SuccessThreshold is a consecutive successful checks count for switch to up (1 by default)
+	SuccessThreshold int
+	// FailureThreshold is a consecutive failed checks count for switch to down (1 by default)
+	FailureThreshold int
+}
+
+// AsyncHealthcheck is a Healthcheck, which runs the check function periodically in background goroutine (after Start()).
+// Check() returns cached status, so exporters are not blocked by slow checks.
+// Status is down until SuccessThreshold consecutive successful checks.
+type AsyncHealthcheck struct {
+	up int32
+
+	f    func(context.Context) error
+	opts AsyncHealthcheckOptions
+
+	lock        sync.RWMutex
+	err         error // last check error
+	lastCheck   time.Time
+	lastSuccess time.Time
+	lastFailure time.Time
+	successes   int // consecutive successful checks
+	failures    int // consecutive failed checks
+
+	runner runner.Runner
+}
+
+// NewAsyncHealthcheck constructs a new AsyncHealthcheck with check function f (returns nil if healthy).
+func NewAsyncHealthcheck(f func(ctx context.Context) error, opts AsyncHealthcheckOptions) *AsyncHealthcheck {
+	if opts.SuccessThreshold <= 0 {
+		opts.SuccessThreshold = 1
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 1
+	}
+	return &AsyncHealthcheck{f: f, opts: opts}
+}
+
+// Check returns the healthcheck's cached status (1 if up), the check function is run by Start() or Run().
+func (h *AsyncHealthcheck) Check() int32 {
+	return atomic.LoadInt32(&h.up)
+}
+
+// IsUp returns the healthcheck's status
+func (h *AsyncHealthcheck) IsUp() bool {
+	return atomic.LoadInt32(&h.up) > 0
+}
+
+// Status returns the healthcheck's internal status value
+func (h *AsyncHealthcheck) Status() int32 {
+	return atomic.LoadInt32(&h.up)
+}
+
+// Healthy marks the healthcheck as healthy (and resets thresholds counters).
+func (h *AsyncHealthcheck) Healthy() {
+	h.lock.Lock()
+	h.successes, h.failures = 0, 0
+	atomic.StoreInt32(&h.up, 1)
+	h.lock.Unlock()
+}
+
+// Unhealthy marks the healthcheck as unhealthy (and resets thresholds counters).
+func (h *AsyncHealthcheck) Unhealthy() {
+	h.lock.Lock()
+	h.successes, h.failures = 0, 0
+	atomic.StoreInt32(&h.up, 0)
+	h.lock.Unlock()
+}
+
+// Err returns the last check error (nil if the last check is successful).
+func (h *AsyncHealthcheck) Err() error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.err
+}
+
+// LastCheck returns the last check time (zero if not checked yet).
+func (h *AsyncHealthcheck) LastCheck() time.Time {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.lastCheck
+}
+
+// LastSuccess returns the last successful check time.
+func (h *AsyncHealthcheck) LastSuccess() time.Time {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.lastSuccess
+}
+
+// LastFailure returns the last failed check time.
+func (h *AsyncHealthcheck) LastFailure() time.Time {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.lastFailure
+}
+
+// Run runs the check function and updates status with thresholds. Returns the check error.
+func (h *AsyncHealthcheck) Run(ctx context.Context) error {
+	if h.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.opts.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := h.f(ctx)
+	h.update(start, err)
+
+	return err
+}
+
+// update updates status with thresholds by the check result.
+func (h *AsyncHealthcheck) update(start time.Time, err error) {
+	h.lock.Lock()
+	h.err = err
+	h.lastCheck = start
+	if err == nil {
+		h.lastSuccess = start
+		h.successes++
+		h.failures = 0
+		if h.successes >= h.opts.SuccessThreshold {
+			atomic.StoreInt32(&h.up, 1)
+		}
+	} else {
+		h.lastFailure = start
+		h.failures++
+		h.successes = 0
+		if h.failures >= h.opts.FailureThreshold {
+			atomic.StoreInt32(&h.up, 0)
+		}
+	}
+	h.lock.Unlock()
+}
+
+// Start runs the check function immediately and then with interval in background goroutine, until Stop() call or ctx cancellation.
+// Start doesn't wait for the first check.
+// Check function context timeout is interval, if not set in options. Stopped healthcheck can be started again.
+func (h *AsyncHealthcheck) Start(ctx context.Context, interval time.Duration) error {
+	timeout := h.opts.Timeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+	return h.runner.StartContext(ctx, interval, func(ctx context.Context) {
+		checkCtx, checkCancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := h.f(checkCtx)
+		checkCancel()
+		if ctx.Err() != nil {
+			// running check is canceled by Stop() or ctx cancellation, it's not a failure
+			return
+		}
+		h.update(start, err)
+	})
+}
+
+// Stop cancels running check, stops background checks and wait for goroutine exit.
+func (h *AsyncHealthcheck) Stop() {
+	h.runner.Stop()
+}
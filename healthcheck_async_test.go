@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAsyncHealthcheckThresholds(t *testing.T) {
+	errDown := errors.New("down")
+	var fail int32
+	h := NewAsyncHealthcheck(func(context.Context) error {
+		if atomic.LoadInt32(&fail) == 1 {
+			return errDown
+		}
+		return nil
+	}, AsyncHealthcheckOptions{SuccessThreshold: 2, FailureThreshold: 3})
+
+	var _ Healthcheck = h
+	ctx := context.Background()
+
+	tests := []struct {
+		fail bool
+		want bool
+	}{
+		{false, false}, // 1 success
+		{false, true},  // 2 successes
+		{true, true},   // 1 failure
+		{true, true},   // 2 failures
+		{false, true},  // reset failures
+		{true, true},
+		{true, true},
+		{true, false}, // 3 failures
+		{false, false},
+		{false, true},
+	}
+	for i, tt := range tests {
+		if tt.fail {
+			atomic.StoreInt32(&fail, 1)
+		} else {
+			atomic.StoreInt32(&fail, 0)
+		}
+		err := h.Run(ctx)
+		if (err != nil) != tt.fail || h.Err() != err {
+			t.Errorf("[%d] Run() = %v, Err() = %v", i, err, h.Err())
+		}
+		if h.IsUp() != tt.want || (h.Check() == 1) != tt.want {
+			t.Errorf("[%d] IsUp() = %v, want %v", i, h.IsUp(), tt.want)
+		}
+	}
+	if h.LastCheck().IsZero() || h.LastSuccess() != h.LastCheck() || h.LastFailure().After(h.LastSuccess()) {
+		t.Errorf("LastCheck() = %v, LastSuccess() = %v, LastFailure() = %v", h.LastCheck(), h.LastSuccess(), h.LastFailure())
+	}
+
+	h.Unhealthy()
+	if h.IsUp() {
+		t.Error("IsUp() after Unhealthy() must be false")
+	}
+	h.Healthy()
+	if !h.IsUp() {
+		t.Error("IsUp() after Healthy() must be true")
+	}
+}
+
+func TestAsyncHealthcheckStart(t *testing.T) {
+	var calls int32
+	h := NewAsyncHealthcheck(func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) > 1 {
+			// slow check, canceled by timeout or Stop()
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, AsyncHealthcheckOptions{FailureThreshold: 2})
+
+	if err := h.Start(context.Background(), 0); err != ErrCollectorInterval {
+		t.Errorf("Start() with zero interval = %v, want %v", err, ErrCollectorInterval)
+	}
+	if err := h.Start(context.Background(), 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// first check is done in background
+	for h.LastCheck().IsZero() {
+		time.Sleep(time.Millisecond)
+	}
+	if !h.IsUp() {
+		t.Error("IsUp() after the first check must be true")
+	}
+	if err := h.Start(context.Background(), 5*time.Millisecond); err != ErrCollectorStarted {
+		t.Errorf("second Start() = %v, want %v", err, ErrCollectorStarted)
+	}
+
+	// cached status is not blocked by slow check
+	start := time.Now()
+	for time.Since(start) < time.Second && h.IsUp() {
+		h.Check()
+		time.Sleep(time.Millisecond)
+	}
+	if h.IsUp() {
+		t.Error("IsUp() after failed checks must be false")
+	}
+	if err := h.Err(); err != context.DeadlineExceeded {
+		t.Errorf("Err() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	h.Stop()
+}
+
+func BenchmarkAsyncHealthcheckCheck(b *testing.B) {
+	h := NewAsyncHealthcheck(func(context.Context) error { return nil }, AsyncHealthcheckOptions{})
+	h.Run(context.Background())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Check()
+	}
+}
+
+func TestAsyncHealthcheckStop(t *testing.T) {
+	started := make(chan struct{})
+	h := NewAsyncHealthcheck(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, AsyncHealthcheckOptions{})
+	h.Healthy()
+
+	if err := h.Start(context.Background(), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	h.Stop()
+	// canceled running check is not a failure
+	if !h.IsUp() {
+		t.Error("IsUp() after Stop() must be true")
+	}
+	if err := h.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+	if !h.LastFailure().IsZero() {
+		t.Errorf("LastFailure() = %v, want zero", h.LastFailure())
+	}
+}
@@ -31,6 +31,12 @@ import (
 // {TAG_PREFIX}.{NAME}{LABEL_BUCKET_INF};TAG=VAL;..;le=inf
 //
 // {TAG_PREFIX}{NAME}{TOTAL};TAG=VAL;..
+//
+// Observations summary (sum, min, max, mean) are exported with fixed postfixes:
+//
+// {PREFIX}.{NAME}.sum
+//
+// {TAG_PREFIX}.{NAME}.sum;TAG=VAL;..
 type HistogramInterface interface {
 	Clear() []uint64
 	Values() []uint64
@@ -40,6 +46,32 @@ type HistogramInterface interface {
 	WeightsAliases() []string
 	// If true, is prometheus-like (cummulative, increment in bucket[1]  also increment bucket[0])
 	IsSummed() bool
+	// Observations summary (count, sum, min, max), read separately from Values(), so use SnapshotHistogram() for consistent read
+	Stats() HistogramStats
+	// Estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets, like prometheus histogram_quantile
+	Quantile(q float64) float64
+	// Estimated quantiles (0 <= q <= 1), calculated from one consistent read
+	Quantiles(qs []float64) []float64
+}
+
+// SnapshotHistogram returns histogram snapshot for consistent read of buckets, stats and quantiles
+// (h is returned for histograms without snapshot).
+// Exporters must read a histogram once per flush, Values(), Stats() and Quantiles() calls on live histogram
+// are separate reads (with possibly different observations).
+func SnapshotHistogram(h HistogramInterface) HistogramInterface {
+	switch h := h.(type) {
+	case ExpHistogram:
+		// buckets layout may change with scale
+		return h.Snapshot()
+	case Histogram:
+		return h.Snapshot()
+	case UHistogram:
+		return h.Snapshot()
+	case FHistogram:
+		return h.Snapshot()
+	default:
+		return h
+	}
 }
 
 // A Histogram is a lossy data structure used to record the distribution of
@@ -53,6 +85,14 @@ type Histogram interface {
 	Snapshot() Histogram
 	Add(v int64)
 	Weights() []int64
+	// Observations count
+	Count() uint64
+	// Observations sum
+	Sum() int64
+	// Minimal observation (or zero without observations)
+	Min() int64
+	// Maximal observation (or zero without observations)
+	Max() int64
 }
 
 // GetOrRegisterHistogram returns an existing Histogram or constructs and registers
@@ -97,42 +137,56 @@ func NewRegisteredFixedHistogramT(name string, tagsMap map[string]string, r Regi
 	return h
 }
 
-func GetOrRegisterVHistogram(name string, r Registry, weights []int64, names []string) Histogram {
+func GetOrRegisterVHistogram(name string, r Registry, weights []int64, names []string) (Histogram, error) {
+	if !IsSortedSliceInt64Ge(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegister(name, func() interface{} {
-		return NewVHistogram(weights, names)
-	}).(Histogram)
+		h, _ := NewVHistogram(weights, names)
+		return h
+	}).(Histogram), nil
 }
 
-func GetOrRegisterVHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) Histogram {
+func GetOrRegisterVHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) (Histogram, error) {
+	if !IsSortedSliceInt64Ge(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
-		return NewVHistogram(weights, names)
-	}).(Histogram)
+		h, _ := NewVHistogram(weights, names)
+		return h
+	}).(Histogram), nil
 }
 
 // NewRegisteredVHistogram constructs and registers a new VHistogram.
-func NewRegisteredVHistogram(name string, r Registry, weights []int64, names []string) Histogram {
+func NewRegisteredVHistogram(name string, r Registry, weights []int64, names []string) (Histogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVHistogram(weights, names)
+	h, err := NewVHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.Register(name, h)
-	return h
+	return h, nil
 }
 
 // NewRegisteredVHistogramT constructs and registers a new VHistogram.
-func NewRegisteredVHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) Histogram {
+func NewRegisteredVHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) (Histogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVHistogram(weights, names)
+	h, err := NewVHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.RegisterT(name, tagsMap, h)
-	return h
+	return h, nil
 }
 
 type NilHistogram struct{}
@@ -173,6 +227,20 @@ func (h NilHistogram) Clear() []uint64 {
 
 func (NilHistogram) Snapshot() Histogram { return NilHistogram{} }
 
+func (NilHistogram) Count() uint64 { return 0 }
+
+func (NilHistogram) Sum() int64 { return 0 }
+
+func (NilHistogram) Min() int64 { return 0 }
+
+func (NilHistogram) Max() int64 { return 0 }
+
+func (NilHistogram) Stats() HistogramStats { return HistogramStats{} }
+
+func (NilHistogram) Quantile(float64) float64 { return 0 }
+
+func (NilHistogram) Quantiles(qs []float64) []float64 { return make([]float64, len(qs)) }
+
 func (NilHistogram) IsSummed() bool { return false }
 
 type HistogramSnapshot struct {
@@ -181,6 +249,10 @@ type HistogramSnapshot struct {
 	names          []string
 	total          string
 	buckets        []uint64 // last buckets stores all, not included at previous
+	count          uint64
+	sum            int64
+	min            int64
+	max            int64
 }
 
 func (h *HistogramSnapshot) Values() []uint64 {
@@ -231,15 +303,113 @@ func (h *HistogramSnapshot) Snapshot() Histogram {
 	return h
 }
 
+func (h *HistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *HistogramSnapshot) Sum() int64 {
+	return h.sum
+}
+
+func (h *HistogramSnapshot) Min() int64 {
+	return h.min
+}
+
+func (h *HistogramSnapshot) Max() int64 {
+	return h.max
+}
+
+func (h *HistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: float64(h.sum), Min: float64(h.min), Max: float64(h.max)}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *HistogramSnapshot) Quantile(q float64) float64 {
+	return bucketQuantile(q, h.weight, h.buckets, false, h.Stats())
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *HistogramSnapshot) Quantiles(qs []float64) []float64 {
+	return bucketQuantiles(qs, h.weight, h.buckets, false, h.Stats())
+}
+
+func (h *HistogramSnapshot) weight(i int) float64 {
+	return float64(h.weights[i])
+}
+
 func (HistogramSnapshot) IsSummed() bool { return false }
 
+// Merge returns a new snapshot with observations from both snapshots (for example, from goroutine-local histograms).
+// Labels and total name are taken from h, weights must be equal.
+func (h *HistogramSnapshot) Merge(other *HistogramSnapshot) (*HistogramSnapshot, error) {
+	if !equalInt64Weights(h.weights, other.weights) || len(h.buckets) != len(other.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	min, max := mergeInt64MinMax(h.count, h.min, h.max, other.count, other.min, other.max)
+	return &HistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        mergeBuckets(h.buckets, other.buckets),
+		count:          h.count + other.count,
+		sum:            h.sum + other.sum,
+		min:            min,
+		max:            max,
+	}, nil
+}
+
+// Sub returns a new snapshot with observations added after prev snapshot of the same histogram.
+// Min and max can't be restored for the interval, so they are taken from h (or zero without new observations).
+func (h *HistogramSnapshot) Sub(prev *HistogramSnapshot) (*HistogramSnapshot, error) {
+	if !equalInt64Weights(h.weights, prev.weights) || len(h.buckets) != len(prev.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	if h.count < prev.count {
+		return nil, ErrHistogramDecreased
+	}
+	buckets, err := subBuckets(h.buckets, prev.buckets)
+	if err != nil {
+		return nil, err
+	}
+	s := &HistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        buckets,
+		count:          h.count - prev.count,
+	}
+	if s.count > 0 {
+		s.sum = h.sum - prev.sum
+		s.min = h.min
+		s.max = h.max
+	}
+	return s, nil
+}
+
+// Cumulative returns a copy of snapshot with cumulative (summed, like in Sum histograms) counts.
+func (h *HistogramSnapshot) Cumulative() *SumHistogramSnapshot {
+	return &SumHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        toCumulativeBuckets(h.buckets),
+		count:          h.count,
+		sum:            h.sum,
+		min:            h.min,
+		max:            h.max,
+	}
+}
+
 type HistogramStorage struct {
-	weights        []int64 // Sorted weights (greater or equal), last is inf
+	buckets        histogramBuckets // last bucket stores endVal overflows count
+	weights        []int64          // Sorted weights (greater or equal), last is inf
 	weightsAliases []string
 	labels         []string
 	total          string
-	buckets        []uint64 // last bucket stores endVal overflows count
-	lock           sync.RWMutex
+	lock           sync.RWMutex // labels lock, also serialize buckets readers
 }
 
 func (h *HistogramStorage) Labels() []string {
@@ -289,31 +459,80 @@ func (h *HistogramStorage) Interface() HistogramInterface {
 	return h
 }
 
-func (h *HistogramStorage) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
+func (h *HistogramStorage) values() histogramValues {
 	h.lock.Lock()
-	copy(buckets, h.buckets)
+	v := h.buckets.values()
 	h.lock.Unlock()
-	return buckets
+	return v
+}
+
+func (h *HistogramStorage) Values() []uint64 {
+	return h.values().buckets
+}
+
+// Count returns observations count
+func (h *HistogramStorage) Count() uint64 {
+	return h.values().count
+}
+
+// Sum returns observations sum
+func (h *HistogramStorage) Sum() int64 {
+	v := h.values()
+	sum, _, _ := v.int64Stats()
+	return sum
+}
+
+// Min returns minimal observation (or zero without observations)
+func (h *HistogramStorage) Min() int64 {
+	v := h.values()
+	_, min, _ := v.int64Stats()
+	return min
+}
+
+// Max returns maximal observation (or zero without observations)
+func (h *HistogramStorage) Max() int64 {
+	v := h.values()
+	_, _, max := v.int64Stats()
+	return max
+}
+
+func (h *HistogramStorage) Stats() HistogramStats {
+	v := h.values()
+	sum, min, max := v.int64Stats()
+	return HistogramStats{Count: v.count, Sum: float64(sum), Min: float64(min), Max: float64(max)}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *HistogramStorage) Quantile(q float64) float64 {
+	return h.Snapshot().Quantile(q)
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *HistogramStorage) Quantiles(qs []float64) []float64 {
+	return h.Snapshot().Quantiles(qs)
 }
 
 func (h *HistogramStorage) Snapshot() Histogram {
+	v := h.values()
+	sum, min, max := v.int64Stats()
 	return &HistogramSnapshot{
 		names:          h.labels,
 		total:          h.total,
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
-		buckets:        h.buckets,
+		buckets:        v.buckets,
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *HistogramStorage) Clear() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
 	h.lock.Lock()
-	v := h.buckets
-	h.buckets = buckets
+	v := h.buckets.clear()
 	h.lock.Unlock()
-	return v
+	return v.buckets
 }
 
 func (h *HistogramStorage) IsSummed() bool { return false }
@@ -344,7 +563,6 @@ func NewFixedHistogram(startVal, endVal, width int64) Histogram {
 	weights := make([]int64, count)
 	weightsAliases := make([]string, count)
 	labels := make([]string, count)
-	buckets := make([]uint64, count)
 	ge := startVal
 	// fmtStr := fmt.Sprintf("%%s%%0%dd", len(strconv.FormatUint(endVal+width, 10)))
 	for i := 0; i < len(weights); i++ {
@@ -367,7 +585,7 @@ func NewFixedHistogram(startVal, endVal, width int64) Histogram {
 			weightsAliases: weightsAliases,
 			labels:         labels,
 			total:          ".total",
-			buckets:        buckets,
+			buckets:        newHistogramBuckets(len(weights), histogramInt64),
 		},
 		start: startVal,
 		width: width,
@@ -383,13 +601,11 @@ func (h *FixedHistogram) Add(v int64) {
 		} else {
 			n = n/h.width + 1
 		}
-		if n >= int64(len(h.buckets)) {
-			n = int64(len(h.buckets) - 1)
+		if n >= int64(h.buckets.len()) {
+			n = int64(h.buckets.len() - 1)
 		}
 	}
-	h.lock.Lock()
-	h.buckets[n]++
-	h.lock.Unlock()
+	h.buckets.addInt64(int(n), v)
 }
 
 func (h *FixedHistogram) SetLabels(labels []string) Histogram {
@@ -413,12 +629,12 @@ type VHistogram struct {
 
 var ErrUnsortedWeights = errors.New("unsorted weights")
 
-func NewVHistogram(weights []int64, labels []string) Histogram {
+func NewVHistogram(weights []int64, labels []string) (Histogram, error) {
 	if UseNilMetrics {
-		return NilHistogram{}
+		return NilHistogram{}, nil
 	}
 	if !IsSortedSliceInt64Ge(weights) {
-		panic(ErrUnsortedWeights)
+		return nil, ErrUnsortedWeights
 	}
 	w := make([]int64, len(weights)+1)
 	weightsAliases := make([]string, len(w))
@@ -453,17 +669,9 @@ func NewVHistogram(weights []int64, labels []string) Histogram {
 			weightsAliases: weightsAliases,
 			labels:         lbls,
 			total:          ".total",
-			buckets:        make([]uint64, len(w)),
+			buckets:        newHistogramBuckets(len(w), histogramInt64),
 		},
-	}
-}
-
-func (h *VHistogram) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	copy(buckets, h.buckets)
-	h.lock.Unlock()
-	return buckets
+	}, nil
 }
 
 func (h *VHistogram) WeightsAliases() []string {
@@ -476,20 +684,23 @@ func (h *VHistogram) Interface() HistogramInterface {
 }
 
 func (h *VHistogram) Snapshot() Histogram {
+	v := h.values()
+	sum, min, max := v.int64Stats()
 	return &HistogramSnapshot{
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
 		names:          h.labels,
 		total:          h.NameTotal(),
-		buckets:        h.Values(),
+		buckets:        v.buckets,
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *VHistogram) Add(v int64) {
-	n := SearchInt64Le(h.weights, v)
-	h.lock.Lock()
-	h.buckets[n]++
-	h.lock.Unlock()
+	h.buckets.addInt64(SearchInt64Le(h.weights, v), v)
 }
 
 func (h *VHistogram) SetLabels(labels []string) Histogram {
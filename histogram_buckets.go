@@ -0,0 +1,306 @@
+package metrics
+
+import (
+	"math"
+	"runtime"
+	"sync/atomic"
+)
+
+const (
+	hotIdxShift = 63
+	countMask   = (1 << hotIdxShift) - 1
+)
+
+// HistogramStats is a summary of histogram observations, converted to float64 for type-independent exporters.
+// Min and Max is zero without observations.
+type HistogramStats struct {
+	Count uint64
+	Sum   float64
+	Min   float64
+	Max   float64
+}
+
+// Mean returns an arithmetic mean of observations (or zero without observations).
+func (s HistogramStats) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+type histogramKind uint8
+
+const (
+	histogramInt64 histogramKind = iota
+	histogramUint64
+	histogramFloat64
+)
+
+// histogramCounts is a one half of histogramBuckets double buffer.
+type histogramCounts struct {
+	count   uint64 // completed observations, incremented after bucket increment
+	sum     uint64 // int64, uint64 or float64 bits (depends on histogramKind)
+	min     uint64 // int64, uint64 or float64 bits (depends on histogramKind)
+	max     uint64 // int64, uint64 or float64 bits (depends on histogramKind)
+	buckets []uint64
+}
+
+func (c *histogramCounts) reset(kind histogramKind) {
+	atomic.StoreUint64(&c.count, 0)
+	atomic.StoreUint64(&c.sum, 0)
+	switch kind {
+	case histogramInt64:
+		atomic.StoreUint64(&c.min, math.MaxInt64)
+		atomic.StoreUint64(&c.max, 1<<63) // math.MinInt64
+	case histogramUint64:
+		atomic.StoreUint64(&c.min, math.MaxUint64)
+		atomic.StoreUint64(&c.max, 0)
+	case histogramFloat64:
+		atomic.StoreUint64(&c.min, math.Float64bits(math.Inf(1)))
+		atomic.StoreUint64(&c.max, math.Float64bits(math.Inf(-1)))
+	}
+}
+
+// histogramValues is a consistent copy of histogramBuckets.
+type histogramValues struct {
+	buckets []uint64
+	count   uint64
+	sum     uint64
+	min     uint64
+	max     uint64
+}
+
+func (v *histogramValues) int64Stats() (sum, min, max int64) {
+	if v.count == 0 {
+		return
+	}
+	return int64(v.sum), int64(v.min), int64(v.max)
+}
+
+func (v *histogramValues) uint64Stats() (sum, min, max uint64) {
+	if v.count == 0 {
+		return
+	}
+	return v.sum, v.min, v.max
+}
+
+func (v *histogramValues) float64Stats() (sum, min, max float64) {
+	if v.count == 0 {
+		return
+	}
+	return math.Float64frombits(v.sum), math.Float64frombits(v.min), math.Float64frombits(v.max)
+}
+
+// histogramBuckets is a lock-free buckets storage for histograms.
+//
+// Observations are added with atomic increments into the hot counts.
+// Readers (must be serialized with external lock) swap hot and cold counts and wait for completion of in-flight observations
+// in the cold counts, so Values() and Clear() always return a consistent buckets set.
+type histogramBuckets struct {
+	// high bit is a hot counts index, other bits are a started observations count
+	countAndHotIdx uint64
+	// pointers for 64-bit alignment of atomic fields on 32-bit platforms
+	counts [2]*histogramCounts
+	kind   histogramKind
+}
+
+func newHistogramBuckets(n int, kind histogramKind) histogramBuckets {
+	b := histogramBuckets{
+		counts: [2]*histogramCounts{
+			{buckets: make([]uint64, n)},
+			{buckets: make([]uint64, n)},
+		},
+		kind: kind,
+	}
+	b.counts[0].reset(kind)
+	b.counts[1].reset(kind)
+	return b
+}
+
+func (b *histogramBuckets) len() int {
+	return len(b.counts[0].buckets)
+}
+
+func (b *histogramBuckets) hot() *histogramCounts {
+	return b.counts[atomic.AddUint64(&b.countAndHotIdx, 1)>>hotIdxShift]
+}
+
+// addInt64 increments bucket with index n and update stats (lock-free).
+func (b *histogramBuckets) addInt64(n int, v int64) {
+	hot := b.hot()
+	atomic.AddUint64(&hot.buckets[n], 1)
+	atomic.AddUint64(&hot.sum, uint64(v))
+	updateMinInt64(&hot.min, v)
+	updateMaxInt64(&hot.max, v)
+	atomic.AddUint64(&hot.count, 1)
+}
+
+// addUint64 increments bucket with index n and update stats (lock-free).
+func (b *histogramBuckets) addUint64(n int, v uint64) {
+	hot := b.hot()
+	atomic.AddUint64(&hot.buckets[n], 1)
+	atomic.AddUint64(&hot.sum, v)
+	updateMinUint64(&hot.min, v)
+	updateMaxUint64(&hot.max, v)
+	atomic.AddUint64(&hot.count, 1)
+}
+
+// addFloat64 increments bucket with index n and update stats (lock-free).
+func (b *histogramBuckets) addFloat64(n int, v float64) {
+	hot := b.hot()
+	atomic.AddUint64(&hot.buckets[n], 1)
+	addFloat64(&hot.sum, v)
+	updateMinFloat64(&hot.min, v)
+	updateMaxFloat64(&hot.max, v)
+	atomic.AddUint64(&hot.count, 1)
+}
+
+// swap switches hot and cold counts and wait for completion of in-flight observations in the cold counts.
+func (b *histogramBuckets) swap() (hot, cold *histogramCounts, count uint64) {
+	n := atomic.AddUint64(&b.countAndHotIdx, 1<<hotIdxShift)
+	count = n & countMask
+	hot = b.counts[n>>hotIdxShift]
+	cold = b.counts[(^n)>>hotIdxShift]
+	for count != atomic.LoadUint64(&cold.count) {
+		runtime.Gosched()
+	}
+	return
+}
+
+// read returns a consistent copy of the cold counts and reset it
+func (b *histogramBuckets) read(cold *histogramCounts, count uint64) histogramValues {
+	v := histogramValues{
+		buckets: make([]uint64, len(cold.buckets)),
+		count:   count,
+		sum:     atomic.LoadUint64(&cold.sum),
+		min:     atomic.LoadUint64(&cold.min),
+		max:     atomic.LoadUint64(&cold.max),
+	}
+	for i := range cold.buckets {
+		v.buckets[i] = atomic.SwapUint64(&cold.buckets[i], 0)
+	}
+	cold.reset(b.kind)
+	return v
+}
+
+// values returns buckets copy. Must be called with readers lock.
+func (b *histogramBuckets) values() histogramValues {
+	hot, cold, count := b.swap()
+	v := b.read(cold, count)
+	// move cold counts to hot, so hot counts stores all observations
+	b.add(hot, &v)
+	atomic.AddUint64(&hot.count, count)
+	return v
+}
+
+// merge adds observations from buckets copy (lock-free).
+func (b *histogramBuckets) merge(v *histogramValues) {
+	if v.count == 0 {
+		return
+	}
+	hot := b.counts[atomic.AddUint64(&b.countAndHotIdx, v.count)>>hotIdxShift]
+	b.add(hot, v)
+	atomic.AddUint64(&hot.count, v.count)
+}
+
+// add adds buckets and stats (without count) from buckets copy to counts.
+func (b *histogramBuckets) add(c *histogramCounts, v *histogramValues) {
+	for i := range v.buckets {
+		atomic.AddUint64(&c.buckets[i], v.buckets[i])
+	}
+	switch b.kind {
+	case histogramInt64:
+		atomic.AddUint64(&c.sum, v.sum)
+		updateMinInt64(&c.min, int64(v.min))
+		updateMaxInt64(&c.max, int64(v.max))
+	case histogramUint64:
+		atomic.AddUint64(&c.sum, v.sum)
+		updateMinUint64(&c.min, v.min)
+		updateMaxUint64(&c.max, v.max)
+	case histogramFloat64:
+		addFloat64(&c.sum, math.Float64frombits(v.sum))
+		updateMinFloat64(&c.min, math.Float64frombits(v.min))
+		updateMaxFloat64(&c.max, math.Float64frombits(v.max))
+	}
+}
+
+// clear returns buckets and reset it. Must be called with readers lock.
+func (b *histogramBuckets) clear() histogramValues {
+	_, cold, count := b.swap()
+	v := b.read(cold, count)
+	// forget cleared observations (subtract count, hot index bit is not changed)
+	atomic.AddUint64(&b.countAndHotIdx, ^(count - 1))
+	return v
+}
+
+func updateMinInt64(addr *uint64, v int64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if int64(old) <= v || atomic.CompareAndSwapUint64(addr, old, uint64(v)) {
+			return
+		}
+	}
+}
+
+func updateMaxInt64(addr *uint64, v int64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if int64(old) >= v || atomic.CompareAndSwapUint64(addr, old, uint64(v)) {
+			return
+		}
+	}
+}
+
+func updateMinUint64(addr *uint64, v uint64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if old <= v || atomic.CompareAndSwapUint64(addr, old, v) {
+			return
+		}
+	}
+}
+
+func updateMaxUint64(addr *uint64, v uint64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if old >= v || atomic.CompareAndSwapUint64(addr, old, v) {
+			return
+		}
+	}
+}
+
+func updateMinFloat64(addr *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if math.Float64frombits(old) <= v || atomic.CompareAndSwapUint64(addr, old, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+func updateMaxFloat64(addr *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if math.Float64frombits(old) >= v || atomic.CompareAndSwapUint64(addr, old, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+func addFloat64(addr *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if atomic.CompareAndSwapUint64(addr, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// cumulativeBuckets convert buckets to cumulative (prometheus-like) in-place:
+// bucket[i] stores all observations, included in bucket[i] and next buckets.
+func cumulativeBuckets(buckets []uint64) []uint64 {
+	for i := len(buckets) - 2; i >= 0; i-- {
+		buckets[i] += buckets[i+1]
+	}
+	return buckets
+}
@@ -0,0 +1,275 @@
+package metrics
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCumulativeBuckets(t *testing.T) {
+	got := cumulativeBuckets([]uint64{1, 0, 2, 0, 3})
+	want := []uint64{6, 5, 5, 3, 3}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("cumulativeBuckets() = %v, want %v", got, want)
+	}
+}
+
+func TestHistogramBuckets_Concurrent(t *testing.T) {
+	const (
+		writers = 8
+		adds    = 10000
+	)
+	h, err := NewVHistogram([]int64{10, 20, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewVSumHistogram([]int64{10, 20, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		stop    = make(chan struct{})
+		cleared = make([]uint64, 4)
+	)
+	wg.Add(writers)
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < adds; n++ {
+				v := int64(n%4)*10 + 1
+				h.Add(v)
+				s.Add(v)
+			}
+		}(i)
+	}
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				for i, v := range h.Clear() {
+					cleared[i] += v
+				}
+				vals := s.Values()
+				for i := 1; i < len(vals); i++ {
+					if vals[i] > vals[i-1] {
+						t.Errorf("inconsistent cumulative buckets %v", vals)
+						return
+					}
+				}
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-readDone
+
+	for i, v := range h.Clear() {
+		cleared[i] += v
+	}
+	want := []uint64{writers * adds / 4, writers * adds / 4, writers * adds / 4, writers * adds / 4}
+	if !reflect.DeepEqual(want, cleared) {
+		t.Errorf("VHistogram.Clear() sum = %v, want %v", cleared, want)
+	}
+	if got := h.Values(); !reflect.DeepEqual([]uint64{0, 0, 0, 0}, got) {
+		t.Errorf("VHistogram.Values() after Clear() = %v, want zero", got)
+	}
+	want = []uint64{writers * adds, writers * adds * 3 / 4, writers * adds / 2, writers * adds / 4}
+	if got := s.Values(); !reflect.DeepEqual(want, got) {
+		t.Errorf("VSumHistogram.Values() = %v, want %v", got, want)
+	}
+	// values must be stable after repeated reads
+	if got := s.Values(); !reflect.DeepEqual(want, got) {
+		t.Errorf("VSumHistogram.Values() = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkFixedUHistogramParallel(b *testing.B) {
+	h := NewFixedUHistogram(10, 100, 10)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.Add(50)
+		}
+	})
+}
+
+func BenchmarkVUHistogram20Parallel(b *testing.B) {
+	h, err := NewVUHistogram(
+		[]uint64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
+		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.Add(50)
+		}
+	})
+}
+
+func BenchmarkFixedFHistogramParallel(b *testing.B) {
+	h := NewFixedFHistogram(10, 100, 10)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.Add(50)
+		}
+	})
+}
+
+func BenchmarkFUHistogram20Parallel(b *testing.B) {
+	h, err := NewFUHistogram(
+		[]float64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
+		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.Add(50)
+		}
+	})
+}
+
+// parallel writes with concurrent reader
+func BenchmarkVSumHistogram20ParallelValues(b *testing.B) {
+	h, err := NewVSumHistogram(
+		[]int64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
+		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = h.Values()
+			}
+		}
+	}()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.Add(50)
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
+}
+
+func TestHistogramStats(t *testing.T) {
+	fixed := NewFixedHistogram(10, 50, 10)
+	v, err := NewVHistogram([]int64{10, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := NewVSumHistogram([]int64{10, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := NewFixedSumUHistogram(10, 50, 10)
+	f, err := NewFUHistogram([]float64{0.5, 1.5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		h    HistogramInterface
+		add  func()
+		want HistogramStats
+	}{
+		{"FixedHistogram", fixed, func() { fixed.Add(-5); fixed.Add(15); fixed.Add(100) }, HistogramStats{Count: 3, Sum: 110, Min: -5, Max: 100}},
+		{"VHistogram", v, func() { v.Add(1); v.Add(2) }, HistogramStats{Count: 2, Sum: 3, Min: 1, Max: 2}},
+		{"VSumHistogram", sum, func() { sum.Add(30); sum.Add(-30); sum.Add(3) }, HistogramStats{Count: 3, Sum: 3, Min: -30, Max: 30}},
+		{"FixedSumUHistogram", u, func() { u.Add(7); u.Add(5) }, HistogramStats{Count: 2, Sum: 12, Min: 5, Max: 7}},
+		{"FUHistogram", f, func() { f.Add(0.25); f.Add(2.5) }, HistogramStats{Count: 2, Sum: 2.75, Min: 0.25, Max: 2.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.Stats(); got != (HistogramStats{}) {
+				t.Errorf("Stats() = %+v, want zero", got)
+			}
+			tt.add()
+			if got := tt.h.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+			// read must not change stats
+			if got := tt.h.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+			tt.h.Clear()
+			if got := tt.h.Stats(); got != (HistogramStats{}) {
+				t.Errorf("Stats() after Clear() = %+v, want zero", got)
+			}
+		})
+	}
+}
+
+func TestHistogramStats_Snapshot(t *testing.T) {
+	h, err := NewVSumHistogram([]int64{10, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Add(5)
+	h.Add(25)
+	s := h.Snapshot()
+	h.Add(100)
+	if s.Count() != 2 || s.Sum() != 30 || s.Min() != 5 || s.Max() != 25 {
+		t.Errorf("Snapshot() count = %d, sum = %d, min = %d, max = %d, want 2, 30, 5, 25", s.Count(), s.Sum(), s.Min(), s.Max())
+	}
+	if want := []uint64{2, 1, 1}; !reflect.DeepEqual(want, s.Values()) {
+		t.Errorf("Snapshot().Values() = %v, want %v", s.Values(), want)
+	}
+	if mean := s.Stats().Mean(); mean != 15 {
+		t.Errorf("Snapshot().Stats().Mean() = %f, want 15", mean)
+	}
+	if h.Max() != 100 || h.Count() != 3 {
+		t.Errorf("Max() = %d, Count() = %d, want 100, 3", h.Max(), h.Count())
+	}
+}
+
+func TestSnapshotHistogram(t *testing.T) {
+	vh, _ := NewVHistogram([]int64{10, 20}, nil)
+	uh, _ := NewVUHistogram([]uint64{10, 20}, nil)
+	fh, _ := NewFUHistogram([]float64{10, 20}, nil)
+	dh, _ := NewDurationHistogram([]time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, nil, time.Millisecond)
+	eh := NewExpHistogram(160, 0)
+	add := []func(v int64){
+		vh.Add,
+		func(v int64) { uh.Add(uint64(v)) },
+		func(v int64) { fh.Add(float64(v)) },
+		func(v int64) { dh.Observe(time.Duration(v) * time.Millisecond) },
+		func(v int64) { eh.Add(float64(v)) },
+	}
+	for i, h := range []HistogramInterface{vh, uh, fh, dh, eh} {
+		add[i](5)
+		add[i](25)
+		s := SnapshotHistogram(h)
+		add[i](15)
+
+		var total uint64
+		for _, v := range s.Values() {
+			total += v
+		}
+		if stats := s.Stats(); stats.Count != 2 || total != 2 || stats.Max != 25 {
+			t.Errorf("[%d] %T snapshot count = %d, buckets total = %d, max = %f, want 2, 2, 25", i, h, stats.Count, total, stats.Max)
+		}
+		if q := s.Quantile(1); q < 20 {
+			t.Errorf("[%d] %T snapshot Quantile(1) = %f, want >= 20", i, h, q)
+		}
+		if h.Stats().Count != 3 {
+			t.Errorf("[%d] %T count = %d, want 3", i, h, h.Stats().Count)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDurationUnit    = errors.New("duration unit must be positive")
+	ErrDurationWeights = errors.New("duration weights must be multiple of unit")
+)
+
+// A DurationHistogram records time.Duration observations into underlying histogram.
+// Durations are scaled to unit (time.Nanosecond, time.Microsecond, time.Millisecond, time.Second, etc.),
+// weights aliases (le tag values) are also in unit.
+//
+// Graphite naming scheme is the same as for underlying histogram.
+type DurationHistogram interface {
+	HistogramInterface
+	// Observe records duration
+	Observe(d time.Duration)
+	// Time records the duration of the execution of f
+	Time(f func())
+	// UpdateSince records the duration elapsed since start
+	UpdateSince(start time.Time)
+	// Unit returns unit, used for scale durations
+	Unit() time.Duration
+}
+
+func GetOrRegisterDurationHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := durationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		h, _ := NewDurationHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+func GetOrRegisterDurationHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := durationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		h, _ := NewDurationHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+// NewRegisteredDurationHistogram constructs and registers a new DurationHistogram over VHistogram.
+func NewRegisteredDurationHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewDurationHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.Register(name, h)
+	return h, nil
+}
+
+// NewRegisteredDurationHistogramT constructs and registers a new DurationHistogram over VHistogram.
+func NewRegisteredDurationHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewDurationHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.RegisterT(name, tagsMap, h)
+	return h, nil
+}
+
+func GetOrRegisterDurationSumHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := durationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		h, _ := NewDurationSumHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+func GetOrRegisterDurationSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := durationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		h, _ := NewDurationSumHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+// NewRegisteredDurationSumHistogram constructs and registers a new DurationHistogram over VSumHistogram (prometheus-like histogram).
+func NewRegisteredDurationSumHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewDurationSumHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.Register(name, h)
+	return h, nil
+}
+
+// NewRegisteredDurationSumHistogramT constructs and registers a new DurationHistogram over VSumHistogram (prometheus-like histogram).
+func NewRegisteredDurationSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewDurationSumHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.RegisterT(name, tagsMap, h)
+	return h, nil
+}
+
+func GetOrRegisterFDurationHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := fDurationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		h, _ := NewFDurationHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+func GetOrRegisterFDurationHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := fDurationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		h, _ := NewFDurationHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+// NewRegisteredFDurationHistogram constructs and registers a new DurationHistogram over FUHistogram.
+func NewRegisteredFDurationHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewFDurationHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.Register(name, h)
+	return h, nil
+}
+
+// NewRegisteredFDurationHistogramT constructs and registers a new DurationHistogram over FUHistogram.
+func NewRegisteredFDurationHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewFDurationHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.RegisterT(name, tagsMap, h)
+	return h, nil
+}
+
+func GetOrRegisterFDurationSumHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := fDurationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		h, _ := NewFDurationSumHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+func GetOrRegisterFDurationSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if _, err := fDurationWeights(weights, unit); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		h, _ := NewFDurationSumHistogram(weights, names, unit)
+		return h
+	}).(DurationHistogram), nil
+}
+
+// NewRegisteredFDurationSumHistogram constructs and registers a new DurationHistogram over VSumFHistogram (prometheus-like histogram).
+func NewRegisteredFDurationSumHistogram(name string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewFDurationSumHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.Register(name, h)
+	return h, nil
+}
+
+// NewRegisteredFDurationSumHistogramT constructs and registers a new DurationHistogram over VSumFHistogram (prometheus-like histogram).
+func NewRegisteredFDurationSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []time.Duration, names []string, unit time.Duration) (DurationHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewFDurationSumHistogram(weights, names, unit)
+	if err != nil {
+		return nil, err
+	}
+	r.RegisterT(name, tagsMap, h)
+	return h, nil
+}
+
+// durationWeights scales weights to integer units
+func durationWeights(weights []time.Duration, unit time.Duration) ([]int64, error) {
+	if unit <= 0 {
+		return nil, ErrDurationUnit
+	}
+	w := make([]int64, len(weights))
+	for i, d := range weights {
+		if d%unit != 0 {
+			return nil, ErrDurationWeights
+		}
+		w[i] = int64(d / unit)
+	}
+	if !IsSortedSliceInt64Ge(w) {
+		return nil, ErrUnsortedWeights
+	}
+	return w, nil
+}
+
+// fDurationWeights scales weights to fractional units
+func fDurationWeights(weights []time.Duration, unit time.Duration) ([]float64, error) {
+	if unit <= 0 {
+		return nil, ErrDurationUnit
+	}
+	w := make([]float64, len(weights))
+	for i, d := range weights {
+		w[i] = float64(d) / float64(unit)
+	}
+	if !IsSortedSliceFloat64Le(w) {
+		return nil, ErrUnsortedWeights
+	}
+	return w, nil
+}
+
+// fDurationAlias formats weight alias (for le key) without precision loss, sub-unit weights are common (like 0.005 s)
+func fDurationAlias(w float64) string {
+	return strings.ReplaceAll(strconv.FormatFloat(w, 'f', -1, 64), ".", "_")
+}
+
+// IDurationHistogram is a DurationHistogram over int64 Histogram.
+// Durations are rounded up to unit, so observation is always counted in the right le bucket.
+type IDurationHistogram struct {
+	Histogram
+	unit time.Duration
+}
+
+// NewDurationHistogram constructs a new DurationHistogram over VHistogram.
+// Weights must be multiple of unit.
+func NewDurationHistogram(weights []time.Duration, names []string, unit time.Duration) (*IDurationHistogram, error) {
+	w, err := durationWeights(weights, unit)
+	if err != nil {
+		return nil, err
+	}
+	h, err := NewVHistogram(w, names)
+	if err != nil {
+		return nil, err
+	}
+	return &IDurationHistogram{
+		Histogram: h,
+		unit:      unit,
+	}, nil
+}
+
+// NewDurationSumHistogram constructs a new DurationHistogram over VSumHistogram (prometheus-like histogram).
+// Weights must be multiple of unit.
+func NewDurationSumHistogram(weights []time.Duration, names []string, unit time.Duration) (*IDurationHistogram, error) {
+	w, err := durationWeights(weights, unit)
+	if err != nil {
+		return nil, err
+	}
+	h, err := NewVSumHistogram(w, names)
+	if err != nil {
+		return nil, err
+	}
+	return &IDurationHistogram{
+		Histogram: h,
+		unit:      unit,
+	}, nil
+}
+
+func (h *IDurationHistogram) Observe(d time.Duration) {
+	v := d / h.unit
+	if d%h.unit > 0 {
+		v++
+	}
+	h.Histogram.Add(int64(v))
+}
+
+func (h *IDurationHistogram) Time(f func()) {
+	start := time.Now()
+	f()
+	h.Observe(time.Since(start))
+}
+
+func (h *IDurationHistogram) UpdateSince(start time.Time) {
+	h.Observe(time.Since(start))
+}
+
+func (h *IDurationHistogram) Unit() time.Duration {
+	return h.unit
+}
+
+// FDurationHistogram is a DurationHistogram over float64 FHistogram.
+// Durations are stored as fractional units.
+type FDurationHistogram struct {
+	FHistogram
+	unit time.Duration
+}
+
+// NewFDurationHistogram constructs a new DurationHistogram over FUHistogram.
+func NewFDurationHistogram(weights []time.Duration, names []string, unit time.Duration) (*FDurationHistogram, error) {
+	w, err := fDurationWeights(weights, unit)
+	if err != nil {
+		return nil, err
+	}
+	h, err := newFUHistogram(w, names, fDurationAlias)
+	if err != nil {
+		return nil, err
+	}
+	return &FDurationHistogram{
+		FHistogram: h,
+		unit:       unit,
+	}, nil
+}
+
+// NewFDurationSumHistogram constructs a new DurationHistogram over VSumFHistogram (prometheus-like histogram).
+func NewFDurationSumHistogram(weights []time.Duration, names []string, unit time.Duration) (*FDurationHistogram, error) {
+	w, err := fDurationWeights(weights, unit)
+	if err != nil {
+		return nil, err
+	}
+	h, err := newVSumFHistogram(w, names, fDurationAlias)
+	if err != nil {
+		return nil, err
+	}
+	return &FDurationHistogram{
+		FHistogram: h,
+		unit:       unit,
+	}, nil
+}
+
+func (h *FDurationHistogram) Observe(d time.Duration) {
+	h.FHistogram.Add(float64(d) / float64(h.unit))
+}
+
+func (h *FDurationHistogram) Time(f func()) {
+	start := time.Now()
+	f()
+	h.Observe(time.Since(start))
+}
+
+func (h *FDurationHistogram) UpdateSince(start time.Time) {
+	h.Observe(time.Since(start))
+}
+
+func (h *FDurationHistogram) Unit() time.Duration {
+	return h.unit
+}
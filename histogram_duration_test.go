@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDurationHistogram(t *testing.T) {
+	weights := []time.Duration{time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond}
+	tests := []struct {
+		name        string
+		h           DurationHistogram
+		wantAliases []string
+		wantValues  []uint64
+		wantStats   HistogramStats
+	}{
+		{
+			name:        "int",
+			h:           mustDurationHistogram(NewDurationHistogram(weights, nil, time.Millisecond)),
+			wantAliases: []string{"1", "5", "10", "inf"},
+			// 1.5ms rounded up to 2ms
+			wantValues: []uint64{1, 2, 0, 1},
+			wantStats:  HistogramStats{Count: 4, Sum: 23, Min: 1, Max: 15},
+		},
+		{
+			name:        "int sum",
+			h:           mustDurationHistogram(NewDurationSumHistogram(weights, nil, time.Millisecond)),
+			wantAliases: []string{"1", "5", "10", "inf"},
+			wantValues:  []uint64{4, 3, 1, 1},
+			wantStats:   HistogramStats{Count: 4, Sum: 23, Min: 1, Max: 15},
+		},
+		{
+			name:        "float",
+			h:           mustDurationHistogram(NewFDurationHistogram(weights, nil, time.Second)),
+			wantAliases: []string{"0_001", "0_005", "0_01", "inf"},
+			wantValues:  []uint64{1, 2, 0, 1},
+			wantStats:   HistogramStats{Count: 4, Sum: 0.0225, Min: 0.001, Max: 0.015},
+		},
+		{
+			name:        "float sum",
+			h:           mustDurationHistogram(NewFDurationSumHistogram(weights, nil, time.Millisecond)),
+			wantAliases: []string{"1", "5", "10", "inf"},
+			wantValues:  []uint64{4, 3, 1, 1},
+			wantStats:   HistogramStats{Count: 4, Sum: 22.5, Min: 1, Max: 15},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, d := range []time.Duration{time.Millisecond, 1500 * time.Microsecond, 5 * time.Millisecond, 15 * time.Millisecond} {
+				tt.h.Observe(d)
+			}
+			if got := tt.h.WeightsAliases(); !reflect.DeepEqual(got, tt.wantAliases) {
+				t.Errorf("WeightsAliases() = %q, want %q", got, tt.wantAliases)
+			}
+			if got := tt.h.Values(); !reflect.DeepEqual(got, tt.wantValues) {
+				t.Errorf("Values() = %v, want %v", got, tt.wantValues)
+			}
+			got := tt.h.Stats()
+			if got.Count != tt.wantStats.Count || !fEqual(got.Sum, tt.wantStats.Sum) || !fEqual(got.Min, tt.wantStats.Min) || !fEqual(got.Max, tt.wantStats.Max) {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.wantStats)
+			}
+
+			tt.h.Time(func() {})
+			tt.h.UpdateSince(time.Now())
+			if got := tt.h.Stats().Count; got != tt.wantStats.Count+2 {
+				t.Errorf("Stats().Count after Time() and UpdateSince() = %d, want %d", got, tt.wantStats.Count+2)
+			}
+		})
+	}
+}
+
+func mustDurationHistogram(h DurationHistogram, err error) DurationHistogram {
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func fEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
+
+func TestDurationHistogram_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []time.Duration
+		unit    time.Duration
+		want    error
+	}{
+		{name: "zero unit", weights: []time.Duration{time.Second}, unit: 0, want: ErrDurationUnit},
+		{name: "weight not multiple of unit", weights: []time.Duration{time.Millisecond, 1500 * time.Microsecond}, unit: time.Millisecond, want: ErrDurationWeights},
+		{name: "unsorted", weights: []time.Duration{time.Second, time.Millisecond}, unit: time.Millisecond, want: ErrUnsortedWeights},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDurationHistogram(tt.weights, nil, tt.unit); err != tt.want {
+				t.Errorf("NewDurationHistogram() error = %v, want %v", err, tt.want)
+			}
+			if _, err := GetOrRegisterDurationHistogram("latency", NewRegistry(), tt.weights, nil, tt.unit); err != tt.want {
+				t.Errorf("GetOrRegisterDurationHistogram() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationHistogram_Registry(t *testing.T) {
+	r := NewRegistry()
+	h, err := GetOrRegisterDurationHistogram("latency", r, []time.Duration{time.Millisecond}, nil, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Observe(time.Millisecond)
+	if got, _ := GetOrRegisterDurationHistogram("latency", r, []time.Duration{time.Second}, nil, time.Second); got != h {
+		t.Errorf("GetOrRegisterDurationHistogram() = %v, want registered %v", got, h)
+	}
+	if _, ok := r.Get("latency").(HistogramInterface); !ok {
+		t.Errorf("registered DurationHistogram is not a HistogramInterface")
+	}
+}
+
+func BenchmarkDurationHistogram(b *testing.B) {
+	h, err := NewDurationHistogram([]time.Duration{time.Millisecond, 10 * time.Millisecond, 100 * time.Millisecond}, nil, time.Millisecond)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Observe(time.Duration(i))
+	}
+}
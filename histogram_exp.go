@@ -0,0 +1,569 @@
+package metrics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// ExpHistogramMaxScale is a maximum scale of ExpHistogram (OpenTelemetry limit)
+	ExpHistogramMaxScale = 20
+	// ExpHistogramMinScale is a minimum scale of ExpHistogram (OpenTelemetry limit)
+	ExpHistogramMinScale = -10
+	// ExpHistogramMaxSize is a default maximum buckets count for positive (and negative) values range
+	ExpHistogramMaxSize = 160
+)
+
+// ExpHistogramBuckets is a buckets range of ExpHistogram, like OTLP ExponentialHistogramDataPoint.Buckets:
+// BucketCounts[i] counts values in (base^(Offset+i), base^(Offset+i+1)], base = 2^(2^-scale).
+type ExpHistogramBuckets struct {
+	Offset       int32
+	BucketCounts []uint64
+}
+
+// An ExpHistogram is OpenTelemetry-like base-2 exponential histogram.
+// Scale is reduced (and buckets merged) automatically as observations arrive, so buckets count is bounded by max size.
+//
+// As HistogramInterface it exported like non-summed FHistogram, with buckets upper bounds as le.
+// Buckets layout can be changed between calls, so use Snapshot() for consistent read.
+type ExpHistogram interface {
+	HistogramInterface
+	Add(v float64)
+	Snapshot() ExpHistogram
+	// Current scale, base = 2^(2^-scale)
+	Scale() int32
+	// Zero observations count
+	ZeroCount() uint64
+	// Positive observations buckets
+	Positive() ExpHistogramBuckets
+	// Negative observations buckets (by absolute value)
+	Negative() ExpHistogramBuckets
+	// Observations count
+	Count() uint64
+	// Observations sum
+	Sum() float64
+	// Minimal observation (or zero without observations)
+	Min() float64
+	// Maximal observation (or zero without observations)
+	Max() float64
+}
+
+// GetOrRegisterExpHistogram returns an existing ExpHistogram or constructs and registers
+// a new StandardExpHistogram.
+func GetOrRegisterExpHistogram(name string, r Registry, maxSize int, maxScale int32) ExpHistogram {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		return NewExpHistogram(maxSize, maxScale)
+	}).(ExpHistogram)
+}
+
+// GetOrRegisterExpHistogramT returns an existing ExpHistogram or constructs and registers
+// a new StandardExpHistogram.
+func GetOrRegisterExpHistogramT(name string, tagsMap map[string]string, r Registry, maxSize int, maxScale int32) ExpHistogram {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		return NewExpHistogram(maxSize, maxScale)
+	}).(ExpHistogram)
+}
+
+// NewRegisteredExpHistogram constructs and registers a new StandardExpHistogram.
+func NewRegisteredExpHistogram(name string, r Registry, maxSize int, maxScale int32) ExpHistogram {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h := NewExpHistogram(maxSize, maxScale)
+	r.Register(name, h)
+	return h
+}
+
+// NewRegisteredExpHistogramT constructs and registers a new StandardExpHistogram.
+func NewRegisteredExpHistogramT(name string, tagsMap map[string]string, r Registry, maxSize int, maxScale int32) ExpHistogram {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h := NewExpHistogram(maxSize, maxScale)
+	r.RegisterT(name, tagsMap, h)
+	return h
+}
+
+// NewExpHistogram constructs a new StandardExpHistogram with maxSize buckets for positive (and negative) values range
+// (ExpHistogramMaxSize if maxSize <= 0) and start maxScale (clamped to [ExpHistogramMinScale, ExpHistogramMaxScale]).
+func NewExpHistogram(maxSize int, maxScale int32) ExpHistogram {
+	if UseNilMetrics {
+		return NilExpHistogram{}
+	}
+	if maxSize <= 0 {
+		maxSize = ExpHistogramMaxSize
+	} else if maxSize < 2 {
+		maxSize = 2
+	}
+	if maxScale > ExpHistogramMaxScale {
+		maxScale = ExpHistogramMaxScale
+	} else if maxScale < ExpHistogramMinScale {
+		maxScale = ExpHistogramMinScale
+	}
+	h := &StandardExpHistogram{
+		h: expHistogram{maxSize: maxSize, maxScale: maxScale},
+	}
+	h.h.reset()
+	return h
+}
+
+// expIndex returns bucket index for positive value at scale: base^index < v <= base^(index+1)
+func expIndex(v float64, scale int32) int32 {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, frac in [0.5, 1)
+	if scale <= 0 {
+		e := exp - 1
+		if frac == 0.5 {
+			// exact power of two is upper bound of lower bucket
+			e--
+		}
+		return int32(e) >> uint(-scale)
+	}
+	if frac == 0.5 {
+		return int32(exp-1)<<uint(scale) - 1
+	}
+	return int32(math.Ceil(math.Log(v)*math.Ldexp(math.Log2E, int(scale)))) - 1
+}
+
+// expLowerBoundary returns bucket lower bound at scale: base^index
+func expLowerBoundary(index, scale int32) float64 {
+	if scale <= 0 {
+		return math.Ldexp(1, int(index)<<uint(-scale))
+	}
+	return math.Exp(math.Ldexp(float64(index)*math.Ln2, -int(scale)))
+}
+
+type expBuckets struct {
+	offset int32
+	counts []uint64
+}
+
+// span returns indexes range, required for store index
+func (b *expBuckets) span(index int32) (lo, hi int32) {
+	if len(b.counts) == 0 {
+		return index, index
+	}
+	lo, hi = b.offset, b.offset+int32(len(b.counts))-1
+	if index < lo {
+		lo = index
+	} else if index > hi {
+		hi = index
+	}
+	return
+}
+
+func (b *expBuckets) increment(index int32) {
+	if len(b.counts) == 0 {
+		b.counts = append(b.counts, 0)
+		b.offset = index
+	} else if index < b.offset {
+		counts := make([]uint64, int(b.offset-index)+len(b.counts))
+		copy(counts[b.offset-index:], b.counts)
+		b.counts = counts
+		b.offset = index
+	} else if end := b.offset + int32(len(b.counts)); index >= end {
+		b.counts = append(b.counts, make([]uint64, index-end+1)...)
+	}
+	b.counts[index-b.offset]++
+}
+
+// downscale merges buckets for scale reduce by change
+func (b *expBuckets) downscale(change int32) {
+	if len(b.counts) == 0 || change == 0 {
+		return
+	}
+	offset := b.offset >> uint(change)
+	end := (b.offset + int32(len(b.counts)) - 1) >> uint(change)
+	counts := make([]uint64, end-offset+1)
+	for i, c := range b.counts {
+		counts[((b.offset+int32(i))>>uint(change))-offset] += c
+	}
+	b.offset = offset
+	b.counts = counts
+}
+
+func (b *expBuckets) copy() ExpHistogramBuckets {
+	counts := make([]uint64, len(b.counts))
+	copy(counts, b.counts)
+	return ExpHistogramBuckets{Offset: b.offset, BucketCounts: counts}
+}
+
+// expHistogram is an ExpHistogram state without locking.
+type expHistogram struct {
+	maxSize  int
+	maxScale int32
+
+	scale     int32
+	positive  expBuckets
+	negative  expBuckets
+	zeroCount uint64
+
+	count uint64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+func (h *expHistogram) reset() {
+	h.scale = h.maxScale
+	h.positive = expBuckets{}
+	h.negative = expBuckets{}
+	h.zeroCount = 0
+	h.count = 0
+	h.sum = 0
+	h.min = math.Inf(1)
+	h.max = math.Inf(-1)
+}
+
+func (h *expHistogram) add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	h.count++
+	h.sum += v
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	if v == 0 {
+		h.zeroCount++
+		return
+	}
+
+	b := &h.positive
+	if v < 0 {
+		b = &h.negative
+		v = -v
+	}
+	index := expIndex(v, h.scale)
+	lo, hi := b.span(index)
+	var change int32
+	for int64(hi)-int64(lo) >= int64(h.maxSize) && h.scale-change > ExpHistogramMinScale {
+		lo >>= 1
+		hi >>= 1
+		change++
+	}
+	if change > 0 {
+		h.scale -= change
+		h.positive.downscale(change)
+		h.negative.downscale(change)
+		index >>= uint(change)
+	}
+	b.increment(index)
+}
+
+func (h *expHistogram) stats() HistogramStats {
+	if h.count == 0 {
+		return HistogramStats{}
+	}
+	return HistogramStats{Count: h.count, Sum: h.sum, Min: h.min, Max: h.max}
+}
+
+func (h *expHistogram) snapshot() *ExpHistogramSnapshot {
+	stats := h.stats()
+	s := &ExpHistogramSnapshot{
+		scale:     h.scale,
+		zeroCount: h.zeroCount,
+		positive:  h.positive.copy(),
+		negative:  h.negative.copy(),
+		count:     stats.Count,
+		sum:       stats.Sum,
+		min:       stats.Min,
+		max:       stats.Max,
+	}
+
+	n := len(s.negative.BucketCounts) + len(s.positive.BucketCounts)
+	withZero := s.zeroCount > 0 || len(s.negative.BucketCounts) > 0
+	if withZero {
+		n++
+	}
+	s.weights = make([]float64, 0, n)
+	s.buckets = make([]uint64, 0, n)
+	// negative buckets from highest absolute values, upper bound is -base^index
+	for i := len(s.negative.BucketCounts) - 1; i >= 0; i-- {
+		s.weights = append(s.weights, -expLowerBoundary(s.negative.Offset+int32(i), s.scale))
+		s.buckets = append(s.buckets, s.negative.BucketCounts[i])
+	}
+	if withZero {
+		s.weights = append(s.weights, 0)
+		s.buckets = append(s.buckets, s.zeroCount)
+	}
+	for i, c := range s.positive.BucketCounts {
+		s.weights = append(s.weights, expLowerBoundary(s.positive.Offset+int32(i)+1, s.scale))
+		s.buckets = append(s.buckets, c)
+	}
+
+	// enough digits for distinguish neighbour bounds at current scale
+	digits := int(math.Ceil(-math.Log10(math.Ln2*math.Ldexp(1, -int(s.scale))))) + 2
+	if digits < 3 {
+		digits = 3
+	}
+	s.weightsAliases = make([]string, len(s.weights))
+	s.labels = make([]string, len(s.weights))
+	for i, w := range s.weights {
+		s.weightsAliases[i] = strings.ReplaceAll(strconv.FormatFloat(w, 'g', digits, 64), ".", "_")
+		s.labels[i] = "." + s.weightsAliases[i]
+	}
+
+	return s
+}
+
+// NilExpHistogram is a no-op ExpHistogram.
+type NilExpHistogram struct{}
+
+func (NilExpHistogram) Add(v float64) {}
+
+func (NilExpHistogram) Clear() []uint64 { return nil }
+
+func (NilExpHistogram) Values() []uint64 { return nil }
+
+func (NilExpHistogram) Labels() []string { return nil }
+
+func (NilExpHistogram) NameTotal() string { return "total" }
+
+func (NilExpHistogram) WeightsAliases() []string { return nil }
+
+func (NilExpHistogram) IsSummed() bool { return false }
+
+func (NilExpHistogram) Stats() HistogramStats { return HistogramStats{} }
+
+func (NilExpHistogram) Quantile(float64) float64 { return 0 }
+
+func (NilExpHistogram) Quantiles(qs []float64) []float64 { return make([]float64, len(qs)) }
+
+func (NilExpHistogram) Snapshot() ExpHistogram { return NilExpHistogram{} }
+
+func (NilExpHistogram) Scale() int32 { return 0 }
+
+func (NilExpHistogram) ZeroCount() uint64 { return 0 }
+
+func (NilExpHistogram) Positive() ExpHistogramBuckets { return ExpHistogramBuckets{} }
+
+func (NilExpHistogram) Negative() ExpHistogramBuckets { return ExpHistogramBuckets{} }
+
+func (NilExpHistogram) Count() uint64 { return 0 }
+
+func (NilExpHistogram) Sum() float64 { return 0 }
+
+func (NilExpHistogram) Min() float64 { return 0 }
+
+func (NilExpHistogram) Max() float64 { return 0 }
+
+// ExpHistogramSnapshot is a read-only copy of ExpHistogram.
+type ExpHistogramSnapshot struct {
+	scale     int32
+	zeroCount uint64
+	positive  ExpHistogramBuckets
+	negative  ExpHistogramBuckets
+	count     uint64
+	sum       float64
+	min       float64
+	max       float64
+
+	// rendered buckets: negative, zero and positive
+	weights        []float64 // buckets upper bounds
+	weightsAliases []string
+	labels         []string
+	buckets        []uint64
+}
+
+func (*ExpHistogramSnapshot) Add(float64) {
+	panic("Add called on a ExpHistogramSnapshot")
+}
+
+func (*ExpHistogramSnapshot) Clear() []uint64 {
+	panic("Clear called on a ExpHistogramSnapshot")
+}
+
+func (h *ExpHistogramSnapshot) Values() []uint64 {
+	return h.buckets
+}
+
+func (h *ExpHistogramSnapshot) Labels() []string {
+	return h.labels
+}
+
+func (h *ExpHistogramSnapshot) NameTotal() string {
+	return ".total"
+}
+
+// Weights returns buckets upper bounds
+func (h *ExpHistogramSnapshot) Weights() []float64 {
+	return h.weights
+}
+
+func (h *ExpHistogramSnapshot) WeightsAliases() []string {
+	return h.weightsAliases
+}
+
+func (*ExpHistogramSnapshot) IsSummed() bool { return false }
+
+func (h *ExpHistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: h.sum, Min: h.min, Max: h.max}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *ExpHistogramSnapshot) Quantile(q float64) float64 {
+	return bucketQuantile(q, h.weight, h.buckets, false, h.Stats())
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *ExpHistogramSnapshot) Quantiles(qs []float64) []float64 {
+	return bucketQuantiles(qs, h.weight, h.buckets, false, h.Stats())
+}
+
+func (h *ExpHistogramSnapshot) weight(i int) float64 {
+	return h.weights[i]
+}
+
+func (h *ExpHistogramSnapshot) Snapshot() ExpHistogram {
+	return h
+}
+
+func (h *ExpHistogramSnapshot) Scale() int32 {
+	return h.scale
+}
+
+func (h *ExpHistogramSnapshot) ZeroCount() uint64 {
+	return h.zeroCount
+}
+
+func (h *ExpHistogramSnapshot) Positive() ExpHistogramBuckets {
+	return h.positive
+}
+
+func (h *ExpHistogramSnapshot) Negative() ExpHistogramBuckets {
+	return h.negative
+}
+
+func (h *ExpHistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *ExpHistogramSnapshot) Sum() float64 {
+	return h.sum
+}
+
+func (h *ExpHistogramSnapshot) Min() float64 {
+	return h.min
+}
+
+func (h *ExpHistogramSnapshot) Max() float64 {
+	return h.max
+}
+
+// StandardExpHistogram is the standard implementation of ExpHistogram.
+type StandardExpHistogram struct {
+	h    expHistogram
+	lock sync.Mutex
+}
+
+func (h *StandardExpHistogram) Add(v float64) {
+	h.lock.Lock()
+	h.h.add(v)
+	h.lock.Unlock()
+}
+
+// Clear returns buckets values and reset histogram (and scale)
+func (h *StandardExpHistogram) Clear() []uint64 {
+	h.lock.Lock()
+	s := h.h.snapshot()
+	h.h.reset()
+	h.lock.Unlock()
+	return s.buckets
+}
+
+func (h *StandardExpHistogram) snapshot() *ExpHistogramSnapshot {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.snapshot()
+}
+
+func (h *StandardExpHistogram) Snapshot() ExpHistogram {
+	return h.snapshot()
+}
+
+func (h *StandardExpHistogram) Values() []uint64 {
+	return h.snapshot().Values()
+}
+
+func (h *StandardExpHistogram) Labels() []string {
+	return h.snapshot().Labels()
+}
+
+func (h *StandardExpHistogram) NameTotal() string {
+	return ".total"
+}
+
+func (h *StandardExpHistogram) WeightsAliases() []string {
+	return h.snapshot().WeightsAliases()
+}
+
+func (*StandardExpHistogram) IsSummed() bool { return false }
+
+func (h *StandardExpHistogram) Stats() HistogramStats {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.stats()
+}
+
+func (h *StandardExpHistogram) Quantile(q float64) float64 {
+	return h.snapshot().Quantile(q)
+}
+
+func (h *StandardExpHistogram) Quantiles(qs []float64) []float64 {
+	return h.snapshot().Quantiles(qs)
+}
+
+func (h *StandardExpHistogram) Scale() int32 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.scale
+}
+
+func (h *StandardExpHistogram) ZeroCount() uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.zeroCount
+}
+
+func (h *StandardExpHistogram) Positive() ExpHistogramBuckets {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.positive.copy()
+}
+
+func (h *StandardExpHistogram) Negative() ExpHistogramBuckets {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.negative.copy()
+}
+
+func (h *StandardExpHistogram) Count() uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.count
+}
+
+func (h *StandardExpHistogram) Sum() float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.h.sum
+}
+
+func (h *StandardExpHistogram) Min() float64 {
+	return h.Stats().Min
+}
+
+func (h *StandardExpHistogram) Max() float64 {
+	return h.Stats().Max
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestExpIndex(t *testing.T) {
+	values := []float64{
+		1, 2, 3, 4, 0.5, 0.25, 0.3, 1.5, 1.0001, 10, 100, 1000.5, 1e-9, 1e9, 1e300, 1e-300,
+		math.MaxFloat64,
+	}
+	for scale := int32(ExpHistogramMinScale); scale <= ExpHistogramMaxScale; scale++ {
+		for _, v := range values {
+			index := expIndex(v, scale)
+			lower := expLowerBoundary(index, scale)
+			upper := expLowerBoundary(index+1, scale)
+			// boundaries calculated with float64 precision
+			if !(lower <= v*(1+1e-12)) || !(v <= upper*(1+1e-12)) || (lower == v && lower != 0 && !math.IsInf(lower, 0)) {
+				t.Errorf("scale %d expIndex(%g) = %d, want bucket (%g, %g]", scale, v, index, lower, upper)
+			}
+		}
+	}
+	// exact powers of two are upper bounds of buckets
+	if got := expIndex(4, 0); got != 1 {
+		t.Errorf("scale 0 expIndex(4) = %d, want 1", got)
+	}
+	if got := expIndex(4, 2); got != 7 {
+		t.Errorf("scale 2 expIndex(4) = %d, want 7", got)
+	}
+	if got := expIndex(4, -1); got != 0 {
+		t.Errorf("scale -1 expIndex(4) = %d, want 0", got)
+	}
+}
+
+func TestExpHistogram_Scale(t *testing.T) {
+	// values 1, 2, 4 require 3 buckets at scale 0: (0.5, 1], (1, 2], (2, 4]
+	h := NewExpHistogram(4, 20)
+	h.Add(1)
+	h.Add(2)
+	h.Add(4)
+	if h.Scale() != 0 {
+		t.Errorf("Scale() = %d, want 0", h.Scale())
+	}
+	if want := (ExpHistogramBuckets{Offset: -1, BucketCounts: []uint64{1, 1, 1}}); !reflect.DeepEqual(h.Positive(), want) {
+		t.Errorf("Positive() = %+v, want %+v", h.Positive(), want)
+	}
+
+	h = NewExpHistogram(20, 20)
+	var count uint64
+	for v := 1.0; v <= 1e6; v *= 1.1 {
+		h.Add(v)
+		h.Add(-v)
+		count += 2
+	}
+	h.Add(0)
+	s := h.Snapshot()
+	if s.Scale() >= 20 {
+		t.Errorf("Scale() = %d, want downscaled", s.Scale())
+	}
+	for _, b := range []ExpHistogramBuckets{s.Positive(), s.Negative()} {
+		if len(b.BucketCounts) > 20 {
+			t.Errorf("buckets count = %d, want <= 20", len(b.BucketCounts))
+		}
+		var n uint64
+		for _, c := range b.BucketCounts {
+			n += c
+		}
+		if n != count/2 {
+			t.Errorf("buckets total = %d, want %d", n, count/2)
+		}
+	}
+	if s.ZeroCount() != 1 || s.Count() != count+1 {
+		t.Errorf("ZeroCount() = %d, Count() = %d, want 1, %d", s.ZeroCount(), s.Count(), count+1)
+	}
+
+	h.Clear()
+	if h.Scale() != 20 || h.Count() != 0 || len(h.Values()) != 0 {
+		t.Errorf("after Clear() Scale() = %d, Count() = %d, Values() = %v", h.Scale(), h.Count(), h.Values())
+	}
+}
+
+func TestExpHistogram_Buckets(t *testing.T) {
+	h := NewExpHistogram(160, 0)
+	for _, v := range []float64{-3, 0, 1, 2, 3, 3, 5, math.NaN(), math.Inf(1)} {
+		h.Add(v)
+	}
+	s := h.Snapshot()
+	if got, want := s.Values(), []uint64{1, 1, 1, 1, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %v, want %v", got, want)
+	}
+	if got, want := s.Labels(), []string{".-2", ".0", ".1", ".2", ".4", ".8"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels() = %v, want %v", got, want)
+	}
+	if got, want := s.WeightsAliases(), []string{"-2", "0", "1", "2", "4", "8"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WeightsAliases() = %v, want %v", got, want)
+	}
+	if want := (HistogramStats{Count: 7, Sum: 11, Min: -3, Max: 5}); s.Stats() != want {
+		t.Errorf("Stats() = %+v, want %+v", s.Stats(), want)
+	}
+	if got, want := s.Quantiles([]float64{0, 0.5, 1}), []float64{-3, 1.5, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Quantiles() = %v, want %v", got, want)
+	}
+
+	// labels precision is enough for neighbour buckets at maximum scale
+	h = NewExpHistogram(160, 20)
+	h.Add(1)
+	h.Add(1.000001)
+	h.Add(1.000002)
+	labels := h.Snapshot().Labels()
+	seen := make(map[string]bool)
+	for _, l := range labels {
+		if seen[l] {
+			t.Errorf("Labels() = %v, duplicate %q", labels, l)
+		}
+		seen[l] = true
+	}
+}
+
+func TestNilExpHistogram(t *testing.T) {
+	UseNilMetrics = true
+	defer func() { UseNilMetrics = false }()
+	h := NewExpHistogram(0, 20)
+	h.Add(1)
+	if _, ok := h.(NilExpHistogram); !ok || h.Snapshot().Count() != 0 {
+		t.Errorf("NewExpHistogram() = %T, want NilExpHistogram", h)
+	}
+}
+
+func TestExpHistogram_Concurrent(t *testing.T) {
+	h := NewExpHistogram(20, 20)
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 1000; i++ {
+				h.Add(float64(i))
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		h.Quantile(0.5)
+	}
+	wg.Wait()
+	if h.Count() != 8000 || h.Sum() != 8*1000*1001/2 {
+		t.Errorf("Count() = %d, Sum() = %v, want 8000, %d", h.Count(), h.Sum(), 8*1000*1001/2)
+	}
+}
+
+func BenchmarkExpHistogram(b *testing.B) {
+	h := NewExpHistogram(160, 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Add(float64(i))
+	}
+}
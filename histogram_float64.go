@@ -18,6 +18,14 @@ type FHistogram interface {
 	Snapshot() FHistogram
 	Add(v float64)
 	Weights() []float64
+	// Observations count
+	Count() uint64
+	// Observations sum
+	Sum() float64
+	// Minimal observation (or zero without observations)
+	Min() float64
+	// Maximal observation (or zero without observations)
+	Max() float64
 }
 
 // GetOrRegisterFHistogram returns an existing FHistogram or constructs and registers
@@ -62,42 +70,56 @@ func NewRegisteredFixedFHistogramT(name string, tagsMap map[string]string, r Reg
 	return h
 }
 
-func GetOrRegisterFUHistogram(name string, r Registry, weights []float64, names []string) FHistogram {
+func GetOrRegisterFUHistogram(name string, r Registry, weights []float64, names []string) (FHistogram, error) {
+	if !IsSortedSliceFloat64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegister(name, func() interface{} {
-		return NewFUHistogram(weights, names)
-	}).(FHistogram)
+		h, _ := NewFUHistogram(weights, names)
+		return h
+	}).(FHistogram), nil
 }
 
-func GetOrRegisterFUHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) FHistogram {
+func GetOrRegisterFUHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) (FHistogram, error) {
+	if !IsSortedSliceFloat64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
-		return NewFUHistogram(weights, names)
-	}).(FHistogram)
+		h, _ := NewFUHistogram(weights, names)
+		return h
+	}).(FHistogram), nil
 }
 
 // NewRegisteredVHistogram constructs and registers a new VHistogram.
-func NewRegisteredFUHistogram(name string, r Registry, weights []float64, names []string) FHistogram {
+func NewRegisteredFUHistogram(name string, r Registry, weights []float64, names []string) (FHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewFUHistogram(weights, names)
+	h, err := NewFUHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.Register(name, h)
-	return h
+	return h, nil
 }
 
 // NewRegisteredVHistogramT constructs and registers a new VHistogram.
-func NewRegisteredFUHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) FHistogram {
+func NewRegisteredFUHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) (FHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewFUHistogram(weights, names)
+	h, err := NewFUHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.RegisterT(name, tagsMap, h)
-	return h
+	return h, nil
 }
 
 func trimFloatZero(f string) string {
@@ -113,6 +135,11 @@ func trimFloatZero(f string) string {
 	return f[:d]
 }
 
+// fWeightAlias formats weight alias (for le key) with 2 digits precision
+func fWeightAlias(w float64) string {
+	return strings.ReplaceAll(trimFloatZero(strconv.FormatFloat(w, 'f', 2, 64)), ".", "_")
+}
+
 type NilFHistogram struct{}
 
 func (NilFHistogram) Values() []uint64 {
@@ -151,6 +178,20 @@ func (h NilFHistogram) Clear() []uint64 {
 
 func (NilFHistogram) Snapshot() FHistogram { return NilFHistogram{} }
 
+func (NilFHistogram) Count() uint64 { return 0 }
+
+func (NilFHistogram) Sum() float64 { return 0 }
+
+func (NilFHistogram) Min() float64 { return 0 }
+
+func (NilFHistogram) Max() float64 { return 0 }
+
+func (NilFHistogram) Stats() HistogramStats { return HistogramStats{} }
+
+func (NilFHistogram) Quantile(float64) float64 { return 0 }
+
+func (NilFHistogram) Quantiles(qs []float64) []float64 { return make([]float64, len(qs)) }
+
 func (NilFHistogram) IsSummed() bool { return false }
 
 type FHistogramSnapshot struct {
@@ -159,6 +200,10 @@ type FHistogramSnapshot struct {
 	labels         []string
 	total          string
 	buckets        []uint64 // last buckets stores all, not included at previous
+	count          uint64
+	sum            float64
+	min            float64
+	max            float64
 }
 
 func (h *FHistogramSnapshot) Values() []uint64 {
@@ -209,15 +254,113 @@ func (h *FHistogramSnapshot) Snapshot() FHistogram {
 	return h
 }
 
+func (h *FHistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *FHistogramSnapshot) Sum() float64 {
+	return h.sum
+}
+
+func (h *FHistogramSnapshot) Min() float64 {
+	return h.min
+}
+
+func (h *FHistogramSnapshot) Max() float64 {
+	return h.max
+}
+
+func (h *FHistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: h.sum, Min: h.min, Max: h.max}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *FHistogramSnapshot) Quantile(q float64) float64 {
+	return bucketQuantile(q, h.weight, h.buckets, false, h.Stats())
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *FHistogramSnapshot) Quantiles(qs []float64) []float64 {
+	return bucketQuantiles(qs, h.weight, h.buckets, false, h.Stats())
+}
+
+func (h *FHistogramSnapshot) weight(i int) float64 {
+	return h.weights[i]
+}
+
 func (h *FHistogramSnapshot) IsSummed() bool { return false }
 
+// Merge returns a new snapshot with observations from both snapshots (for example, from goroutine-local histograms).
+// Labels and total name are taken from h, weights must be equal.
+func (h *FHistogramSnapshot) Merge(other *FHistogramSnapshot) (*FHistogramSnapshot, error) {
+	if !equalFloat64Weights(h.weights, other.weights) || len(h.buckets) != len(other.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	min, max := mergeFloat64MinMax(h.count, h.min, h.max, other.count, other.min, other.max)
+	return &FHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		labels:         h.labels,
+		total:          h.total,
+		buckets:        mergeBuckets(h.buckets, other.buckets),
+		count:          h.count + other.count,
+		sum:            h.sum + other.sum,
+		min:            min,
+		max:            max,
+	}, nil
+}
+
+// Sub returns a new snapshot with observations added after prev snapshot of the same histogram.
+// Min and max can't be restored for the interval, so they are taken from h (or zero without new observations).
+func (h *FHistogramSnapshot) Sub(prev *FHistogramSnapshot) (*FHistogramSnapshot, error) {
+	if !equalFloat64Weights(h.weights, prev.weights) || len(h.buckets) != len(prev.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	if h.count < prev.count {
+		return nil, ErrHistogramDecreased
+	}
+	buckets, err := subBuckets(h.buckets, prev.buckets)
+	if err != nil {
+		return nil, err
+	}
+	s := &FHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		labels:         h.labels,
+		total:          h.total,
+		buckets:        buckets,
+		count:          h.count - prev.count,
+	}
+	if s.count > 0 {
+		s.sum = h.sum - prev.sum
+		s.min = h.min
+		s.max = h.max
+	}
+	return s, nil
+}
+
+// Cumulative returns a copy of snapshot with cumulative (summed, like in Sum histograms) counts.
+func (h *FHistogramSnapshot) Cumulative() *SumFHistogramSnapshot {
+	return &SumFHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.labels,
+		total:          h.total,
+		buckets:        toCumulativeBuckets(h.buckets),
+		count:          h.count,
+		sum:            h.sum,
+		min:            h.min,
+		max:            h.max,
+	}
+}
+
 type FHistogramStorage struct {
-	weights        []float64 // Sorted weights (greater or equal), last is inf
+	buckets        histogramBuckets // last bucket stores endVal overflows count
+	weights        []float64        // Sorted weights (greater or equal), last is inf
 	weightsAliases []string
 	labels         []string
 	total          string
-	buckets        []uint64 // last bucket stores endVal overflows count
-	lock           sync.RWMutex
+	lock           sync.RWMutex // labels lock, also serialize buckets readers
 }
 
 func (h *FHistogramStorage) Labels() []string {
@@ -267,31 +410,80 @@ func (h *FHistogramStorage) Interface() HistogramInterface {
 	return h
 }
 
-func (h *FHistogramStorage) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
+func (h *FHistogramStorage) values() histogramValues {
 	h.lock.Lock()
-	copy(buckets, h.buckets)
+	v := h.buckets.values()
 	h.lock.Unlock()
-	return buckets
+	return v
+}
+
+func (h *FHistogramStorage) Values() []uint64 {
+	return h.values().buckets
+}
+
+// Count returns observations count
+func (h *FHistogramStorage) Count() uint64 {
+	return h.values().count
+}
+
+// Sum returns observations sum
+func (h *FHistogramStorage) Sum() float64 {
+	v := h.values()
+	sum, _, _ := v.float64Stats()
+	return sum
+}
+
+// Min returns minimal observation (or zero without observations)
+func (h *FHistogramStorage) Min() float64 {
+	v := h.values()
+	_, min, _ := v.float64Stats()
+	return min
+}
+
+// Max returns maximal observation (or zero without observations)
+func (h *FHistogramStorage) Max() float64 {
+	v := h.values()
+	_, _, max := v.float64Stats()
+	return max
+}
+
+func (h *FHistogramStorage) Stats() HistogramStats {
+	v := h.values()
+	sum, min, max := v.float64Stats()
+	return HistogramStats{Count: v.count, Sum: sum, Min: min, Max: max}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *FHistogramStorage) Quantile(q float64) float64 {
+	return h.Snapshot().Quantile(q)
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *FHistogramStorage) Quantiles(qs []float64) []float64 {
+	return h.Snapshot().Quantiles(qs)
 }
 
 func (h *FHistogramStorage) Snapshot() FHistogram {
+	v := h.values()
+	sum, min, max := v.float64Stats()
 	return &FHistogramSnapshot{
 		labels:         h.labels,
 		total:          h.total,
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
-		buckets:        h.buckets,
+		buckets:        v.buckets,
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *FHistogramStorage) Clear() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
 	h.lock.Lock()
-	v := h.buckets
-	h.buckets = buckets
+	v := h.buckets.clear()
 	h.lock.Unlock()
-	return v
+	return v.buckets
 }
 
 func (h *FHistogramStorage) IsSummed() bool { return false }
@@ -322,7 +514,6 @@ func NewFixedFHistogram(startVal, endVal, width float64) FHistogram {
 	weights := make([]float64, count)
 	weightsAliases := make([]string, count)
 	labels := make([]string, count)
-	buckets := make([]uint64, count)
 	ge := startVal
 
 	// maxLength := len(strconv.FormatInt(int64(endVal+width)+1, 10))
@@ -349,7 +540,7 @@ func NewFixedFHistogram(startVal, endVal, width float64) FHistogram {
 			weightsAliases: weightsAliases,
 			labels:         labels,
 			total:          ".total",
-			buckets:        buckets,
+			buckets:        newHistogramBuckets(len(weights), histogramFloat64),
 		},
 		start: startVal,
 		width: width,
@@ -368,13 +559,11 @@ func (h *FixedFHistogram) Add(v float64) {
 		} else {
 			n = int(f)
 		}
-		if n >= len(h.buckets) {
-			n = len(h.buckets) - 1
+		if n >= h.buckets.len() {
+			n = h.buckets.len() - 1
 		}
 	}
-	h.lock.Lock()
-	h.buckets[n]++
-	h.lock.Unlock()
+	h.buckets.addFloat64(n, v)
 }
 
 func (h *FixedFHistogram) SetLabels(labels []string) FHistogram {
@@ -396,12 +585,16 @@ type FUHistogram struct {
 	FHistogramStorage
 }
 
-func NewFUHistogram(weights []float64, names []string) FHistogram {
+func NewFUHistogram(weights []float64, names []string) (FHistogram, error) {
+	return newFUHistogram(weights, names, fWeightAlias)
+}
+
+func newFUHistogram(weights []float64, names []string, alias func(float64) string) (FHistogram, error) {
 	if UseNilMetrics {
-		return NilFHistogram{}
+		return NilFHistogram{}, nil
 	}
 	if !IsSortedSliceFloat64Le(weights) {
-		panic(ErrUnsortedWeights)
+		return nil, ErrUnsortedWeights
 	}
 	w := make([]float64, len(weights)+1)
 	weightsAliases := make([]string, len(w))
@@ -420,7 +613,7 @@ func NewFUHistogram(weights []float64, names []string) FHistogram {
 			}
 			w[i] = math.MaxFloat64
 		} else {
-			weightsAliases[i] = strings.ReplaceAll(trimFloatZero(strconv.FormatFloat(w[i], 'f', 2, 64)), ".", "_")
+			weightsAliases[i] = alias(w[i])
 			if i >= len(names) || names[i] == "" {
 				// ns[i] = fmt.Sprintf(fmtStr, prefix, w[i])
 				lbls[i] = "." + weightsAliases[i]
@@ -436,34 +629,29 @@ func NewFUHistogram(weights []float64, names []string) FHistogram {
 			weightsAliases: weightsAliases,
 			labels:         lbls,
 			total:          ".total",
-			buckets:        make([]uint64, len(w)),
+			buckets:        newHistogramBuckets(len(w), histogramFloat64),
 		},
-	}
-}
-
-func (h *FUHistogram) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	copy(buckets, h.buckets)
-	h.lock.Unlock()
-	return buckets
+	}, nil
 }
 
 func (h *FUHistogram) Snapshot() FHistogram {
+	v := h.values()
+	sum, min, max := v.float64Stats()
 	return &FHistogramSnapshot{
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
 		labels:         h.labels,
 		total:          h.NameTotal(),
-		buckets:        h.Values(),
+		buckets:        v.buckets,
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *FUHistogram) Add(v float64) {
-	n := SearchFloat64Le(h.weights, v)
-	h.lock.Lock()
-	h.buckets[n]++
-	h.lock.Unlock()
+	h.buckets.addFloat64(SearchFloat64Le(h.weights, v), v)
 }
 
 func (h *FUHistogram) SetLabels(labels []string) FHistogram {
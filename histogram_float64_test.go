@@ -231,7 +231,10 @@ func TestNewFUHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			got := NewFUHistogram(tt.weights, tt.labels)
+			got, err := NewFUHistogram(tt.weights, tt.labels)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if tt.labelPrefix != "" {
 				got.AddLabelPrefix(tt.labelPrefix)
 			}
@@ -262,7 +265,10 @@ func TestNewFUHistogram(t *testing.T) {
 
 func TestFUHistogram_Add(t *testing.T) {
 	r := NewRegistry()
-	h := GetOrRegisterFUHistogram("histogram", r, []float64{1, 2, 5, 8, 20}, nil)
+	h, err := GetOrRegisterFUHistogram("histogram", r, []float64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	tests := []struct {
 		add  float64
 		want []uint64
@@ -299,7 +305,10 @@ func TestFUHistogram_Add(t *testing.T) {
 }
 
 func TestFUHistogram_SetNames(t *testing.T) {
-	h := NewFUHistogram([]float64{10, 20.1, 50.34, 80.785, 100}, nil)
+	h, err := NewFUHistogram([]float64{10, 20.1, 50.34, 80.785, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	wantLabels := []string{".10", ".20_10", ".50_34", ".80_78", ".100", ".inf"}
 	weightsAliases := []string{"10", "20_10", "50_34", "80_78", "100", "inf"}
@@ -343,7 +352,10 @@ func TestFUHistogram_SetNames(t *testing.T) {
 }
 
 func TestFUHistogram_Snapshot(t *testing.T) {
-	h := NewFUHistogram([]float64{10, 20, 50, 80, 100}, nil)
+	h, err := NewFUHistogram([]float64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.Add(19)
 	got := h.Snapshot()
 	want := []uint64{0, 1, 0, 0, 0, 0}
@@ -373,7 +385,10 @@ func BenchmarkFixedFHistogram(b *testing.B) {
 }
 
 func BenchmarkFUHistogram05(b *testing.B) {
-	h := NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	h, err := NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -381,9 +396,12 @@ func BenchmarkFUHistogram05(b *testing.B) {
 }
 
 func BenchmarkFUHistogram20(b *testing.B) {
-	h := NewFUHistogram(
+	h, err := NewFUHistogram(
 		[]float64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -391,14 +409,17 @@ func BenchmarkFUHistogram20(b *testing.B) {
 }
 
 func BenchmarkFUHistogram100(b *testing.B) {
-	h := NewFUHistogram(
+	h, err := NewFUHistogram(
 		[]float64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -415,7 +436,10 @@ func BenchmarkFixedFHistogram_Values(b *testing.B) {
 }
 
 func BenchmarkFUHistogram05_Values(b *testing.B) {
-	h := NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	h, err := NewFUHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -424,9 +448,12 @@ func BenchmarkFUHistogram05_Values(b *testing.B) {
 }
 
 func BenchmarkFUHistogram20_Values(b *testing.B) {
-	h := NewFUHistogram(
+	h, err := NewFUHistogram(
 		[]float64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -435,14 +462,17 @@ func BenchmarkFUHistogram20_Values(b *testing.B) {
 }
 
 func BenchmarkFUHistogram100_Values(b *testing.B) {
-	h := NewFUHistogram(
+	h, err := NewFUHistogram(
 		[]float64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
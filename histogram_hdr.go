@@ -0,0 +1,571 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+var (
+	ErrHDRSignificantFigures = errors.New("significant figures must be in range [1, 5]")
+	ErrHDRTrackableRange     = errors.New("lowest trackable value must be >= 1 and highest trackable value must be >= 2 * lowest")
+	ErrHDRSnapshotEncoding   = errors.New("invalid HDR histogram snapshot encoding")
+	ErrHDRSnapshotSize       = errors.New("HDR histogram layout is too large for snapshot encoding")
+)
+
+const (
+	hdrEncodingVersion = 1
+	// hdrMaxSnapshotCountsLen is a maximum counts length of encoded snapshots (for bounded allocations on decoding),
+	// layouts with up to 4 significant figures are always in limit.
+	hdrMaxSnapshotCountsLen = 1 << 20
+)
+
+// A HDRHistogram is a High Dynamic Range histogram, records values in range [lowest, highest]
+// with bounded relative error (configured as significant figures of value), without manual buckets weights.
+// Negative values are clamped to zero and values greater than highest are clamped to highest,
+// values below lowest are recorded with lowest resolution (in one counts bucket with zero).
+type HDRHistogram interface {
+	Add(v int64)
+	Clear()
+	Snapshot() HDRHistogram
+	// Merge adds observations from other histogram (with possibly different layout)
+	Merge(HDRHistogram)
+	// Observations count
+	Count() uint64
+	// Observations sum
+	Sum() int64
+	// Minimal observation (or zero without observations)
+	Min() int64
+	// Maximal observation (or zero without observations)
+	Max() int64
+	// Arithmetic mean of observations (or zero without observations)
+	Mean() float64
+	// Observations summary (count, sum, min, max)
+	Stats() HistogramStats
+	// Percentile returns a value (with configured precision) at q (0 <= q <= 1) quantile (or zero without observations)
+	Percentile(q float64) int64
+	// Percentiles returns a values (with configured precision) at quantiles (0 <= q <= 1), calculated from one consistent read
+	Percentiles(qs []float64) []int64
+	LowestTrackableValue() int64
+	HighestTrackableValue() int64
+	SignificantFigures() int
+	// MarshalBinary encodes histogram snapshot (decode with HDRHistogramSnapshot.UnmarshalBinary)
+	MarshalBinary() ([]byte, error)
+}
+
+// GetOrRegisterHDRHistogram returns an existing HDRHistogram or constructs and registers
+// a new StandardHDRHistogram.
+func GetOrRegisterHDRHistogram(name string, r Registry, lowest, highest int64, sigfigs int) (HDRHistogram, error) {
+	if _, err := newHDRLayout(lowest, highest, sigfigs); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		h, _ := NewHDRHistogram(lowest, highest, sigfigs)
+		return h
+	}).(HDRHistogram), nil
+}
+
+// GetOrRegisterHDRHistogramT returns an existing HDRHistogram or constructs and registers
+// a new StandardHDRHistogram.
+func GetOrRegisterHDRHistogramT(name string, tagsMap map[string]string, r Registry, lowest, highest int64, sigfigs int) (HDRHistogram, error) {
+	if _, err := newHDRLayout(lowest, highest, sigfigs); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		h, _ := NewHDRHistogram(lowest, highest, sigfigs)
+		return h
+	}).(HDRHistogram), nil
+}
+
+// NewRegisteredHDRHistogram constructs and registers a new StandardHDRHistogram.
+func NewRegisteredHDRHistogram(name string, r Registry, lowest, highest int64, sigfigs int) (HDRHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewHDRHistogram(lowest, highest, sigfigs)
+	if err != nil {
+		return nil, err
+	}
+	r.Register(name, h)
+	return h, nil
+}
+
+// NewRegisteredHDRHistogramT constructs and registers a new StandardHDRHistogram.
+func NewRegisteredHDRHistogramT(name string, tagsMap map[string]string, r Registry, lowest, highest int64, sigfigs int) (HDRHistogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewHDRHistogram(lowest, highest, sigfigs)
+	if err != nil {
+		return nil, err
+	}
+	r.RegisterT(name, tagsMap, h)
+	return h, nil
+}
+
+// NewHDRHistogram constructs a new StandardHDRHistogram, which tracks values in range [lowest, highest]
+// with sigfigs (1-5) significant figures precision. For example, 1µs to 1min latency in nanoseconds with 0.1% error:
+//
+//	NewHDRHistogram(1000, 60*1000*1000*1000, 3)
+//
+// Returns error for invalid layout (ErrHDRSignificantFigures or invalid range).
+func NewHDRHistogram(lowest, highest int64, sigfigs int) (HDRHistogram, error) {
+	layout, err := newHDRLayout(lowest, highest, sigfigs)
+	if err != nil {
+		return nil, err
+	}
+	if UseNilMetrics {
+		return NilHDRHistogram{}, nil
+	}
+	return &StandardHDRHistogram{
+		buckets:   newHistogramBuckets(layout.countsLen, histogramInt64),
+		hdrLayout: layout,
+	}, nil
+}
+
+// hdrLayout is a HDR histogram counts layout: 2^n buckets, each splitted to sub-buckets with equal width.
+type hdrLayout struct {
+	lowest  int64
+	highest int64
+	sigfigs int
+
+	unitMagnitude               uint
+	subBucketHalfCountMagnitude uint
+	subBucketCount              int
+	subBucketHalfCount          int
+	subBucketMask               int64
+	countsLen                   int
+}
+
+func newHDRLayout(lowest, highest int64, sigfigs int) (hdrLayout, error) {
+	if sigfigs < 1 || sigfigs > 5 {
+		return hdrLayout{}, ErrHDRSignificantFigures
+	}
+	if lowest < 1 || highest < 2*lowest {
+		return hdrLayout{}, ErrHDRTrackableRange
+	}
+	l := hdrLayout{
+		lowest:        lowest,
+		highest:       highest,
+		sigfigs:       sigfigs,
+		unitMagnitude: uint(bits.Len64(uint64(lowest)) - 1),
+	}
+
+	largestValueWithSingleUnitResolution := 2 * int64(math.Pow10(sigfigs))
+	subBucketCountMagnitude := uint(bits.Len64(uint64(largestValueWithSingleUnitResolution - 1)))
+	l.subBucketHalfCountMagnitude = subBucketCountMagnitude - 1
+	l.subBucketCount = 1 << subBucketCountMagnitude
+	l.subBucketHalfCount = l.subBucketCount / 2
+	l.subBucketMask = int64(l.subBucketCount-1) << l.unitMagnitude
+
+	// buckets needed to cover highest value
+	smallestUntrackable := int64(l.subBucketCount) << l.unitMagnitude
+	bucketCount := 1
+	for smallestUntrackable <= highest {
+		if smallestUntrackable > math.MaxInt64/2 {
+			bucketCount++
+			break
+		}
+		smallestUntrackable <<= 1
+		bucketCount++
+	}
+	l.countsLen = (bucketCount + 1) * l.subBucketHalfCount
+
+	return l, nil
+}
+
+func (l *hdrLayout) bucketIndex(v int64) int {
+	pow2Ceiling := bits.Len64(uint64(v | l.subBucketMask))
+	return pow2Ceiling - int(l.unitMagnitude) - int(l.subBucketHalfCountMagnitude+1)
+}
+
+func (l *hdrLayout) subBucketIndex(v int64, bucketIdx int) int {
+	return int(v >> (uint(bucketIdx) + l.unitMagnitude))
+}
+
+// countsIndex returns counts index for value
+func (l *hdrLayout) countsIndex(v int64) int {
+	bucketIdx := l.bucketIndex(v)
+	subBucketIdx := l.subBucketIndex(v, bucketIdx)
+	return ((bucketIdx + 1) << l.subBucketHalfCountMagnitude) + subBucketIdx - l.subBucketHalfCount
+}
+
+// value returns lowest equivalent value for counts index
+func (l *hdrLayout) value(idx int) int64 {
+	bucketIdx := (idx >> l.subBucketHalfCountMagnitude) - 1
+	subBucketIdx := (idx & (l.subBucketHalfCount - 1)) + l.subBucketHalfCount
+	if bucketIdx < 0 {
+		subBucketIdx -= l.subBucketHalfCount
+		bucketIdx = 0
+	}
+	return int64(subBucketIdx) << (uint(bucketIdx) + l.unitMagnitude)
+}
+
+// highestEquivalentValue returns highest value, counted with the same counts index as v
+func (l *hdrLayout) highestEquivalentValue(v int64) int64 {
+	bucketIdx := l.bucketIndex(v)
+	subBucketIdx := l.subBucketIndex(v, bucketIdx)
+	lowest := int64(subBucketIdx) << (uint(bucketIdx) + l.unitMagnitude)
+	if subBucketIdx >= l.subBucketCount {
+		bucketIdx++
+	}
+	return lowest + int64(1)<<(l.unitMagnitude+uint(bucketIdx)) - 1
+}
+
+// clamp returns v in range [0, highest]
+func (l *hdrLayout) clamp(v int64) int64 {
+	if v < 0 {
+		return 0
+	}
+	if v > l.highest {
+		return l.highest
+	}
+	return v
+}
+
+func (l *hdrLayout) LowestTrackableValue() int64 {
+	return l.lowest
+}
+
+func (l *hdrLayout) HighestTrackableValue() int64 {
+	return l.highest
+}
+
+func (l *hdrLayout) SignificantFigures() int {
+	return l.sigfigs
+}
+
+// NilHDRHistogram is a no-op HDRHistogram.
+type NilHDRHistogram struct{}
+
+func (NilHDRHistogram) Add(v int64) {}
+
+func (NilHDRHistogram) Clear() {}
+
+func (NilHDRHistogram) Snapshot() HDRHistogram { return NilHDRHistogram{} }
+
+func (NilHDRHistogram) Merge(HDRHistogram) {}
+
+func (NilHDRHistogram) Count() uint64 { return 0 }
+
+func (NilHDRHistogram) Sum() int64 { return 0 }
+
+func (NilHDRHistogram) Min() int64 { return 0 }
+
+func (NilHDRHistogram) Max() int64 { return 0 }
+
+func (NilHDRHistogram) Mean() float64 { return 0 }
+
+func (NilHDRHistogram) Stats() HistogramStats { return HistogramStats{} }
+
+func (NilHDRHistogram) Percentile(float64) int64 { return 0 }
+
+func (NilHDRHistogram) Percentiles(qs []float64) []int64 { return make([]int64, len(qs)) }
+
+func (NilHDRHistogram) LowestTrackableValue() int64 { return 0 }
+
+func (NilHDRHistogram) HighestTrackableValue() int64 { return 0 }
+
+func (NilHDRHistogram) SignificantFigures() int { return 0 }
+
+func (NilHDRHistogram) MarshalBinary() ([]byte, error) { return nil, nil }
+
+// HDRHistogramSnapshot is a read-only copy of HDRHistogram.
+type HDRHistogramSnapshot struct {
+	hdrLayout
+	counts []uint64 // can be shorter than countsLen (without trailing zero counts)
+	count  uint64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func (*HDRHistogramSnapshot) Add(int64) {
+	panic("Add called on a HDRHistogramSnapshot")
+}
+
+func (*HDRHistogramSnapshot) Clear() {
+	panic("Clear called on a HDRHistogramSnapshot")
+}
+
+func (*HDRHistogramSnapshot) Merge(HDRHistogram) {
+	panic("Merge called on a HDRHistogramSnapshot")
+}
+
+func (h *HDRHistogramSnapshot) Snapshot() HDRHistogram {
+	return h
+}
+
+func (h *HDRHistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *HDRHistogramSnapshot) Sum() int64 {
+	return h.sum
+}
+
+func (h *HDRHistogramSnapshot) Min() int64 {
+	return h.min
+}
+
+func (h *HDRHistogramSnapshot) Max() int64 {
+	return h.max
+}
+
+func (h *HDRHistogramSnapshot) Mean() float64 {
+	return h.Stats().Mean()
+}
+
+func (h *HDRHistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: float64(h.sum), Min: float64(h.min), Max: float64(h.max)}
+}
+
+func (h *HDRHistogramSnapshot) Percentile(q float64) int64 {
+	if h.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.min
+	}
+	if q >= 1 {
+		return h.max
+	}
+	rank := uint64(q*float64(h.count) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	var cum uint64
+	for i, c := range h.counts {
+		cum += c
+		if cum >= rank {
+			v := h.highestEquivalentValue(h.value(i))
+			if v > h.max {
+				return h.max
+			}
+			if v < h.min {
+				return h.min
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+func (h *HDRHistogramSnapshot) Percentiles(qs []float64) []int64 {
+	ps := make([]int64, len(qs))
+	for i, q := range qs {
+		ps[i] = h.Percentile(q)
+	}
+	return ps
+}
+
+// MarshalBinary encodes snapshot: version, layout, stats and counts (zero counts runs encoded as negative values).
+// Returns ErrHDRSnapshotSize for layouts with more than 2^20 counts (like 5 significant figures over wide range).
+func (h *HDRHistogramSnapshot) MarshalBinary() ([]byte, error) {
+	if h.countsLen > hdrMaxSnapshotCountsLen {
+		return nil, ErrHDRSnapshotSize
+	}
+	w := binaryWriter{data: make([]byte, 0, 64)}
+
+	w.uvarint(hdrEncodingVersion)
+	w.varint(h.lowest)
+	w.varint(h.highest)
+	w.uvarint(uint64(h.sigfigs))
+	w.uvarint(h.count)
+	w.varint(h.sum)
+	w.varint(h.min)
+	w.varint(h.max)
+
+	// trailing zero counts are not encoded
+	last := len(h.counts) - 1
+	for last >= 0 && h.counts[last] == 0 {
+		last--
+	}
+	var zeros int64
+	for i := 0; i <= last; i++ {
+		if c := h.counts[i]; c == 0 {
+			zeros++
+		} else {
+			if zeros > 0 {
+				w.varint(-zeros)
+				zeros = 0
+			}
+			w.varint(int64(c))
+		}
+	}
+
+	return w.data, nil
+}
+
+// UnmarshalBinary decodes snapshot, encoded with MarshalBinary.
+// Counts are allocated up to the last non-zero count, trailing zero counts are not stored.
+func (h *HDRHistogramSnapshot) UnmarshalBinary(data []byte) error {
+	r := binaryReader{data: data, errInvalid: ErrHDRSnapshotEncoding}
+
+	if r.uvarint() != hdrEncodingVersion || r.err != nil {
+		return ErrHDRSnapshotEncoding
+	}
+	lowest := r.varint()
+	highest := r.varint()
+	sigfigs := r.uvarint()
+	count := r.uvarint()
+	sum := r.varint()
+	min := r.varint()
+	max := r.varint()
+	if r.err != nil {
+		return r.err
+	}
+	if sigfigs > 5 {
+		return ErrHDRSignificantFigures
+	}
+	layout, err := newHDRLayout(lowest, highest, int(sigfigs))
+	if err != nil {
+		return err
+	}
+	if layout.countsLen > hdrMaxSnapshotCountsLen {
+		return ErrHDRSnapshotSize
+	}
+
+	var (
+		counts []uint64
+		total  uint64
+	)
+	for i := 0; len(r.data) > 0; {
+		v := r.varint()
+		if r.err != nil {
+			return r.err
+		}
+		if v < 0 {
+			// zeroes run length, -(v+1) can't overflow (for math.MinInt64 too)
+			run := uint64(-(v + 1)) + 1
+			if run > uint64(layout.countsLen-i) {
+				return ErrHDRSnapshotEncoding
+			}
+			i += int(run)
+		} else {
+			if i >= layout.countsLen {
+				return ErrHDRSnapshotEncoding
+			}
+			if i >= len(counts) {
+				counts = append(counts, make([]uint64, i+1-len(counts))...)
+			}
+			counts[i] = uint64(v)
+			total += uint64(v)
+			i++
+		}
+	}
+	if total != count {
+		return ErrHDRSnapshotEncoding
+	}
+
+	*h = HDRHistogramSnapshot{
+		hdrLayout: layout,
+		counts:    counts,
+		count:     count,
+		sum:       sum,
+		min:       min,
+		max:       max,
+	}
+	return nil
+}
+
+// StandardHDRHistogram is the standard implementation of HDRHistogram with lock-free recording.
+type StandardHDRHistogram struct {
+	buckets histogramBuckets
+	hdrLayout
+	lock sync.Mutex // serialize buckets readers
+}
+
+func (h *StandardHDRHistogram) Add(v int64) {
+	v = h.clamp(v)
+	h.buckets.addInt64(h.countsIndex(v), v)
+}
+
+func (h *StandardHDRHistogram) Clear() {
+	h.lock.Lock()
+	h.buckets.clear()
+	h.lock.Unlock()
+}
+
+func (h *StandardHDRHistogram) Snapshot() HDRHistogram {
+	h.lock.Lock()
+	v := h.buckets.values()
+	h.lock.Unlock()
+	sum, min, max := v.int64Stats()
+	return &HDRHistogramSnapshot{
+		hdrLayout: h.hdrLayout,
+		counts:    v.buckets,
+		count:     v.count,
+		sum:       sum,
+		min:       min,
+		max:       max,
+	}
+}
+
+func (h *StandardHDRHistogram) Merge(other HDRHistogram) {
+	s, ok := other.Snapshot().(*HDRHistogramSnapshot)
+	if !ok || s.count == 0 {
+		return
+	}
+	v := histogramValues{
+		count: s.count,
+		sum:   uint64(s.sum),
+		min:   uint64(h.clamp(s.min)),
+		max:   uint64(h.clamp(s.max)),
+	}
+	if s.hdrLayout == h.hdrLayout {
+		v.buckets = s.counts
+	} else {
+		// re-record counts with equivalent values
+		v.buckets = make([]uint64, h.countsLen)
+		for i, c := range s.counts {
+			if c > 0 {
+				v.buckets[h.countsIndex(h.clamp(s.value(i)))] += c
+			}
+		}
+	}
+	h.buckets.merge(&v)
+}
+
+func (h *StandardHDRHistogram) Count() uint64 {
+	return h.Snapshot().Count()
+}
+
+func (h *StandardHDRHistogram) Sum() int64 {
+	return h.Snapshot().Sum()
+}
+
+func (h *StandardHDRHistogram) Min() int64 {
+	return h.Snapshot().Min()
+}
+
+func (h *StandardHDRHistogram) Max() int64 {
+	return h.Snapshot().Max()
+}
+
+func (h *StandardHDRHistogram) Mean() float64 {
+	return h.Snapshot().Mean()
+}
+
+func (h *StandardHDRHistogram) Stats() HistogramStats {
+	return h.Snapshot().Stats()
+}
+
+func (h *StandardHDRHistogram) Percentile(q float64) int64 {
+	return h.Snapshot().Percentile(q)
+}
+
+func (h *StandardHDRHistogram) Percentiles(qs []float64) []int64 {
+	return h.Snapshot().Percentiles(qs)
+}
+
+func (h *StandardHDRHistogram) MarshalBinary() ([]byte, error) {
+	return h.Snapshot().MarshalBinary()
+}
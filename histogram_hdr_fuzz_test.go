@@ -0,0 +1,29 @@
+//go:build go1.18
+// +build go1.18
+
+package metrics
+
+import "testing"
+
+func FuzzHDRHistogramSnapshot_UnmarshalBinary(f *testing.F) {
+	h := newTestHDRHistogram(f, 1, 1000000, 2)
+	for i := int64(1); i <= 100; i++ {
+		h.Add(i * i * 100)
+	}
+	data, _ := h.MarshalBinary()
+	f.Add(data)
+	empty, _ := newTestHDRHistogram(f, 1, 1000, 1).MarshalBinary()
+	f.Add(empty)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var s HDRHistogramSnapshot
+		if err := s.UnmarshalBinary(data); err != nil {
+			return
+		}
+		// decoded snapshot must be usable and encoded back
+		s.Percentiles([]float64{0, 0.5, 1})
+		if _, err := s.MarshalBinary(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestNewHDRHistogram_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		lowest  int64
+		highest int64
+		sigfigs int
+		want    error
+	}{
+		{name: "sigfigs 0", lowest: 1, highest: 1000, sigfigs: 0, want: ErrHDRSignificantFigures},
+		{name: "sigfigs 6", lowest: 1, highest: 1000, sigfigs: 6, want: ErrHDRSignificantFigures},
+		{name: "lowest 0", lowest: 0, highest: 1000, sigfigs: 3, want: ErrHDRTrackableRange},
+		{name: "highest < 2 * lowest", lowest: 10, highest: 19, sigfigs: 3, want: ErrHDRTrackableRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if h, err := NewHDRHistogram(tt.lowest, tt.highest, tt.sigfigs); err != tt.want || h != nil {
+				t.Errorf("NewHDRHistogram() = (%v, %v), want (nil, %v)", h, err, tt.want)
+			}
+			if _, err := GetOrRegisterHDRHistogram("hdr", NewRegistry(), tt.lowest, tt.highest, tt.sigfigs); err != tt.want {
+				t.Errorf("GetOrRegisterHDRHistogram() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHDRHistogram_RelativeError(t *testing.T) {
+	tests := []struct {
+		lowest  int64
+		highest int64
+		sigfigs int
+	}{
+		{lowest: 1, highest: 3600 * 1000 * 1000, sigfigs: 3},
+		{lowest: 1000, highest: 60 * 1000 * 1000 * 1000, sigfigs: 2},
+		{lowest: 1, highest: math.MaxInt64, sigfigs: 5},
+		{lowest: 7, highest: 1000, sigfigs: 1},
+	}
+	for _, tt := range tests {
+		h := newTestHDRHistogram(t, tt.lowest, tt.highest, tt.sigfigs).(*StandardHDRHistogram)
+		maxErr := math.Pow10(-tt.sigfigs)
+		for v := tt.lowest; v > 0 && v <= tt.highest; v = v*3 + 1 {
+			idx := h.countsIndex(v)
+			if idx < 0 || idx >= h.countsLen {
+				t.Fatalf("HDRHistogram(%d, %d, %d) countsIndex(%d) = %d, out of range [0, %d)",
+					tt.lowest, tt.highest, tt.sigfigs, v, idx, h.countsLen)
+			}
+			lo := h.value(idx)
+			hi := h.highestEquivalentValue(v)
+			if lo > v || hi < v {
+				t.Errorf("HDRHistogram(%d, %d, %d) value %d not in equivalent range [%d, %d]",
+					tt.lowest, tt.highest, tt.sigfigs, v, lo, hi)
+			}
+			if v >= tt.lowest*int64(math.Pow10(tt.sigfigs)) && float64(hi-lo)/float64(v) > maxErr {
+				t.Errorf("HDRHistogram(%d, %d, %d) value %d equivalent range [%d, %d] exceed relative error %f",
+					tt.lowest, tt.highest, tt.sigfigs, v, lo, hi, maxErr)
+			}
+		}
+	}
+}
+
+func TestHDRHistogram(t *testing.T) {
+	h := newTestHDRHistogram(t, 1, 3600*1000*1000, 3)
+	if got := h.Percentiles([]float64{0.5, 0.99}); !reflect.DeepEqual(got, []int64{0, 0}) {
+		t.Errorf("Percentiles() without observations = %v, want zeroes", got)
+	}
+	for i := int64(1); i <= 10000; i++ {
+		h.Add(i * 1000)
+	}
+	h.Add(-1) // clamped to 0
+	s := h.Snapshot()
+	if s.Count() != 10001 || s.Min() != 0 || s.Max() != 10000000 {
+		t.Errorf("Snapshot() count = %d, min = %d, max = %d, want 10001, 0, 10000000", s.Count(), s.Min(), s.Max())
+	}
+	if mean := s.Mean(); mean != 5000000 {
+		t.Errorf("Snapshot().Mean() = %f, want 5000000", mean)
+	}
+	qs := []float64{0, 0.5, 0.9, 0.99, 0.999, 1}
+	want := []int64{0, 5000000, 9000000, 9900000, 9990000, 10000000}
+	for i, got := range s.Percentiles(qs) {
+		if math.Abs(float64(got-want[i])) > float64(want[i])/1000 {
+			t.Errorf("Percentile(%f) = %d, want %d with 0.1%% error", qs[i], got, want[i])
+		}
+	}
+
+	h.Add(math.MaxInt64) // clamped to highest
+	if h.Max() != 3600*1000*1000 {
+		t.Errorf("Max() = %d, want %d", h.Max(), 3600*1000*1000)
+	}
+
+	h.Clear()
+	if s := h.Snapshot(); s.Count() != 0 || s.Max() != 0 || s.Percentile(0.5) != 0 {
+		t.Errorf("Snapshot() after Clear() count = %d, max = %d, want zeroes", s.Count(), s.Max())
+	}
+}
+
+func TestHDRHistogram_Merge(t *testing.T) {
+	a := newTestHDRHistogram(t, 1, 1000000, 3)
+	b := newTestHDRHistogram(t, 1, 1000000, 3)
+	c := newTestHDRHistogram(t, 1000, 100000000, 2)
+	for i := int64(1); i <= 100; i++ {
+		a.Add(i * 100)
+		b.Add(i * 10000)
+		c.Add(i * 10000)
+	}
+
+	a.Merge(b)
+	a.Merge(NilHDRHistogram{})
+	if a.Count() != 200 || a.Min() != 100 || a.Max() != 1000000 || a.Sum() != 505000+50500000 {
+		t.Errorf("Merge() count = %d, min = %d, max = %d, sum = %d", a.Count(), a.Min(), a.Max(), a.Sum())
+	}
+	if p := a.Percentile(0.75); math.Abs(float64(p-500000)) > 500 {
+		t.Errorf("Merge() Percentile(0.75) = %d, want 500000", p)
+	}
+
+	// different layout
+	d := newTestHDRHistogram(t, 1, 1000000, 3)
+	d.Merge(c)
+	if d.Count() != 100 || d.Max() != 1000000 {
+		t.Errorf("Merge() with different layout count = %d, max = %d, want 100, 1000000", d.Count(), d.Max())
+	}
+	if p := d.Percentile(0.5); math.Abs(float64(p-500000)) > 5000 {
+		t.Errorf("Merge() with different layout Percentile(0.5) = %d, want 500000 with 1%% error", p)
+	}
+}
+
+func TestHDRHistogram_MarshalBinary(t *testing.T) {
+	h := newTestHDRHistogram(t, 1000, 60*1000*1000*1000, 3)
+	for i := int64(1); i <= 1000; i++ {
+		h.Add(i * i * 1000)
+	}
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s HDRHistogramSnapshot
+	if err = s.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	qs := []float64{0, 0.5, 0.9, 0.99, 1}
+	if s.Stats() != h.Stats() || !reflect.DeepEqual(s.Percentiles(qs), h.Percentiles(qs)) {
+		t.Errorf("UnmarshalBinary() = %+v, want %+v", s.Stats(), h.Stats())
+	}
+	// trailing zero counts are not allocated
+	if len(s.counts) >= s.countsLen {
+		t.Errorf("UnmarshalBinary() counts length = %d, want less than %d", len(s.counts), s.countsLen)
+	}
+	if got, _ := s.MarshalBinary(); !reflect.DeepEqual(got, data) {
+		t.Errorf("MarshalBinary() of decoded snapshot differs")
+	}
+	m := newTestHDRHistogram(t, 1000, 60*1000*1000*1000, 3)
+	m.Merge(&s)
+	if m.Stats() != h.Stats() || !reflect.DeepEqual(m.Percentiles(qs), h.Percentiles(qs)) {
+		t.Errorf("Merge(decoded) = %+v, want %+v", m.Stats(), h.Stats())
+	}
+
+	for _, bad := range [][]byte{nil, {2}, data[:len(data)-1], append(append([]byte{}, data...), 2)} {
+		if err = s.UnmarshalBinary(bad); err != ErrHDRSnapshotEncoding {
+			t.Errorf("UnmarshalBinary(%v) error = %v, want %v", bad, err, ErrHDRSnapshotEncoding)
+		}
+	}
+
+	empty, _ := newTestHDRHistogram(t, 1, 1000, 1).MarshalBinary()
+	if err = s.UnmarshalBinary(empty); err != nil || s.Count() != 0 {
+		t.Errorf("UnmarshalBinary(empty) count = %d, error = %v", s.Count(), err)
+	}
+}
+
+func TestHDRHistogramSnapshot_UnmarshalBinaryCorrupt(t *testing.T) {
+	// header for 1..1000 with 1 significant figure, counts are appended to it
+	header := func(count uint64) binaryWriter {
+		var w binaryWriter
+		w.uvarint(hdrEncodingVersion)
+		w.varint(1)
+		w.varint(1000)
+		w.uvarint(1)
+		w.uvarint(count)
+		w.varint(0)
+		w.varint(0)
+		w.varint(0)
+		return w
+	}
+	countsLen := newTestHDRHistogram(t, 1, 1000, 1).(*StandardHDRHistogram).countsLen
+
+	tests := []struct {
+		name   string
+		count  uint64
+		counts []int64
+		want   error
+	}{
+		{name: "zeroes run MinInt64", count: 1, counts: []int64{math.MinInt64, 1}, want: ErrHDRSnapshotEncoding},
+		{name: "zeroes run MinInt64 + 1", count: 1, counts: []int64{math.MinInt64 + 1, 1}, want: ErrHDRSnapshotEncoding},
+		{name: "zeroes run overflow", count: 1, counts: []int64{-int64(countsLen), 1}, want: ErrHDRSnapshotEncoding},
+		{name: "zeroes run to end", count: 1, counts: []int64{1, -int64(countsLen - 1)}},
+		{name: "counts overflow", count: 0, counts: make([]int64, countsLen+1), want: ErrHDRSnapshotEncoding},
+		{name: "count mismatch", count: 2, counts: []int64{1}, want: ErrHDRSnapshotEncoding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := header(tt.count)
+			for _, c := range tt.counts {
+				w.varint(c)
+			}
+			var s HDRHistogramSnapshot
+			if err := s.UnmarshalBinary(w.data); err != tt.want {
+				t.Errorf("UnmarshalBinary() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// layout too large for decoding (allocated before counts decoding)
+	var w binaryWriter
+	w.uvarint(hdrEncodingVersion)
+	w.varint(1)
+	w.varint(math.MaxInt64)
+	w.uvarint(5)
+	w.uvarint(0)
+	w.varint(0)
+	w.varint(0)
+	w.varint(0)
+	var s HDRHistogramSnapshot
+	if err := s.UnmarshalBinary(w.data); err != ErrHDRSnapshotSize {
+		t.Errorf("UnmarshalBinary() with large layout error = %v, want %v", err, ErrHDRSnapshotSize)
+	}
+	if _, err := newTestHDRHistogram(t, 1, math.MaxInt64, 5).MarshalBinary(); err != ErrHDRSnapshotSize {
+		t.Errorf("MarshalBinary() with large layout error = %v, want %v", err, ErrHDRSnapshotSize)
+	}
+
+	// invalid layout
+	for _, layout := range [][3]int64{{0, 1000, 1}, {1, 1000, 6}, {10, 19, 3}} {
+		var w binaryWriter
+		w.uvarint(hdrEncodingVersion)
+		w.varint(layout[0])
+		w.varint(layout[1])
+		w.uvarint(uint64(layout[2]))
+		w.uvarint(0)
+		w.varint(0)
+		w.varint(0)
+		w.varint(0)
+		var s HDRHistogramSnapshot
+		if err := s.UnmarshalBinary(w.data); err == nil {
+			t.Errorf("UnmarshalBinary(%v) with invalid layout succeeded", layout)
+		}
+	}
+}
+
+func TestHDRHistogram_Concurrent(t *testing.T) {
+	h := newTestHDRHistogram(t, 1, 1000000, 3)
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int64(0); i < 1000; i++ {
+				h.Add(i)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		h.Percentile(0.5)
+	}
+	wg.Wait()
+	if h.Count() != 8000 || h.Sum() != 8*999*500 {
+		t.Errorf("Count() = %d, Sum() = %d, want 8000, %d", h.Count(), h.Sum(), 8*999*500)
+	}
+}
+
+func BenchmarkHDRHistogram(b *testing.B) {
+	h := newTestHDRHistogram(b, 1000, 60*1000*1000*1000, 3)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Add(int64(i) * 1000)
+	}
+}
+
+func BenchmarkHDRHistogramParallel(b *testing.B) {
+	h := newTestHDRHistogram(b, 1000, 60*1000*1000*1000, 3)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int64
+		for pb.Next() {
+			h.Add(i * 1000)
+			i++
+		}
+	})
+}
+
+func newTestHDRHistogram(tb testing.TB, lowest, highest int64, sigfigs int) HDRHistogram {
+	h, err := NewHDRHistogram(lowest, highest, sigfigs)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return h
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import "errors"
+
+var (
+	ErrHistogramLayout    = errors.New("histogram buckets layout mismatch")
+	ErrHistogramDecreased = errors.New("histogram counts less than at subtracted snapshot (histogram cleared ?)")
+)
+
+func equalInt64Weights(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalUint64Weights(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalFloat64Weights(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeBuckets returns new buckets with a + b counts (per-bucket or cumulative, but the same for both)
+func mergeBuckets(a, b []uint64) []uint64 {
+	buckets := make([]uint64, len(a))
+	for i := range a {
+		buckets[i] = a[i] + b[i]
+	}
+	return buckets
+}
+
+// subBuckets returns new buckets with cur - prev counts (per-bucket or cumulative, but the same for both)
+func subBuckets(cur, prev []uint64) ([]uint64, error) {
+	buckets := make([]uint64, len(cur))
+	for i := range cur {
+		if cur[i] < prev[i] {
+			return nil, ErrHistogramDecreased
+		}
+		buckets[i] = cur[i] - prev[i]
+	}
+	return buckets, nil
+}
+
+// toCumulativeBuckets returns new cumulative buckets, source buckets are not modified
+func toCumulativeBuckets(buckets []uint64) []uint64 {
+	c := make([]uint64, len(buckets))
+	copy(c, buckets)
+	return cumulativeBuckets(c)
+}
+
+// toPerBucketBuckets returns new per-bucket buckets from cumulative ones, reverse for cumulativeBuckets
+func toPerBucketBuckets(buckets []uint64) []uint64 {
+	p := make([]uint64, len(buckets))
+	for i := range buckets {
+		if i == len(buckets)-1 {
+			p[i] = buckets[i]
+		} else {
+			p[i] = buckets[i] - buckets[i+1]
+		}
+	}
+	return p
+}
+
+func mergeInt64MinMax(count uint64, min, max int64, otherCount uint64, otherMin, otherMax int64) (int64, int64) {
+	if otherCount == 0 {
+		return min, max
+	}
+	if count == 0 {
+		return otherMin, otherMax
+	}
+	if otherMin < min {
+		min = otherMin
+	}
+	if otherMax > max {
+		max = otherMax
+	}
+	return min, max
+}
+
+func mergeUint64MinMax(count uint64, min, max uint64, otherCount uint64, otherMin, otherMax uint64) (uint64, uint64) {
+	if otherCount == 0 {
+		return min, max
+	}
+	if count == 0 {
+		return otherMin, otherMax
+	}
+	if otherMin < min {
+		min = otherMin
+	}
+	if otherMax > max {
+		max = otherMax
+	}
+	return min, max
+}
+
+func mergeFloat64MinMax(count uint64, min, max float64, otherCount uint64, otherMin, otherMax float64) (float64, float64) {
+	if otherCount == 0 {
+		return min, max
+	}
+	if count == 0 {
+		return otherMin, otherMax
+	}
+	if otherMin < min {
+		min = otherMin
+	}
+	if otherMax > max {
+		max = otherMax
+	}
+	return min, max
+}
@@ -0,0 +1,300 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistogramBucketsConvert(t *testing.T) {
+	per := []uint64{1, 0, 2, 0, 3}
+	c := toCumulativeBuckets(per)
+	if want := []uint64{6, 5, 5, 3, 3}; !reflect.DeepEqual(want, c) {
+		t.Errorf("toCumulativeBuckets() = %v, want %v", c, want)
+	}
+	if want := []uint64{1, 0, 2, 0, 3}; !reflect.DeepEqual(want, per) {
+		t.Errorf("toCumulativeBuckets() modify source buckets: %v", per)
+	}
+	if got := toPerBucketBuckets(c); !reflect.DeepEqual(per, got) {
+		t.Errorf("toPerBucketBuckets() = %v, want %v", got, per)
+	}
+}
+
+func TestHistogramSnapshot_Merge(t *testing.T) {
+	a, err := NewVHistogram([]int64{10, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewVHistogram([]int64{10, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Add(5)
+	a.Add(15)
+	b.Add(-5)
+	b.Add(30)
+	b.Add(12)
+
+	s, err := a.Snapshot().(*HistogramSnapshot).Merge(b.Snapshot().(*HistogramSnapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{2, 2, 1}; !reflect.DeepEqual(want, s.Values()) {
+		t.Errorf("Merge().Values() = %v, want %v", s.Values(), want)
+	}
+	if want := (HistogramStats{Count: 5, Sum: 57, Min: -5, Max: 30}); s.Stats() != want {
+		t.Errorf("Merge().Stats() = %+v, want %+v", s.Stats(), want)
+	}
+
+	// merge with empty snapshot must not reset min
+	empty, err := NewVHistogram([]int64{10, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := a.Snapshot().(*HistogramSnapshot).Merge(empty.Snapshot().(*HistogramSnapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (HistogramStats{Count: 2, Sum: 20, Min: 5, Max: 15}); m.Stats() != want {
+		t.Errorf("Merge(empty).Stats() = %+v, want %+v", m.Stats(), want)
+	}
+
+	other, err := NewVHistogram([]int64{10, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Merge(other.Snapshot().(*HistogramSnapshot)); err != ErrHistogramLayout {
+		t.Errorf("Merge() error = %v, want %v", err, ErrHistogramLayout)
+	}
+}
+
+func TestHistogramSnapshot_Sub(t *testing.T) {
+	h, err := NewVHistogram([]int64{10, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Add(5)
+	h.Add(15)
+	prev := h.Snapshot().(*HistogramSnapshot)
+
+	d, err := prev.Sub(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{0, 0, 0}; !reflect.DeepEqual(want, d.Values()) {
+		t.Errorf("Sub(self).Values() = %v, want %v", d.Values(), want)
+	}
+	if d.Stats() != (HistogramStats{}) {
+		t.Errorf("Sub(self).Stats() = %+v, want zero", d.Stats())
+	}
+
+	h.Add(25)
+	h.Add(7)
+	cur := h.Snapshot().(*HistogramSnapshot)
+	d, err = cur.Sub(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{1, 0, 1}; !reflect.DeepEqual(want, d.Values()) {
+		t.Errorf("Sub().Values() = %v, want %v", d.Values(), want)
+	}
+	if want := (HistogramStats{Count: 2, Sum: 32, Min: 5, Max: 25}); d.Stats() != want {
+		t.Errorf("Sub().Stats() = %+v, want %+v", d.Stats(), want)
+	}
+
+	h.Clear()
+	if _, err = h.Snapshot().(*HistogramSnapshot).Sub(cur); err != ErrHistogramDecreased {
+		t.Errorf("Sub() after Clear() error = %v, want %v", err, ErrHistogramDecreased)
+	}
+}
+
+func TestHistogramSnapshot_Convert(t *testing.T) {
+	weights := []int64{10, 20, 30}
+	values := []int64{5, 15, 15, 25, 40}
+
+	h, err := NewVHistogram(weights, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sh, err := NewVSumHistogram(weights, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uh, err := NewVUHistogram([]uint64{10, 20, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suh, err := NewVSumUHistogram([]uint64{10, 20, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fh, err := NewFUHistogram([]float64{10, 20, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfh, err := NewVSumFHistogram([]float64{10, 20, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range values {
+		h.Add(v)
+		sh.Add(v)
+		uh.Add(uint64(v))
+		suh.Add(uint64(v))
+		fh.Add(float64(v))
+		sfh.Add(float64(v))
+	}
+
+	perBucket := []uint64{1, 2, 1, 1}
+	cumulative := []uint64{5, 4, 2, 1}
+	tests := []struct {
+		name string
+		h    HistogramInterface
+		want []uint64
+	}{
+		{"HistogramSnapshot.Cumulative", h.Snapshot().(*HistogramSnapshot).Cumulative(), cumulative},
+		{"SumHistogramSnapshot.PerBucket", sh.Snapshot().(*SumHistogramSnapshot).PerBucket(), perBucket},
+		{"UHistogramSnapshot.Cumulative", uh.Snapshot().(*UHistogramSnapshot).Cumulative(), cumulative},
+		{"SumUHistogramSnapshot.PerBucket", suh.Snapshot().(*SumUHistogramSnapshot).PerBucket(), perBucket},
+		{"FHistogramSnapshot.Cumulative", fh.Snapshot().(*FHistogramSnapshot).Cumulative(), cumulative},
+		{"SumFHistogramSnapshot.PerBucket", sfh.Snapshot().(*SumFHistogramSnapshot).PerBucket(), perBucket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.Values(); !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("Values() = %v, want %v", got, tt.want)
+			}
+			if want := (HistogramStats{Count: 5, Sum: 100, Min: 5, Max: 40}); tt.h.Stats() != want {
+				t.Errorf("Stats() = %+v, want %+v", tt.h.Stats(), want)
+			}
+			// quantiles must be the same for both representations
+			if got := tt.h.Quantile(0.5); got != 17.5 {
+				t.Errorf("Quantile(0.5) = %v, want 17.5", got)
+			}
+		})
+	}
+}
+
+func TestHistogramSnapshot_MergeSub(t *testing.T) {
+	tests := []struct {
+		name string
+		// returns merged and subtracted (merged - second) snapshots
+		run  func(t *testing.T) (merged, sub HistogramInterface)
+		want []uint64
+	}{
+		{
+			name: "SumHistogramSnapshot",
+			run: func(t *testing.T) (HistogramInterface, HistogramInterface) {
+				a, _ := NewVSumHistogram([]int64{10, 20}, nil)
+				b, _ := NewVSumHistogram([]int64{10, 20}, nil)
+				a.Add(5)
+				b.Add(15)
+				b.Add(25)
+				m, err := a.Snapshot().(*SumHistogramSnapshot).Merge(b.Snapshot().(*SumHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s, err := m.Sub(b.Snapshot().(*SumHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return m, s
+			},
+			want: []uint64{3, 2, 1},
+		},
+		{
+			name: "UHistogramSnapshot",
+			run: func(t *testing.T) (HistogramInterface, HistogramInterface) {
+				a, _ := NewVUHistogram([]uint64{10, 20}, nil)
+				b, _ := NewVUHistogram([]uint64{10, 20}, nil)
+				a.Add(5)
+				b.Add(15)
+				b.Add(25)
+				m, err := a.Snapshot().(*UHistogramSnapshot).Merge(b.Snapshot().(*UHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s, err := m.Sub(b.Snapshot().(*UHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return m, s
+			},
+			want: []uint64{1, 1, 1},
+		},
+		{
+			name: "SumUHistogramSnapshot",
+			run: func(t *testing.T) (HistogramInterface, HistogramInterface) {
+				a, _ := NewVSumUHistogram([]uint64{10, 20}, nil)
+				b, _ := NewVSumUHistogram([]uint64{10, 20}, nil)
+				a.Add(5)
+				b.Add(15)
+				b.Add(25)
+				m, err := a.Snapshot().(*SumUHistogramSnapshot).Merge(b.Snapshot().(*SumUHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s, err := m.Sub(b.Snapshot().(*SumUHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return m, s
+			},
+			want: []uint64{3, 2, 1},
+		},
+		{
+			name: "FHistogramSnapshot",
+			run: func(t *testing.T) (HistogramInterface, HistogramInterface) {
+				a, _ := NewFUHistogram([]float64{10, 20}, nil)
+				b, _ := NewFUHistogram([]float64{10, 20}, nil)
+				a.Add(5)
+				b.Add(15)
+				b.Add(25)
+				m, err := a.Snapshot().(*FHistogramSnapshot).Merge(b.Snapshot().(*FHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s, err := m.Sub(b.Snapshot().(*FHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return m, s
+			},
+			want: []uint64{1, 1, 1},
+		},
+		{
+			name: "SumFHistogramSnapshot",
+			run: func(t *testing.T) (HistogramInterface, HistogramInterface) {
+				a, _ := NewVSumFHistogram([]float64{10, 20}, nil)
+				b, _ := NewVSumFHistogram([]float64{10, 20}, nil)
+				a.Add(5)
+				b.Add(15)
+				b.Add(25)
+				m, err := a.Snapshot().(*SumFHistogramSnapshot).Merge(b.Snapshot().(*SumFHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s, err := m.Sub(b.Snapshot().(*SumFHistogramSnapshot))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return m, s
+			},
+			want: []uint64{3, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s := tt.run(t)
+			if got := m.Values(); !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("Merge().Values() = %v, want %v", got, tt.want)
+			}
+			if want := (HistogramStats{Count: 3, Sum: 45, Min: 5, Max: 25}); m.Stats() != want {
+				t.Errorf("Merge().Stats() = %+v, want %+v", m.Stats(), want)
+			}
+			// only first histogram observation remains, min and max are from merged snapshot
+			if want := (HistogramStats{Count: 1, Sum: 5, Min: 5, Max: 25}); s.Stats() != want {
+				t.Errorf("Sub().Stats() = %+v, want %+v", s.Stats(), want)
+			}
+		})
+	}
+}
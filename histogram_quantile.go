@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultPercentiles is a default histograms percentiles for exporters (log, syslog, exp, graphite) and StandardRegistry.GetAll().
+var DefaultPercentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// PercentileKey returns percentile metric name suffix, like "99-percentile" for 0.99 or "999-percentile" for 0.999.
+func PercentileKey(p float64) string {
+	return strings.Replace(strconv.FormatFloat(p*100.0, 'f', -1, 64), ".", "", 1) + "-percentile"
+}
+
+// PercentileName returns human-readable percentile name, like "median" for 0.5 or "99.9%" for 0.999.
+func PercentileName(p float64) string {
+	if p == 0.5 {
+		return "median"
+	}
+	return strconv.FormatFloat(p*100.0, 'f', -1, 64) + "%"
+}
+
+// bucketQuantile estimates q-quantile (0 <= q <= 1) with linear interpolation inside buckets bounds, like prometheus histogram_quantile.
+//
+// Bucket i stores observations in (weight(i-1), weight(i)], the last bucket is unbounded.
+// Unlike prometheus, observed min and max are used as the lower bound of the first bucket and the upper bound of the last bucket.
+// Returns zero without observations.
+func bucketQuantile(q float64, weight func(i int) float64, buckets []uint64, summed bool, stats HistogramStats) float64 {
+	if math.IsNaN(q) {
+		return math.NaN()
+	}
+	if len(buckets) == 0 || stats.Count == 0 {
+		return 0
+	}
+	var total uint64
+	if summed {
+		total = buckets[0]
+	} else {
+		for _, c := range buckets {
+			total += c
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return stats.Min
+	}
+	if q >= 1 {
+		return stats.Max
+	}
+
+	rank := q * float64(total)
+	last := len(buckets) - 1
+	var cum uint64
+	for i, c := range buckets {
+		if summed && i < last {
+			c -= buckets[i+1]
+		}
+		if c == 0 {
+			continue
+		}
+		if float64(cum+c) >= rank || i == last {
+			lo := stats.Min
+			if i > 0 {
+				lo = math.Max(lo, weight(i-1))
+			}
+			hi := stats.Max
+			if i < last {
+				hi = math.Min(hi, weight(i))
+			}
+			if hi <= lo {
+				return hi
+			}
+			return lo + (hi-lo)*(rank-float64(cum))/float64(c)
+		}
+		cum += c
+	}
+	return stats.Max
+}
+
+func bucketQuantiles(qs []float64, weight func(i int) float64, buckets []uint64, summed bool, stats HistogramStats) []float64 {
+	res := make([]float64, len(qs))
+	for i, q := range qs {
+		res[i] = bucketQuantile(q, weight, buckets, summed, stats)
+	}
+	return res
+}
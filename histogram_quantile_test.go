@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestHistogramQuantiles(t *testing.T) {
+	qs := []float64{0, 0.1, 0.5, 0.9, 1}
+	// buckets (per-bucket): [1, 2, 1, 1], min 5, max 40
+	want := []float64{5, 7.5, 17.5, 35, 40}
+
+	tests := []struct {
+		name string
+		h    HistogramInterface
+		add  func(HistogramInterface, float64)
+	}{
+		{
+			name: "VHistogram",
+			h:    mustHistogram(NewVHistogram([]int64{10, 20, 30}, nil)),
+			add:  func(h HistogramInterface, v float64) { h.(Histogram).Add(int64(v)) },
+		},
+		{
+			name: "VSumHistogram",
+			h:    mustHistogram(NewVSumHistogram([]int64{10, 20, 30}, nil)),
+			add:  func(h HistogramInterface, v float64) { h.(Histogram).Add(int64(v)) },
+		},
+		{
+			name: "FixedSumHistogram",
+			h:    NewFixedSumHistogram(10, 30, 10),
+			add:  func(h HistogramInterface, v float64) { h.(Histogram).Add(int64(v)) },
+		},
+		{
+			name: "VUHistogram",
+			h:    mustHistogram(NewVUHistogram([]uint64{10, 20, 30}, nil)),
+			add:  func(h HistogramInterface, v float64) { h.(UHistogram).Add(uint64(v)) },
+		},
+		{
+			name: "VSumUHistogram",
+			h:    mustHistogram(NewVSumUHistogram([]uint64{10, 20, 30}, nil)),
+			add:  func(h HistogramInterface, v float64) { h.(UHistogram).Add(uint64(v)) },
+		},
+		{
+			name: "FUHistogram",
+			h:    mustHistogram(NewFUHistogram([]float64{10, 20, 30}, nil)),
+			add:  func(h HistogramInterface, v float64) { h.(FHistogram).Add(v) },
+		},
+		{
+			name: "VSumFHistogram",
+			h:    mustHistogram(NewVSumFHistogram([]float64{10, 20, 30}, nil)),
+			add:  func(h HistogramInterface, v float64) { h.(FHistogram).Add(v) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.Quantiles(qs); !reflect.DeepEqual(got, make([]float64, len(qs))) {
+				t.Errorf("Quantiles() without observations = %v, want zeroes", got)
+			}
+			for _, v := range []float64{5, 15, 15, 25, 40} {
+				tt.add(tt.h, v)
+			}
+			if got := tt.h.Quantiles(qs); !reflect.DeepEqual(got, want) {
+				t.Errorf("Quantiles() = %v, want %v", got, want)
+			}
+			if got := tt.h.Quantile(0.5); got != 17.5 {
+				t.Errorf("Quantile(0.5) = %v, want 17.5", got)
+			}
+			if got := tt.h.Quantile(math.NaN()); !math.IsNaN(got) {
+				t.Errorf("Quantile(NaN) = %v, want NaN", got)
+			}
+		})
+	}
+}
+
+func TestHistogramSnapshot_Quantile(t *testing.T) {
+	h, err := NewVSumHistogram([]int64{10, 20, 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Add(12)
+	h.Add(12)
+	s := h.Snapshot()
+	h.Add(100)
+	// all observations in one bucket with min == max
+	if got := s.Quantile(0.5); got != 12 {
+		t.Errorf("Snapshot().Quantile(0.5) = %v, want 12", got)
+	}
+	// overflow bucket bounded by max observation: 30 + (100 - 30) * (0.99 * 3 - 2)
+	if got := h.Quantile(0.99); math.Abs(got-97.9) > 1e-9 {
+		t.Errorf("Quantile(0.99) = %v, want 97.9", got)
+	}
+}
+
+func TestNilHistogram_Quantiles(t *testing.T) {
+	if got := (NilHistogram{}).Quantiles([]float64{0.5, 0.9}); !reflect.DeepEqual(got, []float64{0, 0}) {
+		t.Errorf("NilHistogram.Quantiles() = %v, want [0 0]", got)
+	}
+}
+
+func mustHistogram(h HistogramInterface, err error) HistogramInterface {
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func TestPercentileNames(t *testing.T) {
+	tests := []struct {
+		p    float64
+		key  string
+		name string
+	}{
+		{0.5, "50-percentile", "median"},
+		{0.75, "75-percentile", "75%"},
+		{0.99, "99-percentile", "99%"},
+		{0.999, "999-percentile", "99.9%"},
+	}
+	for _, tt := range tests {
+		if got := PercentileKey(tt.p); got != tt.key {
+			t.Errorf("PercentileKey(%v) = %q, want %q", tt.p, got, tt.key)
+		}
+		if got := PercentileName(tt.p); got != tt.name {
+			t.Errorf("PercentileName(%v) = %q, want %q", tt.p, got, tt.name)
+		}
+	}
+}
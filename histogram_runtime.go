@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"math"
+	rtmetrics "runtime/metrics"
+	"strings"
+	"sync"
+)
+
+// RuntimeFHistogram is a FHistogram, filled from runtime/metrics Float64Histogram (like /gc/pauses:seconds).
+// Runtime buckets are folded into weights by bucket upper bound, so weights must be coarser than runtime buckets boundaries.
+// Runtime histograms don't track sum, min and max, so they are estimated from non-empty runtime buckets boundaries.
+type RuntimeFHistogram struct {
+	weights        []float64 // Sorted weights (greater or equal), last is inf
+	weightsAliases []string
+	labels         []string
+	total          string
+	index          []int    // runtime bucket to weights bucket
+	base           []uint64 // runtime buckets counts at last Clear()
+	raw            []uint64 // runtime buckets counts at last Update()
+	buckets        []uint64
+	count          uint64
+	sum            float64
+	min            float64
+	max            float64
+	lock           sync.RWMutex
+}
+
+// NewRuntimeFHistogram constructs a new RuntimeFHistogram with weights (last inf bucket is appended).
+func NewRuntimeFHistogram(weights []float64, names []string) (FHistogram, error) {
+	h, err := NewFUHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
+	u, ok := h.(*FUHistogram)
+	if !ok {
+		// UseNilMetrics
+		return h, nil
+	}
+	return &RuntimeFHistogram{
+		weights:        u.weights,
+		weightsAliases: u.weightsAliases,
+		labels:         u.labels,
+		total:          u.total,
+		buckets:        make([]uint64, len(u.weights)),
+	}, nil
+}
+
+// Update replaces histogram buckets with runtime/metrics histogram counts (minus counts at last Clear()).
+func (h *RuntimeFHistogram) Update(rh *rtmetrics.Float64Histogram) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.index) != len(rh.Counts) {
+		// runtime buckets boundaries are fixed for metric, so rebuild only on first update
+		h.index = make([]int, len(rh.Counts))
+		for i := range h.index {
+			h.index[i] = SearchFloat64Le(h.weights, rh.Buckets[i+1])
+		}
+		h.base = make([]uint64, len(rh.Counts))
+		h.raw = make([]uint64, len(rh.Counts))
+	}
+	copy(h.raw, rh.Counts)
+
+	for i := range h.buckets {
+		h.buckets[i] = 0
+	}
+	h.count = 0
+	h.sum = 0
+	for i, n := range h.raw {
+		if n <= h.base[i] {
+			continue
+		}
+		n -= h.base[i]
+		lo, hi := rh.Buckets[i], rh.Buckets[i+1]
+		if math.IsInf(lo, -1) {
+			lo = hi
+		}
+		if math.IsInf(hi, 1) {
+			hi = lo
+		}
+		if h.count == 0 {
+			h.min = lo
+		}
+		h.max = hi
+		h.count += n
+		h.sum += float64(n) * (lo + hi) / 2
+		h.buckets[h.index[i]] += n
+	}
+	if h.count == 0 {
+		h.min = 0
+		h.max = 0
+	}
+}
+
+func (h *RuntimeFHistogram) Values() []uint64 {
+	h.lock.RLock()
+	buckets := make([]uint64, len(h.buckets))
+	copy(buckets, h.buckets)
+	h.lock.RUnlock()
+	return buckets
+}
+
+func (h *RuntimeFHistogram) Labels() []string {
+	return h.labels
+}
+
+func (h *RuntimeFHistogram) SetLabels(labels []string) FHistogram {
+	h.lock.Lock()
+	for i := 0; i < Min(len(h.labels), len(labels)); i++ {
+		h.labels[i] = labels[i]
+	}
+	h.lock.Unlock()
+	return h
+}
+
+func (h *RuntimeFHistogram) AddLabelPrefix(labelPrefix string) FHistogram {
+	h.lock.Lock()
+	for i := range h.labels {
+		if strings.HasPrefix(h.labels[i], ".") {
+			h.labels[i] = "." + labelPrefix + h.labels[i][1:]
+		} else {
+			h.labels[i] = labelPrefix + h.labels[i]
+		}
+	}
+	h.lock.Unlock()
+	return h
+}
+
+func (h *RuntimeFHistogram) SetNameTotal(total string) FHistogram {
+	h.lock.Lock()
+	h.total = total
+	h.lock.Unlock()
+	return h
+}
+
+func (h *RuntimeFHistogram) NameTotal() string {
+	return h.total
+}
+
+func (h *RuntimeFHistogram) Weights() []float64 {
+	return h.weights
+}
+
+func (h *RuntimeFHistogram) WeightsAliases() []string {
+	return h.weightsAliases
+}
+
+// for static check compatbility with HistogramInterface
+func (h *RuntimeFHistogram) Interface() HistogramInterface {
+	return h
+}
+
+func (h *RuntimeFHistogram) Add(v float64) {
+	panic("Add called on a RuntimeFHistogram")
+}
+
+// Clear returns buckets and resets histogram, next Update() counts observations after Clear() call.
+func (h *RuntimeFHistogram) Clear() []uint64 {
+	h.lock.Lock()
+	buckets := h.buckets
+	h.buckets = make([]uint64, len(buckets))
+	copy(h.base, h.raw)
+	h.count = 0
+	h.sum = 0
+	h.min = 0
+	h.max = 0
+	h.lock.Unlock()
+	return buckets
+}
+
+func (h *RuntimeFHistogram) Snapshot() FHistogram {
+	return h.snapshot()
+}
+
+func (h *RuntimeFHistogram) snapshot() *FHistogramSnapshot {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	buckets := make([]uint64, len(h.buckets))
+	copy(buckets, h.buckets)
+	return &FHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		labels:         h.labels,
+		total:          h.total,
+		buckets:        buckets,
+		count:          h.count,
+		sum:            h.sum,
+		min:            h.min,
+		max:            h.max,
+	}
+}
+
+// Count returns observations count
+func (h *RuntimeFHistogram) Count() uint64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.count
+}
+
+// Sum returns estimated observations sum
+func (h *RuntimeFHistogram) Sum() float64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.sum
+}
+
+// Min returns estimated minimal observation (or zero without observations)
+func (h *RuntimeFHistogram) Min() float64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.min
+}
+
+// Max returns estimated maximal observation (or zero without observations)
+func (h *RuntimeFHistogram) Max() float64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.max
+}
+
+func (h *RuntimeFHistogram) Stats() HistogramStats {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return HistogramStats{Count: h.count, Sum: h.sum, Min: h.min, Max: h.max}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *RuntimeFHistogram) Quantile(q float64) float64 {
+	return h.snapshot().Quantile(q)
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *RuntimeFHistogram) Quantiles(qs []float64) []float64 {
+	return h.snapshot().Quantiles(qs)
+}
+
+func (h *RuntimeFHistogram) IsSummed() bool { return false }
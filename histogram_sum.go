@@ -47,42 +47,56 @@ func NewRegisteredFixedSumHistogramT(name string, tagsMap map[string]string, r R
 	return h
 }
 
-func GetOrRegisterVSumHistogram(name string, r Registry, weights []int64, names []string) Histogram {
+func GetOrRegisterVSumHistogram(name string, r Registry, weights []int64, names []string) (Histogram, error) {
+	if !IsSortedSliceInt64Ge(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegister(name, func() interface{} {
-		return NewVSumHistogram(weights, names)
-	}).(Histogram)
+		h, _ := NewVSumHistogram(weights, names)
+		return h
+	}).(Histogram), nil
 }
 
-func GetOrRegisterVSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) Histogram {
+func GetOrRegisterVSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) (Histogram, error) {
+	if !IsSortedSliceInt64Ge(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
-		return NewVSumHistogram(weights, names)
-	}).(Histogram)
+		h, _ := NewVSumHistogram(weights, names)
+		return h
+	}).(Histogram), nil
 }
 
 // NewRegisteredVSumHistogram constructs and registers a new VSumHistogram (prometheus-like histogram).
-func NewRegisteredVSumHistogram(name string, r Registry, weights []int64, names []string) Histogram {
+func NewRegisteredVSumHistogram(name string, r Registry, weights []int64, names []string) (Histogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVSumHistogram(weights, names)
+	h, err := NewVSumHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.Register(name, h)
-	return h
+	return h, nil
 }
 
 // NewRegisteredVSumHistogramT constructs and registers a new VSumHistogram (prometheus-like histogram).
-func NewRegisteredVSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) Histogram {
+func NewRegisteredVSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string) (Histogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVSumHistogram(weights, names)
+	h, err := NewVSumHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.RegisterT(name, tagsMap, h)
-	return h
+	return h, nil
 }
 
 type SumHistogramSnapshot struct {
@@ -91,6 +105,10 @@ type SumHistogramSnapshot struct {
 	names          []string
 	total          string
 	buckets        []uint64 // last buckets stores all, not included at previous
+	count          uint64
+	sum            int64
+	min            int64
+	max            int64
 }
 
 func (h *SumHistogramSnapshot) Values() []uint64 {
@@ -141,8 +159,106 @@ func (h *SumHistogramSnapshot) Snapshot() Histogram {
 	return h
 }
 
+func (h *SumHistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *SumHistogramSnapshot) Sum() int64 {
+	return h.sum
+}
+
+func (h *SumHistogramSnapshot) Min() int64 {
+	return h.min
+}
+
+func (h *SumHistogramSnapshot) Max() int64 {
+	return h.max
+}
+
+func (h *SumHistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: float64(h.sum), Min: float64(h.min), Max: float64(h.max)}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *SumHistogramSnapshot) Quantile(q float64) float64 {
+	return bucketQuantile(q, h.weight, h.buckets, true, h.Stats())
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *SumHistogramSnapshot) Quantiles(qs []float64) []float64 {
+	return bucketQuantiles(qs, h.weight, h.buckets, true, h.Stats())
+}
+
+func (h *SumHistogramSnapshot) weight(i int) float64 {
+	return float64(h.weights[i])
+}
+
 func (SumHistogramSnapshot) IsSummed() bool { return true }
 
+// Merge returns a new snapshot with observations from both snapshots (for example, from goroutine-local histograms).
+// Labels and total name are taken from h, weights must be equal.
+func (h *SumHistogramSnapshot) Merge(other *SumHistogramSnapshot) (*SumHistogramSnapshot, error) {
+	if !equalInt64Weights(h.weights, other.weights) || len(h.buckets) != len(other.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	min, max := mergeInt64MinMax(h.count, h.min, h.max, other.count, other.min, other.max)
+	return &SumHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        mergeBuckets(h.buckets, other.buckets),
+		count:          h.count + other.count,
+		sum:            h.sum + other.sum,
+		min:            min,
+		max:            max,
+	}, nil
+}
+
+// Sub returns a new snapshot with observations added after prev snapshot of the same histogram.
+// Min and max can't be restored for the interval, so they are taken from h (or zero without new observations).
+func (h *SumHistogramSnapshot) Sub(prev *SumHistogramSnapshot) (*SumHistogramSnapshot, error) {
+	if !equalInt64Weights(h.weights, prev.weights) || len(h.buckets) != len(prev.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	if h.count < prev.count {
+		return nil, ErrHistogramDecreased
+	}
+	buckets, err := subBuckets(h.buckets, prev.buckets)
+	if err != nil {
+		return nil, err
+	}
+	s := &SumHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        buckets,
+		count:          h.count - prev.count,
+	}
+	if s.count > 0 {
+		s.sum = h.sum - prev.sum
+		s.min = h.min
+		s.max = h.max
+	}
+	return s, nil
+}
+
+// PerBucket returns a copy of snapshot with per-bucket (not summed) counts.
+func (h *SumHistogramSnapshot) PerBucket() *HistogramSnapshot {
+	return &HistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        toPerBucketBuckets(h.buckets),
+		count:          h.count,
+		sum:            h.sum,
+		min:            h.min,
+		max:            h.max,
+	}
+}
+
 // A FixedSumHistogram is implementation of prometheus-like Histogram with fixed-size buckets.
 type FixedSumHistogram struct {
 	HistogramStorage
@@ -166,7 +282,6 @@ func NewFixedSumHistogram(startVal, endVal, width int64) *FixedSumHistogram {
 	weights := make([]int64, count)
 	weightsAliases := make([]string, count)
 	labels := make([]string, count)
-	buckets := make([]uint64, count)
 	ge := startVal
 	// fmtStr := fmt.Sprintf("%%s%%0%dd", len(strconv.FormatUint(endVal+width, 10)))
 	for i := 0; i < len(weights); i++ {
@@ -189,7 +304,7 @@ func NewFixedSumHistogram(startVal, endVal, width int64) *FixedSumHistogram {
 			weightsAliases: weightsAliases,
 			labels:         labels,
 			total:          ".total",
-			buckets:        buckets,
+			buckets:        newHistogramBuckets(len(weights), histogramInt64),
 		},
 		start: startVal,
 		width: width,
@@ -197,14 +312,19 @@ func NewFixedSumHistogram(startVal, endVal, width int64) *FixedSumHistogram {
 }
 
 func (h *FixedSumHistogram) Add(v int64) {
-	h.lock.Lock()
-	for i := 0; i < len(h.buckets); i++ {
-		h.buckets[i]++
-		if v <= h.weights[i] {
-			break
+	var n int64
+	if v > h.start {
+		n = v - h.start
+		if n%h.width == 0 {
+			n /= h.width
+		} else {
+			n = n/h.width + 1
+		}
+		if n >= int64(h.buckets.len()) {
+			n = int64(h.buckets.len() - 1)
 		}
 	}
-	h.lock.Unlock()
+	h.buckets.addInt64(int(n), v)
 }
 
 func (h *FixedSumHistogram) SetLabels(labels []string) Histogram {
@@ -221,13 +341,28 @@ func (h *FixedSumHistogram) SetNameTotal(total string) Histogram {
 	return h
 }
 
+func (h *FixedSumHistogram) Values() []uint64 {
+	return cumulativeBuckets(h.HistogramStorage.Values())
+}
+
+func (h *FixedSumHistogram) Snapshot() Histogram {
+	v := h.values()
+	sum, min, max := v.int64Stats()
+	return &SumHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.labels,
+		total:          h.NameTotal(),
+		buckets:        cumulativeBuckets(v.buckets),
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
+	}
+}
+
 func (h *FixedSumHistogram) Clear() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	v := h.buckets
-	h.buckets = buckets
-	h.lock.Unlock()
-	return v
+	return cumulativeBuckets(h.HistogramStorage.Clear())
 }
 
 func (h *FixedSumHistogram) IsSummed() bool { return true }
@@ -237,9 +372,26 @@ type VSumHistogram struct {
 	HistogramStorage
 }
 
-func NewVSumHistogram(weights []int64, names []string) *VSumHistogram {
+func NewVSumHistogram(weights []int64, names []string) (*VSumHistogram, error) {
+	w, weightsAliases, lbls, err := vSumHistogramWeights(weights, names)
+	if err != nil {
+		return nil, err
+	}
+	return &VSumHistogram{
+		HistogramStorage: HistogramStorage{
+			weights:        w,
+			weightsAliases: weightsAliases,
+			labels:         lbls,
+			total:          ".total",
+			buckets:        newHistogramBuckets(len(w), histogramInt64),
+		},
+	}, nil
+}
+
+// vSumHistogramWeights returns weights (with appended inf), le aliases and labels for variable-size buckets
+func vSumHistogramWeights(weights []int64, names []string) ([]int64, []string, []string, error) {
 	if !IsSortedSliceInt64Ge(weights) {
-		panic(ErrUnsortedWeights)
+		return nil, nil, nil, ErrUnsortedWeights
 	}
 	w := make([]int64, len(weights)+1)
 	weightsAliases := make([]string, len(w))
@@ -268,23 +420,15 @@ func NewVSumHistogram(weights []int64, names []string) *VSumHistogram {
 		}
 	}
 
-	return &VSumHistogram{
-		HistogramStorage: HistogramStorage{
-			weights:        w,
-			weightsAliases: weightsAliases,
-			labels:         lbls,
-			total:          ".total",
-			buckets:        make([]uint64, len(w)),
-		},
-	}
+	return w, weightsAliases, lbls, nil
 }
 
 func (h *VSumHistogram) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	copy(buckets, h.buckets)
-	h.lock.Unlock()
-	return buckets
+	return cumulativeBuckets(h.HistogramStorage.Values())
+}
+
+func (h *VSumHistogram) Clear() []uint64 {
+	return cumulativeBuckets(h.HistogramStorage.Clear())
 }
 
 func (h *VSumHistogram) WeightsAliases() []string {
@@ -297,24 +441,23 @@ func (h *VSumHistogram) Interface() HistogramInterface {
 }
 
 func (h *VSumHistogram) Snapshot() Histogram {
+	v := h.values()
+	sum, min, max := v.int64Stats()
 	return &SumHistogramSnapshot{
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
 		names:          h.labels,
 		total:          h.NameTotal(),
-		buckets:        h.Values(),
+		buckets:        cumulativeBuckets(v.buckets),
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *VSumHistogram) Add(v int64) {
-	h.lock.Lock()
-	for i := 0; i < len(h.buckets); i++ {
-		h.buckets[i]++
-		if v <= h.weights[i] {
-			break
-		}
-	}
-	h.lock.Unlock()
+	h.buckets.addInt64(SearchInt64Le(h.weights, v), v)
 }
 
 func (h *VSumHistogram) SetLabels(labels []string) Histogram {
@@ -48,42 +48,56 @@ func NewRegisteredFixedSumFHistogramT(name string, tagsMap map[string]string, r
 	return h
 }
 
-func GetOrRegisterVSumFHistogram(name string, r Registry, weights []float64, names []string) FHistogram {
+func GetOrRegisterVSumFHistogram(name string, r Registry, weights []float64, names []string) (FHistogram, error) {
+	if !IsSortedSliceFloat64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegister(name, func() interface{} {
-		return NewVSumFHistogram(weights, names)
-	}).(FHistogram)
+		h, _ := NewVSumFHistogram(weights, names)
+		return h
+	}).(FHistogram), nil
 }
 
-func GetOrRegisterVSumFHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) FHistogram {
+func GetOrRegisterVSumFHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) (FHistogram, error) {
+	if !IsSortedSliceFloat64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
-		return NewVSumFHistogram(weights, names)
-	}).(FHistogram)
+		h, _ := NewVSumFHistogram(weights, names)
+		return h
+	}).(FHistogram), nil
 }
 
 // NewRegisteredVSumFHistogram constructs and registers a new VSumFHistogram (prometheus-like histogram).
-func NewRegisteredVSumFHistogram(name string, r Registry, weights []float64, names []string) FHistogram {
+func NewRegisteredVSumFHistogram(name string, r Registry, weights []float64, names []string) (FHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVSumFHistogram(weights, names)
+	h, err := NewVSumFHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.Register(name, h)
-	return h
+	return h, nil
 }
 
 // NewRegisteredVSumFHistogramT constructs and registers a new VSumFHistogram (prometheus-like histogram).
-func NewRegisteredVSumFHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) FHistogram {
+func NewRegisteredVSumFHistogramT(name string, tagsMap map[string]string, r Registry, weights []float64, names []string) (FHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVSumFHistogram(weights, names)
+	h, err := NewVSumFHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.RegisterT(name, tagsMap, h)
-	return h
+	return h, nil
 }
 
 type SumFHistogramSnapshot struct {
@@ -92,6 +106,10 @@ type SumFHistogramSnapshot struct {
 	names          []string
 	total          string
 	buckets        []uint64 // last buckets stores all, not included at previous
+	count          uint64
+	sum            float64
+	min            float64
+	max            float64
 }
 
 func (h *SumFHistogramSnapshot) Values() []uint64 {
@@ -142,8 +160,106 @@ func (h *SumFHistogramSnapshot) Snapshot() FHistogram {
 	return h
 }
 
+func (h *SumFHistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *SumFHistogramSnapshot) Sum() float64 {
+	return h.sum
+}
+
+func (h *SumFHistogramSnapshot) Min() float64 {
+	return h.min
+}
+
+func (h *SumFHistogramSnapshot) Max() float64 {
+	return h.max
+}
+
+func (h *SumFHistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: h.sum, Min: h.min, Max: h.max}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *SumFHistogramSnapshot) Quantile(q float64) float64 {
+	return bucketQuantile(q, h.weight, h.buckets, true, h.Stats())
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *SumFHistogramSnapshot) Quantiles(qs []float64) []float64 {
+	return bucketQuantiles(qs, h.weight, h.buckets, true, h.Stats())
+}
+
+func (h *SumFHistogramSnapshot) weight(i int) float64 {
+	return h.weights[i]
+}
+
 func (SumFHistogramSnapshot) IsSummed() bool { return true }
 
+// Merge returns a new snapshot with observations from both snapshots (for example, from goroutine-local histograms).
+// Labels and total name are taken from h, weights must be equal.
+func (h *SumFHistogramSnapshot) Merge(other *SumFHistogramSnapshot) (*SumFHistogramSnapshot, error) {
+	if !equalFloat64Weights(h.weights, other.weights) || len(h.buckets) != len(other.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	min, max := mergeFloat64MinMax(h.count, h.min, h.max, other.count, other.min, other.max)
+	return &SumFHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        mergeBuckets(h.buckets, other.buckets),
+		count:          h.count + other.count,
+		sum:            h.sum + other.sum,
+		min:            min,
+		max:            max,
+	}, nil
+}
+
+// Sub returns a new snapshot with observations added after prev snapshot of the same histogram.
+// Min and max can't be restored for the interval, so they are taken from h (or zero without new observations).
+func (h *SumFHistogramSnapshot) Sub(prev *SumFHistogramSnapshot) (*SumFHistogramSnapshot, error) {
+	if !equalFloat64Weights(h.weights, prev.weights) || len(h.buckets) != len(prev.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	if h.count < prev.count {
+		return nil, ErrHistogramDecreased
+	}
+	buckets, err := subBuckets(h.buckets, prev.buckets)
+	if err != nil {
+		return nil, err
+	}
+	s := &SumFHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        buckets,
+		count:          h.count - prev.count,
+	}
+	if s.count > 0 {
+		s.sum = h.sum - prev.sum
+		s.min = h.min
+		s.max = h.max
+	}
+	return s, nil
+}
+
+// PerBucket returns a copy of snapshot with per-bucket (not summed) counts.
+func (h *SumFHistogramSnapshot) PerBucket() *FHistogramSnapshot {
+	return &FHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		labels:         h.names,
+		total:          h.total,
+		buckets:        toPerBucketBuckets(h.buckets),
+		count:          h.count,
+		sum:            h.sum,
+		min:            h.min,
+		max:            h.max,
+	}
+}
+
 // A FixedSumFHistogram is implementation of prometheus-like FHistogram with fixed-size buckets.
 type FixedSumFHistogram struct {
 	FHistogramStorage
@@ -167,7 +283,6 @@ func NewFixedSumFHistogram(startVal, endVal, width float64) *FixedSumFHistogram
 	weights := make([]float64, count)
 	weightsAliases := make([]string, count)
 	labels := make([]string, count)
-	buckets := make([]uint64, count)
 	ge := startVal
 
 	// maxLength := len(strconv.FormatInt(int64(endVal+width)+1, 10))
@@ -194,7 +309,7 @@ func NewFixedSumFHistogram(startVal, endVal, width float64) *FixedSumFHistogram
 			weightsAliases: weightsAliases,
 			labels:         labels,
 			total:          ".total",
-			buckets:        buckets,
+			buckets:        newHistogramBuckets(len(weights), histogramFloat64),
 		},
 		start: startVal,
 		width: width,
@@ -202,14 +317,7 @@ func NewFixedSumFHistogram(startVal, endVal, width float64) *FixedSumFHistogram
 }
 
 func (h *FixedSumFHistogram) Add(v float64) {
-	h.lock.Lock()
-	for i := 0; i < len(h.buckets); i++ {
-		h.buckets[i]++
-		if v <= h.weights[i] {
-			break
-		}
-	}
-	h.lock.Unlock()
+	h.buckets.addFloat64(SearchFloat64Le(h.weights, v), v)
 }
 
 func (h *FixedSumFHistogram) SetLabels(labels []string) FHistogram {
@@ -226,13 +334,28 @@ func (h *FixedSumFHistogram) SetNameTotal(total string) FHistogram {
 	return h
 }
 
+func (h *FixedSumFHistogram) Values() []uint64 {
+	return cumulativeBuckets(h.FHistogramStorage.Values())
+}
+
+func (h *FixedSumFHistogram) Snapshot() FHistogram {
+	v := h.values()
+	sum, min, max := v.float64Stats()
+	return &SumFHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.labels,
+		total:          h.NameTotal(),
+		buckets:        cumulativeBuckets(v.buckets),
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
+	}
+}
+
 func (h *FixedSumFHistogram) Clear() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	v := h.buckets
-	h.buckets = buckets
-	h.lock.Unlock()
-	return v
+	return cumulativeBuckets(h.FHistogramStorage.Clear())
 }
 
 func (h *FixedSumFHistogram) IsSummed() bool { return true }
@@ -242,9 +365,13 @@ type VSumFHistogram struct {
 	FHistogramStorage
 }
 
-func NewVSumFHistogram(weights []float64, names []string) *VSumFHistogram {
+func NewVSumFHistogram(weights []float64, names []string) (*VSumFHistogram, error) {
+	return newVSumFHistogram(weights, names, fWeightAlias)
+}
+
+func newVSumFHistogram(weights []float64, names []string, alias func(float64) string) (*VSumFHistogram, error) {
 	if !IsSortedSliceFloat64Le(weights) {
-		panic(ErrUnsortedWeights)
+		return nil, ErrUnsortedWeights
 	}
 	w := make([]float64, len(weights)+1)
 	weightsAliases := make([]string, len(w))
@@ -263,7 +390,7 @@ func NewVSumFHistogram(weights []float64, names []string) *VSumFHistogram {
 			weightsAliases[i] = "inf"
 			w[i] = math.MaxFloat64
 		} else {
-			weightsAliases[i] = strings.ReplaceAll(trimFloatZero(strconv.FormatFloat(w[i], 'f', 2, 64)), ".", "_")
+			weightsAliases[i] = alias(w[i])
 			if i >= len(names) || names[i] == "" {
 				// ns[i] = fmt.Sprintf(fmtStr, prefix, w[i])
 				lbls[i] = "." + weightsAliases[i]
@@ -279,17 +406,17 @@ func NewVSumFHistogram(weights []float64, names []string) *VSumFHistogram {
 			weightsAliases: weightsAliases,
 			labels:         lbls,
 			total:          ".total",
-			buckets:        make([]uint64, len(w)),
+			buckets:        newHistogramBuckets(len(w), histogramFloat64),
 		},
-	}
+	}, nil
 }
 
 func (h *VSumFHistogram) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	copy(buckets, h.buckets)
-	h.lock.Unlock()
-	return buckets
+	return cumulativeBuckets(h.FHistogramStorage.Values())
+}
+
+func (h *VSumFHistogram) Clear() []uint64 {
+	return cumulativeBuckets(h.FHistogramStorage.Clear())
 }
 
 func (h *VSumFHistogram) WeightsAliases() []string {
@@ -302,24 +429,23 @@ func (h *VSumFHistogram) Interface() HistogramInterface {
 }
 
 func (h *VSumFHistogram) Snapshot() FHistogram {
+	v := h.values()
+	sum, min, max := v.float64Stats()
 	return &SumFHistogramSnapshot{
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
 		names:          h.labels,
 		total:          h.NameTotal(),
-		buckets:        h.Values(),
+		buckets:        cumulativeBuckets(v.buckets),
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *VSumFHistogram) Add(v float64) {
-	h.lock.Lock()
-	for i := 0; i < len(h.buckets); i++ {
-		h.buckets[i]++
-		if v <= h.weights[i] {
-			break
-		}
-	}
-	h.lock.Unlock()
+	h.buckets.addFloat64(SearchFloat64Le(h.weights, v), v)
 }
 
 func (h *VSumFHistogram) SetLabels(labels []string) FHistogram {
@@ -219,7 +219,10 @@ func TestNewVSumFHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			got := NewVSumFHistogram(tt.weights, tt.names)
+			got, err := NewVSumFHistogram(tt.weights, tt.names)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if tt.labelPrefix != "" {
 				got.AddLabelPrefix(tt.labelPrefix)
 			}
@@ -250,7 +253,10 @@ func TestNewVSumFHistogram(t *testing.T) {
 
 func TestVSumFHistogram_Add(t *testing.T) {
 	r := NewRegistry()
-	h := GetOrRegisterVSumFHistogram("histogram", r, []float64{1, 2, 5, 8, 20}, nil)
+	h, err := GetOrRegisterVSumFHistogram("histogram", r, []float64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	// 1, 2, 5, 8, 20, inf
 	tests := []struct {
 		add  float64
@@ -288,7 +294,10 @@ func TestVSumFHistogram_Add(t *testing.T) {
 }
 
 func TestVSumFHistogram_SetNames(t *testing.T) {
-	h := NewVSumFHistogram([]float64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVSumFHistogram([]float64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	wantLabels := []string{".10", ".20", ".50", ".80", ".100", ".inf"}
 	weightsAliases := []string{"10", "20", "50", "80", "100", "inf"}
@@ -332,7 +341,10 @@ func TestVSumFHistogram_SetNames(t *testing.T) {
 }
 
 func TestVSumFHistogram_Snapshot(t *testing.T) {
-	h := NewVSumFHistogram([]float64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVSumFHistogram([]float64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.Add(19)
 	got := h.Snapshot()
 	want := []uint64{1, 1, 0, 0, 0, 0}
@@ -388,7 +400,10 @@ func BenchmarkFixedSumFHistogramParallelH(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram05(b *testing.B) {
-	h := NewVSumFHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVSumFHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -396,7 +411,10 @@ func BenchmarkVSumFHistogram05(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram05Parallel(b *testing.B) {
-	h := NewVSumFHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVSumFHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -405,9 +423,12 @@ func BenchmarkVSumFHistogram05Parallel(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram20(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -415,9 +436,12 @@ func BenchmarkVSumFHistogram20(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram20Parallel(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -426,9 +450,12 @@ func BenchmarkVSumFHistogram20Parallel(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram20ParallelH(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(10000)
@@ -437,14 +464,17 @@ func BenchmarkVSumFHistogram20ParallelH(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram100(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -452,14 +482,17 @@ func BenchmarkVSumFHistogram100(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram100H(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(10000)
@@ -467,14 +500,17 @@ func BenchmarkVSumFHistogram100H(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram100Parallel(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -483,14 +519,17 @@ func BenchmarkVSumFHistogram100Parallel(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram100ParallelH(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(10000)
@@ -508,7 +547,10 @@ func BenchmarkFixedSumFHistogram_Values(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram05_Values(b *testing.B) {
-	h := NewVSumFHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVSumFHistogram([]float64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -517,9 +559,12 @@ func BenchmarkVSumFHistogram05_Values(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram20_Values(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(1)
@@ -528,14 +573,17 @@ func BenchmarkVSumFHistogram20_Values(b *testing.B) {
 }
 
 func BenchmarkVSumFHistogram100_Values(b *testing.B) {
-	h := NewVSumFHistogram(
+	h, err := NewVSumFHistogram(
 		[]float64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(1)
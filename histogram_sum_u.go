@@ -47,42 +47,56 @@ func NewRegisteredFixedSumUHistogramT(name string, tagsMap map[string]string, r
 	return h
 }
 
-func GetOrRegisterVSumUHistogram(name string, r Registry, weights []uint64, names []string) UHistogram {
+func GetOrRegisterVSumUHistogram(name string, r Registry, weights []uint64, names []string) (UHistogram, error) {
+	if !IsSortedSliceUint64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegister(name, func() interface{} {
-		return NewVSumUHistogram(weights, names)
-	}).(UHistogram)
+		h, _ := NewVSumUHistogram(weights, names)
+		return h
+	}).(UHistogram), nil
 }
 
-func GetOrRegisterVSumUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) UHistogram {
+func GetOrRegisterVSumUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) (UHistogram, error) {
+	if !IsSortedSliceUint64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
-		return NewVSumUHistogram(weights, names)
-	}).(UHistogram)
+		h, _ := NewVSumUHistogram(weights, names)
+		return h
+	}).(UHistogram), nil
 }
 
 // NewRegisteredVSumUHistogram constructs and registers a new VSumUHistogram (prometheus-like histogram).
-func NewRegisteredVSumUHistogram(name string, r Registry, weights []uint64, names []string) UHistogram {
+func NewRegisteredVSumUHistogram(name string, r Registry, weights []uint64, names []string) (UHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVSumUHistogram(weights, names)
+	h, err := NewVSumUHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.Register(name, h)
-	return h
+	return h, nil
 }
 
 // NewRegisteredVSumUHistogramT constructs and registers a new VSumUHistogram (prometheus-like histogram).
-func NewRegisteredVSumUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) UHistogram {
+func NewRegisteredVSumUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) (UHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVSumUHistogram(weights, names)
+	h, err := NewVSumUHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.RegisterT(name, tagsMap, h)
-	return h
+	return h, nil
 }
 
 type SumUHistogramSnapshot struct {
@@ -91,6 +105,10 @@ type SumUHistogramSnapshot struct {
 	names          []string
 	total          string
 	buckets        []uint64 // last buckets stores all, not included at previous
+	count          uint64
+	sum            uint64
+	min            uint64
+	max            uint64
 }
 
 func (h *SumUHistogramSnapshot) Values() []uint64 {
@@ -141,8 +159,106 @@ func (h *SumUHistogramSnapshot) Snapshot() UHistogram {
 	return h
 }
 
+func (h *SumUHistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *SumUHistogramSnapshot) Sum() uint64 {
+	return h.sum
+}
+
+func (h *SumUHistogramSnapshot) Min() uint64 {
+	return h.min
+}
+
+func (h *SumUHistogramSnapshot) Max() uint64 {
+	return h.max
+}
+
+func (h *SumUHistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: float64(h.sum), Min: float64(h.min), Max: float64(h.max)}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *SumUHistogramSnapshot) Quantile(q float64) float64 {
+	return bucketQuantile(q, h.weight, h.buckets, true, h.Stats())
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *SumUHistogramSnapshot) Quantiles(qs []float64) []float64 {
+	return bucketQuantiles(qs, h.weight, h.buckets, true, h.Stats())
+}
+
+func (h *SumUHistogramSnapshot) weight(i int) float64 {
+	return float64(h.weights[i])
+}
+
 func (SumUHistogramSnapshot) IsSummed() bool { return true }
 
+// Merge returns a new snapshot with observations from both snapshots (for example, from goroutine-local histograms).
+// Labels and total name are taken from h, weights must be equal.
+func (h *SumUHistogramSnapshot) Merge(other *SumUHistogramSnapshot) (*SumUHistogramSnapshot, error) {
+	if !equalUint64Weights(h.weights, other.weights) || len(h.buckets) != len(other.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	min, max := mergeUint64MinMax(h.count, h.min, h.max, other.count, other.min, other.max)
+	return &SumUHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        mergeBuckets(h.buckets, other.buckets),
+		count:          h.count + other.count,
+		sum:            h.sum + other.sum,
+		min:            min,
+		max:            max,
+	}, nil
+}
+
+// Sub returns a new snapshot with observations added after prev snapshot of the same histogram.
+// Min and max can't be restored for the interval, so they are taken from h (or zero without new observations).
+func (h *SumUHistogramSnapshot) Sub(prev *SumUHistogramSnapshot) (*SumUHistogramSnapshot, error) {
+	if !equalUint64Weights(h.weights, prev.weights) || len(h.buckets) != len(prev.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	if h.count < prev.count {
+		return nil, ErrHistogramDecreased
+	}
+	buckets, err := subBuckets(h.buckets, prev.buckets)
+	if err != nil {
+		return nil, err
+	}
+	s := &SumUHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.names,
+		total:          h.total,
+		buckets:        buckets,
+		count:          h.count - prev.count,
+	}
+	if s.count > 0 {
+		s.sum = h.sum - prev.sum
+		s.min = h.min
+		s.max = h.max
+	}
+	return s, nil
+}
+
+// PerBucket returns a copy of snapshot with per-bucket (not summed) counts.
+func (h *SumUHistogramSnapshot) PerBucket() *UHistogramSnapshot {
+	return &UHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		labels:         h.names,
+		total:          h.total,
+		buckets:        toPerBucketBuckets(h.buckets),
+		count:          h.count,
+		sum:            h.sum,
+		min:            h.min,
+		max:            h.max,
+	}
+}
+
 // A FixedSumUHistogram is implementation of prometheus-like UHistogram with fixed-size buckets.
 type FixedSumUHistogram struct {
 	UHistogramStorage
@@ -163,7 +279,6 @@ func NewFixedSumUHistogram(startVal, endVal, width uint64) *FixedSumUHistogram {
 	weights := make([]uint64, count)
 	weightsAliases := make([]string, count)
 	labels := make([]string, count)
-	buckets := make([]uint64, count)
 	ge := startVal
 	// fmtStr := fmt.Sprintf("%%s%%0%dd", len(strconv.FormatUint(endVal+width, 10)))
 	for i := 0; i < len(weights); i++ {
@@ -186,7 +301,7 @@ func NewFixedSumUHistogram(startVal, endVal, width uint64) *FixedSumUHistogram {
 			weightsAliases: weightsAliases,
 			labels:         labels,
 			total:          ".total",
-			buckets:        buckets,
+			buckets:        newHistogramBuckets(len(weights), histogramUint64),
 		},
 		start: startVal,
 		width: width,
@@ -194,14 +309,19 @@ func NewFixedSumUHistogram(startVal, endVal, width uint64) *FixedSumUHistogram {
 }
 
 func (h *FixedSumUHistogram) Add(v uint64) {
-	h.lock.Lock()
-	for i := 0; i < len(h.buckets); i++ {
-		h.buckets[i]++
-		if v <= h.weights[i] {
-			break
+	var n uint64
+	if v > h.start {
+		n = v - h.start
+		if n%h.width == 0 {
+			n /= h.width
+		} else {
+			n = n/h.width + 1
+		}
+		if n >= uint64(h.buckets.len()) {
+			n = uint64(h.buckets.len()) - 1
 		}
 	}
-	h.lock.Unlock()
+	h.buckets.addUint64(int(n), v)
 }
 
 func (h *FixedSumUHistogram) SetLabels(labels []string) UHistogram {
@@ -218,13 +338,28 @@ func (h *FixedSumUHistogram) SetNameTotal(total string) UHistogram {
 	return h
 }
 
+func (h *FixedSumUHistogram) Values() []uint64 {
+	return cumulativeBuckets(h.UHistogramStorage.Values())
+}
+
+func (h *FixedSumUHistogram) Snapshot() UHistogram {
+	v := h.values()
+	sum, min, max := v.uint64Stats()
+	return &SumUHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.labels,
+		total:          h.NameTotal(),
+		buckets:        cumulativeBuckets(v.buckets),
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
+	}
+}
+
 func (h *FixedSumUHistogram) Clear() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	v := h.buckets
-	h.buckets = buckets
-	h.lock.Unlock()
-	return v
+	return cumulativeBuckets(h.UHistogramStorage.Clear())
 }
 
 func (h *FixedSumUHistogram) IsSummed() bool { return true }
@@ -234,9 +369,9 @@ type VSumUHistogram struct {
 	UHistogramStorage
 }
 
-func NewVSumUHistogram(weights []uint64, names []string) *VSumUHistogram {
+func NewVSumUHistogram(weights []uint64, names []string) (*VSumUHistogram, error) {
 	if !IsSortedSliceUint64Le(weights) {
-		panic(ErrUnsortedWeights)
+		return nil, ErrUnsortedWeights
 	}
 	w := make([]uint64, len(weights)+1)
 	weightsAliases := make([]string, len(w))
@@ -270,17 +405,17 @@ func NewVSumUHistogram(weights []uint64, names []string) *VSumUHistogram {
 			weightsAliases: weightsAliases,
 			labels:         lbls,
 			total:          ".total",
-			buckets:        make([]uint64, len(w)),
+			buckets:        newHistogramBuckets(len(w), histogramUint64),
 		},
-	}
+	}, nil
 }
 
 func (h *VSumUHistogram) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	copy(buckets, h.buckets)
-	h.lock.Unlock()
-	return buckets
+	return cumulativeBuckets(h.UHistogramStorage.Values())
+}
+
+func (h *VSumUHistogram) Clear() []uint64 {
+	return cumulativeBuckets(h.UHistogramStorage.Clear())
 }
 
 func (h *VSumUHistogram) WeightsAliases() []string {
@@ -293,24 +428,23 @@ func (h *VSumUHistogram) Interface() HistogramInterface {
 }
 
 func (h *VSumUHistogram) Snapshot() UHistogram {
+	v := h.values()
+	sum, min, max := v.uint64Stats()
 	return &SumUHistogramSnapshot{
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
 		names:          h.labels,
 		total:          h.NameTotal(),
-		buckets:        h.Values(),
+		buckets:        cumulativeBuckets(v.buckets),
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *VSumUHistogram) Add(v uint64) {
-	h.lock.Lock()
-	for i := 0; i < len(h.buckets); i++ {
-		h.buckets[i]++
-		if v <= h.weights[i] {
-			break
-		}
-	}
-	h.lock.Unlock()
+	h.buckets.addUint64(SearchUint64Le(h.weights, v), v)
 }
 
 func (h *VSumUHistogram) SetLabels(labels []string) UHistogram {
@@ -193,12 +193,9 @@ func TestInvalidVSumUHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			defer func() {
-				if r := recover(); r == nil {
-					t.Fatal("must panic")
-				}
-			}()
-			_ = NewVSumUHistogram(tt.weights, nil)
+			if _, err := NewVSumUHistogram(tt.weights, nil); err != ErrUnsortedWeights {
+				t.Fatalf("NewVSumUHistogram() error = %v, want %v", err, ErrUnsortedWeights)
+			}
 		})
 	}
 }
@@ -239,7 +236,10 @@ func TestNewVSumUHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			got := NewVSumUHistogram(tt.weights, tt.names)
+			got, err := NewVSumUHistogram(tt.weights, tt.names)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if tt.labelPrefix != "" {
 				got.AddLabelPrefix(tt.labelPrefix)
 			}
@@ -270,7 +270,10 @@ func TestNewVSumUHistogram(t *testing.T) {
 
 func TestVSumUHistogram_Add(t *testing.T) {
 	r := NewRegistry()
-	h := GetOrRegisterVSumUHistogram("histogram", r, []uint64{1, 2, 5, 8, 20}, nil)
+	h, err := GetOrRegisterVSumUHistogram("histogram", r, []uint64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	// 1, 2, 5, 8, 20, inf
 	tests := []struct {
 		add  uint64
@@ -308,7 +311,10 @@ func TestVSumUHistogram_Add(t *testing.T) {
 }
 
 func TestVSumUHistogram_SetNames(t *testing.T) {
-	h := NewVSumUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVSumUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	wantLabels := []string{".10", ".20", ".50", ".80", ".100", ".inf"}
 	weightsAliases := []string{"10", "20", "50", "80", "100", "inf"}
@@ -352,7 +358,10 @@ func TestVSumUHistogram_SetNames(t *testing.T) {
 }
 
 func TestVSumUHistogram_Snapshot(t *testing.T) {
-	h := NewVSumUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVSumUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.Add(19)
 	got := h.Snapshot()
 	want := []uint64{1, 1, 0, 0, 0, 0}
@@ -408,7 +417,10 @@ func BenchmarkFixedSumUHistogramParallelH(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram05(b *testing.B) {
-	h := NewVSumUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVSumUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -416,7 +428,10 @@ func BenchmarkVSumUHistogram05(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram05Parallel(b *testing.B) {
-	h := NewVSumUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVSumUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -425,9 +440,12 @@ func BenchmarkVSumUHistogram05Parallel(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram20(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -435,9 +453,12 @@ func BenchmarkVSumUHistogram20(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram20Parallel(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -446,9 +467,12 @@ func BenchmarkVSumUHistogram20Parallel(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram20ParallelH(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(10000)
@@ -457,14 +481,17 @@ func BenchmarkVSumUHistogram20ParallelH(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram100(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -472,14 +499,17 @@ func BenchmarkVSumUHistogram100(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram100H(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(10000)
@@ -487,14 +517,17 @@ func BenchmarkVSumUHistogram100H(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram100Parallel(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -503,14 +536,17 @@ func BenchmarkVSumUHistogram100Parallel(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram100ParallelH(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(10000)
@@ -528,7 +564,10 @@ func BenchmarkFixedSumUHistogram_Values(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram05_Values(b *testing.B) {
-	h := NewVSumUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVSumUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -537,9 +576,12 @@ func BenchmarkVSumUHistogram05_Values(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram20_Values(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(1)
@@ -548,14 +590,17 @@ func BenchmarkVSumUHistogram20_Values(b *testing.B) {
 }
 
 func BenchmarkVSumUHistogram100_Values(b *testing.B) {
-	h := NewVSumUHistogram(
+	h, err := NewVSumUHistogram(
 		[]uint64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(1)
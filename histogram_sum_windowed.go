@@ -0,0 +1,278 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+func GetOrRegisterWindowedVSumHistogram(name string, r Registry, weights []int64, names []string, window time.Duration, slices int) (Histogram, error) {
+	if !IsSortedSliceInt64Ge(weights) {
+		return nil, ErrUnsortedWeights
+	}
+	if err := checkWindow(window, slices); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() interface{} {
+		h, _ := NewWindowedVSumHistogram(weights, names, window, slices)
+		return h
+	}).(Histogram), nil
+}
+
+func GetOrRegisterWindowedVSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string, window time.Duration, slices int) (Histogram, error) {
+	if !IsSortedSliceInt64Ge(weights) {
+		return nil, ErrUnsortedWeights
+	}
+	if err := checkWindow(window, slices); err != nil {
+		return nil, err
+	}
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
+		h, _ := NewWindowedVSumHistogram(weights, names, window, slices)
+		return h
+	}).(Histogram), nil
+}
+
+// NewRegisteredWindowedVSumHistogram constructs and registers a new WindowedVSumHistogram (prometheus-like histogram over sliding time window).
+func NewRegisteredWindowedVSumHistogram(name string, r Registry, weights []int64, names []string, window time.Duration, slices int) (Histogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewWindowedVSumHistogram(weights, names, window, slices)
+	if err != nil {
+		return nil, err
+	}
+	r.Register(name, h)
+	return h, nil
+}
+
+// NewRegisteredWindowedVSumHistogramT constructs and registers a new WindowedVSumHistogram (prometheus-like histogram over sliding time window).
+func NewRegisteredWindowedVSumHistogramT(name string, tagsMap map[string]string, r Registry, weights []int64, names []string, window time.Duration, slices int) (Histogram, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	h, err := NewWindowedVSumHistogram(weights, names, window, slices)
+	if err != nil {
+		return nil, err
+	}
+	r.RegisterT(name, tagsMap, h)
+	return h, nil
+}
+
+// windowedHistogramSlice is observations, recorded in one window sub-interval
+type windowedHistogramSlice struct {
+	buckets []uint64 // not cumulative
+	count   uint64
+	sum     int64
+	min     int64
+	max     int64
+}
+
+func (s *windowedHistogramSlice) reset() {
+	for i := range s.buckets {
+		s.buckets[i] = 0
+	}
+	s.count = 0
+	s.sum = 0
+	s.min = 0
+	s.max = 0
+}
+
+// A WindowedVSumHistogram is implementation of prometheus-like Histogram with varibale-size buckets over sliding time window.
+//
+// Observations are stored in a ring of window/slices sub-intervals, rotated by wall clock.
+// Reads return aggregate over the last window (with slice granularity), so any number of readers
+// get the same view without destructive Clear.
+type WindowedVSumHistogram struct {
+	window
+	weights        []int64 // Sorted weights (greater or equal), last is inf
+	weightsAliases []string
+	labels         []string
+	total          string
+	slices         []windowedHistogramSlice
+	lock           sync.Mutex
+}
+
+func NewWindowedVSumHistogram(weights []int64, names []string, window time.Duration, slices int) (*WindowedVSumHistogram, error) {
+	w, weightsAliases, lbls, err := vSumHistogramWeights(weights, names)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := newWindow(window, slices)
+	if err != nil {
+		return nil, err
+	}
+	h := &WindowedVSumHistogram{
+		window:         ring,
+		weights:        w,
+		weightsAliases: weightsAliases,
+		labels:         lbls,
+		total:          ".total",
+		slices:         make([]windowedHistogramSlice, slices),
+	}
+	for i := range h.slices {
+		h.slices[i].buckets = make([]uint64, len(w))
+	}
+	return h, nil
+}
+
+func (h *WindowedVSumHistogram) Add(v int64) {
+	n := SearchInt64Le(h.weights, v)
+	h.lock.Lock()
+	i, stale := h.rotate(h.now())
+	s := &h.slices[i]
+	if stale {
+		s.reset()
+	}
+	s.buckets[n]++
+	if s.count == 0 || v < s.min {
+		s.min = v
+	}
+	if s.count == 0 || v > s.max {
+		s.max = v
+	}
+	s.count++
+	s.sum += v
+	h.lock.Unlock()
+}
+
+// snapshot aggregates slices inside window. Must be called under lock.
+func (h *WindowedVSumHistogram) snapshot(ts int64) *SumHistogramSnapshot {
+	snap := &SumHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.labels,
+		total:          h.total,
+		buckets:        make([]uint64, len(h.weights)),
+	}
+	for i := range h.slices {
+		s := &h.slices[i]
+		if s.count == 0 || !h.live(i, ts) {
+			continue
+		}
+		for n, c := range s.buckets {
+			snap.buckets[n] += c
+		}
+		if snap.count == 0 || s.min < snap.min {
+			snap.min = s.min
+		}
+		if snap.count == 0 || s.max > snap.max {
+			snap.max = s.max
+		}
+		snap.count += s.count
+		snap.sum += s.sum
+	}
+	snap.buckets = cumulativeBuckets(snap.buckets)
+	return snap
+}
+
+// Snapshot returns a read-only copy of the histogram over the last window.
+func (h *WindowedVSumHistogram) Snapshot() Histogram {
+	h.lock.Lock()
+	snap := h.snapshot(h.now())
+	h.lock.Unlock()
+	return snap
+}
+
+// Clear resets the histogram and returns buckets over the last window.
+func (h *WindowedVSumHistogram) Clear() []uint64 {
+	h.lock.Lock()
+	snap := h.snapshot(h.now())
+	for i := range h.slices {
+		h.slices[i].reset()
+	}
+	h.reset()
+	h.lock.Unlock()
+	return snap.buckets
+}
+
+func (h *WindowedVSumHistogram) Values() []uint64 {
+	return h.Snapshot().Values()
+}
+
+func (h *WindowedVSumHistogram) Count() uint64 {
+	return h.Snapshot().Count()
+}
+
+func (h *WindowedVSumHistogram) Sum() int64 {
+	return h.Snapshot().Sum()
+}
+
+func (h *WindowedVSumHistogram) Min() int64 {
+	return h.Snapshot().Min()
+}
+
+func (h *WindowedVSumHistogram) Max() int64 {
+	return h.Snapshot().Max()
+}
+
+func (h *WindowedVSumHistogram) Stats() HistogramStats {
+	return h.Snapshot().Stats()
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) over the last window with linear interpolation inside buckets (or zero without observations)
+func (h *WindowedVSumHistogram) Quantile(q float64) float64 {
+	return h.Snapshot().Quantile(q)
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) over the last window with linear interpolation inside buckets (or zeroes without observations)
+func (h *WindowedVSumHistogram) Quantiles(qs []float64) []float64 {
+	return h.Snapshot().Quantiles(qs)
+}
+
+func (h *WindowedVSumHistogram) Labels() []string {
+	return h.labels
+}
+
+func (h *WindowedVSumHistogram) SetLabels(labels []string) Histogram {
+	h.lock.Lock()
+	for i := 0; i < Min(len(h.labels), len(labels)); i++ {
+		h.labels[i] = labels[i]
+	}
+	h.lock.Unlock()
+	return h
+}
+
+func (h *WindowedVSumHistogram) AddLabelPrefix(labelPrefix string) Histogram {
+	h.lock.Lock()
+	for i := range h.labels {
+		if strings.HasPrefix(h.labels[i], ".") {
+			h.labels[i] = "." + labelPrefix + h.labels[i][1:]
+		} else {
+			h.labels[i] = labelPrefix + h.labels[i]
+		}
+	}
+	h.lock.Unlock()
+	return h
+}
+
+func (h *WindowedVSumHistogram) SetNameTotal(total string) Histogram {
+	h.lock.Lock()
+	h.total = total
+	h.lock.Unlock()
+	return h
+}
+
+func (h *WindowedVSumHistogram) NameTotal() string {
+	return h.total
+}
+
+func (h *WindowedVSumHistogram) Weights() []int64 {
+	return h.weights
+}
+
+func (h *WindowedVSumHistogram) WeightsAliases() []string {
+	return h.weightsAliases
+}
+
+// for static check compatbility with HistogramInterface
+func (h *WindowedVSumHistogram) Interface() HistogramInterface {
+	return h
+}
+
+func (h *WindowedVSumHistogram) IsSummed() bool { return true }
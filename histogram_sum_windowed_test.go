@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWindowedVSumHistogram(t *testing.T) {
+	h, err := NewWindowedVSumHistogram([]int64{1, 2, 5}, nil, time.Minute, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ts int64 = 1e12
+	h.now = func() int64 { return ts }
+
+	if !reflect.DeepEqual(h.Weights(), []int64{1, 2, 5, math.MaxInt64}) {
+		t.Errorf("Weights() = %v", h.Weights())
+	}
+	if !reflect.DeepEqual(h.Labels(), []string{".1", ".2", ".5", ".inf"}) {
+		t.Errorf("Labels() = %q", h.Labels())
+	}
+
+	tests := []struct {
+		step       time.Duration // clock shift before add
+		add        []int64
+		wantValues []uint64
+		wantStats  HistogramStats
+	}{
+		{
+			add:        []int64{1, 3},
+			wantValues: []uint64{2, 1, 1, 0},
+			wantStats:  HistogramStats{Count: 2, Sum: 4, Min: 1, Max: 3},
+		},
+		{
+			step:       30 * time.Second,
+			add:        []int64{10},
+			wantValues: []uint64{3, 2, 2, 1},
+			wantStats:  HistogramStats{Count: 3, Sum: 14, Min: 1, Max: 10},
+		},
+		{
+			// first slice is out of window
+			step:       30 * time.Second,
+			add:        []int64{2},
+			wantValues: []uint64{2, 2, 1, 1},
+			wantStats:  HistogramStats{Count: 2, Sum: 12, Min: 2, Max: 10},
+		},
+		{
+			step:       time.Hour,
+			wantValues: []uint64{0, 0, 0, 0},
+		},
+	}
+	for i, tt := range tests {
+		ts += int64(tt.step)
+		for _, v := range tt.add {
+			h.Add(v)
+		}
+		if got := h.Values(); !reflect.DeepEqual(got, tt.wantValues) {
+			t.Errorf("[%d] Values() = %v, want %v", i, got, tt.wantValues)
+		}
+		if got := h.Stats(); got != tt.wantStats {
+			t.Errorf("[%d] Stats() = %+v, want %+v", i, got, tt.wantStats)
+		}
+		// readers are not destructive
+		if got := h.Snapshot().Values(); !reflect.DeepEqual(got, tt.wantValues) {
+			t.Errorf("[%d] Snapshot().Values() = %v, want %v", i, got, tt.wantValues)
+		}
+	}
+
+	h.Add(4)
+	if got := h.Quantile(1); got != 4 {
+		t.Errorf("Quantile(1) = %v, want 4", got)
+	}
+	if got := h.Clear(); !reflect.DeepEqual(got, []uint64{1, 1, 1, 0}) {
+		t.Errorf("Clear() = %v, want [1 1 1 0]", got)
+	}
+	if got := h.Count(); got != 0 {
+		t.Errorf("Count() after Clear() = %v, want 0", got)
+	}
+}
+
+func TestWindowedVSumHistogram_Concurrent(t *testing.T) {
+	h, err := NewWindowedVSumHistogram([]int64{10, 100, 1000}, nil, time.Hour, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 1000; i++ {
+				h.Add(int64(i))
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		h.Snapshot()
+	}
+	wg.Wait()
+	if h.Count() != 8000 || h.Sum() != 8*1000*1001/2 {
+		t.Errorf("Count() = %d, Sum() = %v, want 8000, %d", h.Count(), h.Sum(), 8*1000*1001/2)
+	}
+}
+
+func BenchmarkWindowedVSumHistogram(b *testing.B) {
+	h, err := NewWindowedVSumHistogram([]int64{10, 100, 1000, 10000}, nil, time.Minute, 6)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Add(int64(i % 20000))
+	}
+}
@@ -197,12 +197,9 @@ func TestInvalidVHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			defer func() {
-				if r := recover(); r == nil {
-					t.Fatal("must panic")
-				}
-			}()
-			_ = NewVHistogram(tt.weights, nil)
+			if _, err := NewVHistogram(tt.weights, nil); err != ErrUnsortedWeights {
+				t.Fatalf("NewVHistogram() error = %v, want %v", err, ErrUnsortedWeights)
+			}
 		})
 	}
 }
@@ -243,7 +240,10 @@ func TestNewVHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			got := NewVHistogram(tt.weights, tt.labels)
+			got, err := NewVHistogram(tt.weights, tt.labels)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if tt.labelPrefix != "" {
 				got.AddLabelPrefix(tt.labelPrefix)
 			}
@@ -274,7 +274,10 @@ func TestNewVHistogram(t *testing.T) {
 
 func TestVHistogram_Add(t *testing.T) {
 	r := NewRegistry()
-	h := GetOrRegisterVHistogram("histogram", r, []int64{1, 2, 5, 8, 20}, nil)
+	h, err := GetOrRegisterVHistogram("histogram", r, []int64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	tests := []struct {
 		add  int64
 		want []uint64
@@ -311,7 +314,10 @@ func TestVHistogram_Add(t *testing.T) {
 }
 
 func TestVHistogram_SetNames(t *testing.T) {
-	h := NewVHistogram([]int64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVHistogram([]int64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	wantLabels := []string{".10", ".20", ".50", ".80", ".100", ".inf"}
 	weightsAliases := []string{"10", "20", "50", "80", "100", "inf"}
@@ -355,7 +361,10 @@ func TestVHistogram_SetNames(t *testing.T) {
 }
 
 func TestVHistogram_Snapshot(t *testing.T) {
-	h := NewVHistogram([]int64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVHistogram([]int64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.Add(19)
 	got := h.Snapshot()
 	want := []uint64{0, 1, 0, 0, 0, 0}
@@ -394,7 +403,10 @@ func BenchmarkFixedHistogramParallel(b *testing.B) {
 }
 
 func BenchmarkVHistogram05(b *testing.B) {
-	h := NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -402,7 +414,10 @@ func BenchmarkVHistogram05(b *testing.B) {
 }
 
 func BenchmarkVHistogram05Parallel(b *testing.B) {
-	h := NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -411,9 +426,12 @@ func BenchmarkVHistogram05Parallel(b *testing.B) {
 }
 
 func BenchmarkVHistogram20(b *testing.B) {
-	h := NewVHistogram(
+	h, err := NewVHistogram(
 		[]int64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -421,9 +439,12 @@ func BenchmarkVHistogram20(b *testing.B) {
 }
 
 func BenchmarkVHistogram20Parallel(b *testing.B) {
-	h := NewVHistogram(
+	h, err := NewVHistogram(
 		[]int64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -432,14 +453,17 @@ func BenchmarkVHistogram20Parallel(b *testing.B) {
 }
 
 func BenchmarkVHistogram100(b *testing.B) {
-	h := NewVHistogram(
+	h, err := NewVHistogram(
 		[]int64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -447,14 +471,17 @@ func BenchmarkVHistogram100(b *testing.B) {
 }
 
 func BenchmarkVHistogram100Parallel(b *testing.B) {
-	h := NewVHistogram(
+	h, err := NewVHistogram(
 		[]int64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			h.Add(50)
@@ -472,7 +499,10 @@ func BenchmarkFixedHistogram_Values(b *testing.B) {
 }
 
 func BenchmarkVHistogram05_Values(b *testing.B) {
-	h := NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVHistogram([]int64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -481,9 +511,12 @@ func BenchmarkVHistogram05_Values(b *testing.B) {
 }
 
 func BenchmarkVHistogram20_Values(b *testing.B) {
-	h := NewVHistogram(
+	h, err := NewVHistogram(
 		[]int64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(1)
@@ -492,14 +525,17 @@ func BenchmarkVHistogram20_Values(b *testing.B) {
 }
 
 func BenchmarkVHistogram100_Values(b *testing.B) {
-	h := NewVHistogram(
+	h, err := NewVHistogram(
 		[]int64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(1)
@@ -18,6 +18,14 @@ type UHistogram interface {
 	Snapshot() UHistogram
 	Add(v uint64)
 	Weights() []uint64
+	// Observations count
+	Count() uint64
+	// Observations sum
+	Sum() uint64
+	// Minimal observation (or zero without observations)
+	Min() uint64
+	// Maximal observation (or zero without observations)
+	Max() uint64
 }
 
 // GetOrRegisterHistogram returns an existing Histogram or constructs and registers
@@ -62,42 +70,56 @@ func NewRegisteredFixedUHistogramT(name string, tagsMap map[string]string, r Reg
 	return h
 }
 
-func GetOrRegisterVUHistogram(name string, r Registry, weights []uint64, names []string) UHistogram {
+func GetOrRegisterVUHistogram(name string, r Registry, weights []uint64, names []string) (UHistogram, error) {
+	if !IsSortedSliceUint64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegister(name, func() interface{} {
-		return NewVUHistogram(weights, names)
-	}).(UHistogram)
+		h, _ := NewVUHistogram(weights, names)
+		return h
+	}).(UHistogram), nil
 }
 
-func GetOrRegisterVUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) UHistogram {
+func GetOrRegisterVUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) (UHistogram, error) {
+	if !IsSortedSliceUint64Le(weights) {
+		return nil, ErrUnsortedWeights
+	}
 	if nil == r {
 		r = DefaultRegistry
 	}
 	return r.GetOrRegisterT(name, tagsMap, func() interface{} {
-		return NewVUHistogram(weights, names)
-	}).(UHistogram)
+		h, _ := NewVUHistogram(weights, names)
+		return h
+	}).(UHistogram), nil
 }
 
 // NewRegisteredVHistogram constructs and registers a new VHistogram.
-func NewRegisteredVUHistogram(name string, r Registry, weights []uint64, names []string) UHistogram {
+func NewRegisteredVUHistogram(name string, r Registry, weights []uint64, names []string) (UHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVUHistogram(weights, names)
+	h, err := NewVUHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.Register(name, h)
-	return h
+	return h, nil
 }
 
 // NewRegisteredVHistogramT constructs and registers a new VHistogram.
-func NewRegisteredVUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) UHistogram {
+func NewRegisteredVUHistogramT(name string, tagsMap map[string]string, r Registry, weights []uint64, names []string) (UHistogram, error) {
 	if nil == r {
 		r = DefaultRegistry
 	}
-	h := NewVUHistogram(weights, names)
+	h, err := NewVUHistogram(weights, names)
+	if err != nil {
+		return nil, err
+	}
 	r.RegisterT(name, tagsMap, h)
-	return h
+	return h, nil
 }
 
 type NilUHistogram struct{}
@@ -138,6 +160,20 @@ func (h NilUHistogram) Clear() []uint64 {
 
 func (NilUHistogram) Snapshot() UHistogram { return NilUHistogram{} }
 
+func (NilUHistogram) Count() uint64 { return 0 }
+
+func (NilUHistogram) Sum() uint64 { return 0 }
+
+func (NilUHistogram) Min() uint64 { return 0 }
+
+func (NilUHistogram) Max() uint64 { return 0 }
+
+func (NilUHistogram) Stats() HistogramStats { return HistogramStats{} }
+
+func (NilUHistogram) Quantile(float64) float64 { return 0 }
+
+func (NilUHistogram) Quantiles(qs []float64) []float64 { return make([]float64, len(qs)) }
+
 func (NilUHistogram) IsSummed() bool { return false }
 
 type UHistogramSnapshot struct {
@@ -146,6 +182,10 @@ type UHistogramSnapshot struct {
 	labels         []string
 	total          string
 	buckets        []uint64 // last buckets stores all, not included at previous
+	count          uint64
+	sum            uint64
+	min            uint64
+	max            uint64
 }
 
 func (h *UHistogramSnapshot) Values() []uint64 {
@@ -197,15 +237,113 @@ func (h *UHistogramSnapshot) Snapshot() UHistogram {
 	return h
 }
 
+func (h *UHistogramSnapshot) Count() uint64 {
+	return h.count
+}
+
+func (h *UHistogramSnapshot) Sum() uint64 {
+	return h.sum
+}
+
+func (h *UHistogramSnapshot) Min() uint64 {
+	return h.min
+}
+
+func (h *UHistogramSnapshot) Max() uint64 {
+	return h.max
+}
+
+func (h *UHistogramSnapshot) Stats() HistogramStats {
+	return HistogramStats{Count: h.count, Sum: float64(h.sum), Min: float64(h.min), Max: float64(h.max)}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *UHistogramSnapshot) Quantile(q float64) float64 {
+	return bucketQuantile(q, h.weight, h.buckets, false, h.Stats())
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *UHistogramSnapshot) Quantiles(qs []float64) []float64 {
+	return bucketQuantiles(qs, h.weight, h.buckets, false, h.Stats())
+}
+
+func (h *UHistogramSnapshot) weight(i int) float64 {
+	return float64(h.weights[i])
+}
+
 func (h *UHistogramSnapshot) IsSummed() bool { return false }
 
+// Merge returns a new snapshot with observations from both snapshots (for example, from goroutine-local histograms).
+// Labels and total name are taken from h, weights must be equal.
+func (h *UHistogramSnapshot) Merge(other *UHistogramSnapshot) (*UHistogramSnapshot, error) {
+	if !equalUint64Weights(h.weights, other.weights) || len(h.buckets) != len(other.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	min, max := mergeUint64MinMax(h.count, h.min, h.max, other.count, other.min, other.max)
+	return &UHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		labels:         h.labels,
+		total:          h.total,
+		buckets:        mergeBuckets(h.buckets, other.buckets),
+		count:          h.count + other.count,
+		sum:            h.sum + other.sum,
+		min:            min,
+		max:            max,
+	}, nil
+}
+
+// Sub returns a new snapshot with observations added after prev snapshot of the same histogram.
+// Min and max can't be restored for the interval, so they are taken from h (or zero without new observations).
+func (h *UHistogramSnapshot) Sub(prev *UHistogramSnapshot) (*UHistogramSnapshot, error) {
+	if !equalUint64Weights(h.weights, prev.weights) || len(h.buckets) != len(prev.buckets) {
+		return nil, ErrHistogramLayout
+	}
+	if h.count < prev.count {
+		return nil, ErrHistogramDecreased
+	}
+	buckets, err := subBuckets(h.buckets, prev.buckets)
+	if err != nil {
+		return nil, err
+	}
+	s := &UHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		labels:         h.labels,
+		total:          h.total,
+		buckets:        buckets,
+		count:          h.count - prev.count,
+	}
+	if s.count > 0 {
+		s.sum = h.sum - prev.sum
+		s.min = h.min
+		s.max = h.max
+	}
+	return s, nil
+}
+
+// Cumulative returns a copy of snapshot with cumulative (summed, like in Sum histograms) counts.
+func (h *UHistogramSnapshot) Cumulative() *SumUHistogramSnapshot {
+	return &SumUHistogramSnapshot{
+		weights:        h.weights,
+		weightsAliases: h.weightsAliases,
+		names:          h.labels,
+		total:          h.total,
+		buckets:        toCumulativeBuckets(h.buckets),
+		count:          h.count,
+		sum:            h.sum,
+		min:            h.min,
+		max:            h.max,
+	}
+}
+
 type UHistogramStorage struct {
-	weights        []uint64 // Sorted weights (greater or equal), last is inf
+	buckets        histogramBuckets // last bucket stores endVal overflows count
+	weights        []uint64         // Sorted weights (greater or equal), last is inf
 	weightsAliases []string
 	labels         []string
 	total          string
-	buckets        []uint64 // last bucket stores endVal overflows count
-	lock           sync.RWMutex
+	lock           sync.RWMutex // labels lock, also serialize buckets readers
 }
 
 func (h *UHistogramStorage) Labels() []string {
@@ -246,12 +384,57 @@ func (h *UHistogramStorage) Weights() []uint64 {
 	return h.weights
 }
 
-func (h *UHistogramStorage) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
+func (h *UHistogramStorage) values() histogramValues {
 	h.lock.Lock()
-	copy(buckets, h.buckets)
+	v := h.buckets.values()
 	h.lock.Unlock()
-	return buckets
+	return v
+}
+
+func (h *UHistogramStorage) Values() []uint64 {
+	return h.values().buckets
+}
+
+// Count returns observations count
+func (h *UHistogramStorage) Count() uint64 {
+	return h.values().count
+}
+
+// Sum returns observations sum
+func (h *UHistogramStorage) Sum() uint64 {
+	v := h.values()
+	sum, _, _ := v.uint64Stats()
+	return sum
+}
+
+// Min returns minimal observation (or zero without observations)
+func (h *UHistogramStorage) Min() uint64 {
+	v := h.values()
+	_, min, _ := v.uint64Stats()
+	return min
+}
+
+// Max returns maximal observation (or zero without observations)
+func (h *UHistogramStorage) Max() uint64 {
+	v := h.values()
+	_, _, max := v.uint64Stats()
+	return max
+}
+
+func (h *UHistogramStorage) Stats() HistogramStats {
+	v := h.values()
+	sum, min, max := v.uint64Stats()
+	return HistogramStats{Count: v.count, Sum: float64(sum), Min: float64(min), Max: float64(max)}
+}
+
+// Quantile returns estimated q-quantile (0 <= q <= 1) with linear interpolation inside buckets (or zero without observations)
+func (h *UHistogramStorage) Quantile(q float64) float64 {
+	return h.Snapshot().Quantile(q)
+}
+
+// Quantiles returns estimated quantiles (0 <= q <= 1) with linear interpolation inside buckets (or zeroes without observations)
+func (h *UHistogramStorage) Quantiles(qs []float64) []float64 {
+	return h.Snapshot().Quantiles(qs)
 }
 
 func (h *UHistogramStorage) WeightsAliases() []string {
@@ -266,22 +449,26 @@ func (h *UHistogramStorage) Interface() HistogramInterface {
 func (h *UHistogramStorage) IsSummed() bool { return false }
 
 func (h *UHistogramStorage) Snapshot() UHistogram {
+	v := h.values()
+	sum, min, max := v.uint64Stats()
 	return &UHistogramSnapshot{
 		labels:         h.labels,
 		total:          h.total,
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
-		buckets:        h.buckets,
+		buckets:        v.buckets,
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *UHistogramStorage) Clear() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
 	h.lock.Lock()
-	v := h.buckets
-	h.buckets = buckets
+	v := h.buckets.clear()
 	h.lock.Unlock()
-	return v
+	return v.buckets
 }
 
 // A FixedUHistogram is implementation of UHistogram with fixed-size buckets.
@@ -306,7 +493,6 @@ func NewFixedUHistogram(startVal, endVal, width uint64) UHistogram {
 	weights := make([]uint64, count)
 	weightsAliases := make([]string, count)
 	labels := make([]string, count)
-	buckets := make([]uint64, count)
 	ge := startVal
 	// fmtStr := fmt.Sprintf("%%s%%0%dd", len(strconv.FormatUint(endVal+width, 10)))
 	for i := 0; i < len(weights); i++ {
@@ -329,7 +515,7 @@ func NewFixedUHistogram(startVal, endVal, width uint64) UHistogram {
 			weightsAliases: weightsAliases,
 			labels:         labels,
 			total:          ".total",
-			buckets:        buckets,
+			buckets:        newHistogramBuckets(len(weights), histogramUint64),
 		},
 		start: startVal,
 		width: width,
@@ -345,13 +531,11 @@ func (h *FixedUHistogram) Add(v uint64) {
 		} else {
 			n = n/h.width + 1
 		}
-		if n >= uint64(len(h.buckets)) {
-			n = uint64(len(h.buckets)) - 1
+		if n >= uint64(h.buckets.len()) {
+			n = uint64(h.buckets.len()) - 1
 		}
 	}
-	h.lock.Lock()
-	h.buckets[n]++
-	h.lock.Unlock()
+	h.buckets.addUint64(int(n), v)
 }
 
 func (h *FixedUHistogram) SetLabels(labels []string) UHistogram {
@@ -373,12 +557,12 @@ type VUHistogram struct {
 	UHistogramStorage
 }
 
-func NewVUHistogram(weights []uint64, labels []string) UHistogram {
+func NewVUHistogram(weights []uint64, labels []string) (UHistogram, error) {
 	if UseNilMetrics {
-		return NilUHistogram{}
+		return NilUHistogram{}, nil
 	}
 	if !IsSortedSliceUint64Le(weights) {
-		panic(ErrUnsortedWeights)
+		return nil, ErrUnsortedWeights
 	}
 	w := make([]uint64, len(weights)+1)
 	weightsAliases := make([]string, len(w))
@@ -413,34 +597,29 @@ func NewVUHistogram(weights []uint64, labels []string) UHistogram {
 			weightsAliases: weightsAliases,
 			labels:         lbls,
 			total:          ".total",
-			buckets:        make([]uint64, len(w)),
+			buckets:        newHistogramBuckets(len(w), histogramUint64),
 		},
-	}
-}
-
-func (h *VUHistogram) Values() []uint64 {
-	buckets := make([]uint64, len(h.buckets))
-	h.lock.Lock()
-	copy(buckets, h.buckets)
-	h.lock.Unlock()
-	return buckets
+	}, nil
 }
 
 func (h *VUHistogram) Snapshot() UHistogram {
+	v := h.values()
+	sum, min, max := v.uint64Stats()
 	return &UHistogramSnapshot{
 		weights:        h.weights,
 		weightsAliases: h.weightsAliases,
 		labels:         h.labels,
 		total:          h.NameTotal(),
-		buckets:        h.Values(),
+		buckets:        v.buckets,
+		count:          v.count,
+		sum:            sum,
+		min:            min,
+		max:            max,
 	}
 }
 
 func (h *VUHistogram) Add(v uint64) {
-	n := SearchUint64Le(h.weights, v)
-	h.lock.Lock()
-	h.buckets[n]++
-	h.lock.Unlock()
+	h.buckets.addUint64(SearchUint64Le(h.weights, v), v)
 }
 
 func (h *VUHistogram) SetLabels(labels []string) UHistogram {
@@ -192,12 +192,9 @@ func TestInvalidVUHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			defer func() {
-				if r := recover(); r == nil {
-					t.Fatal("must panic")
-				}
-			}()
-			_ = NewVUHistogram(tt.weights, nil)
+			if _, err := NewVUHistogram(tt.weights, nil); err != ErrUnsortedWeights {
+				t.Fatalf("NewVUHistogram() error = %v, want %v", err, ErrUnsortedWeights)
+			}
 		})
 	}
 }
@@ -238,7 +235,10 @@ func TestNewVUHistogram(t *testing.T) {
 	}
 	for i, tt := range tests {
 		t.Run("#"+strconv.Itoa(i), func(t *testing.T) {
-			got := NewVUHistogram(tt.weights, tt.names)
+			got, err := NewVUHistogram(tt.weights, tt.names)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if tt.labelPrefix != "" {
 				got.AddLabelPrefix(tt.labelPrefix)
 			}
@@ -269,7 +269,10 @@ func TestNewVUHistogram(t *testing.T) {
 
 func TestVUHistogram_Add(t *testing.T) {
 	r := NewRegistry()
-	h := GetOrRegisterVUHistogram("histogram", r, []uint64{1, 2, 5, 8, 20}, nil)
+	h, err := GetOrRegisterVUHistogram("histogram", r, []uint64{1, 2, 5, 8, 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	tests := []struct {
 		add  uint64
 		want []uint64
@@ -306,7 +309,10 @@ func TestVUHistogram_Add(t *testing.T) {
 }
 
 func TestVUHistogram_SetNames(t *testing.T) {
-	h := NewVUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	wantLabels := []string{".10", ".20", ".50", ".80", ".100", ".inf"}
 	weightsAliases := []string{"10", "20", "50", "80", "100", "inf"}
@@ -350,7 +356,10 @@ func TestVUHistogram_SetNames(t *testing.T) {
 }
 
 func TestVUHistogram_Snapshot(t *testing.T) {
-	h := NewVUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	h, err := NewVUHistogram([]uint64{10, 20, 50, 80, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	h.Add(19)
 	got := h.Snapshot()
 	want := []uint64{0, 1, 0, 0, 0, 0}
@@ -380,7 +389,10 @@ func BenchmarkFixedUHistogram(b *testing.B) {
 }
 
 func BenchmarkVUHistogram05(b *testing.B) {
-	h := NewVUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -388,9 +400,12 @@ func BenchmarkVUHistogram05(b *testing.B) {
 }
 
 func BenchmarkVUHistogram20(b *testing.B) {
-	h := NewVUHistogram(
+	h, err := NewVUHistogram(
 		[]uint64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -398,14 +413,17 @@ func BenchmarkVUHistogram20(b *testing.B) {
 }
 
 func BenchmarkVUHistogram100(b *testing.B) {
-	h := NewVUHistogram(
+	h, err := NewVUHistogram(
 		[]uint64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -422,7 +440,10 @@ func BenchmarkFixedUHistogram_Values(b *testing.B) {
 }
 
 func BenchmarkVUHistogram05_Values(b *testing.B) {
-	h := NewVUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	h, err := NewVUHistogram([]uint64{10, 50, 100, 200, 300}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -431,9 +452,12 @@ func BenchmarkVUHistogram05_Values(b *testing.B) {
 }
 
 func BenchmarkVUHistogram20_Values(b *testing.B) {
-	h := NewVUHistogram(
+	h, err := NewVUHistogram(
 		[]uint64{10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800},
 		nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
@@ -442,14 +466,17 @@ func BenchmarkVUHistogram20_Values(b *testing.B) {
 }
 
 func BenchmarkVUHistogram100_Values(b *testing.B) {
-	h := NewVUHistogram(
+	h, err := NewVUHistogram(
 		[]uint64{
 			10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1300, 1400, 1500, 1600, 1700, 1800,
 			1900, 2000, 2100, 2200, 2300, 2400, 2500, 2600, 2700, 2800, 2900, 3000, 3100, 3200, 3300, 3400, 3500, 3600, 3700, 3800,
 			3900, 4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900, 5000, 5100, 5200, 5300, 5400, 5500, 5600, 5700, 5800,
 			5900, 6000, 6100, 6200, 6300, 6400, 6500, 6600, 6700, 6800, 6900, 7000, 7100, 7200, 7300, 7400, 7500, 7600, 7700, 7800,
-			6900, 7000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
+			7900, 8000, 8100, 8200, 8300, 8400, 8500, 8600, 8700, 8800, 8900, 9000, 9100, 9200, 9300, 9400, 9500, 9600, 9700, 9800,
 		}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		h.Add(50)
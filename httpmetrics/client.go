@@ -0,0 +1,190 @@
+package httpmetrics
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+// StatusError is a status tag value for failed client requests (without response)
+const StatusError = "error"
+
+// clientMetrics is a client metrics for host, method, status class and route
+type clientMetrics struct {
+	requests metrics.Counter
+	duration metrics.DurationHistogram
+}
+
+// hostMetrics is a client connection phases metrics for host
+type hostMetrics struct {
+	dns         metrics.DurationHistogram
+	connect     metrics.DurationHistogram
+	tls         metrics.DurationHistogram
+	ttfb        metrics.DurationHistogram
+	connReused  metrics.Counter
+	connCreated metrics.Counter
+}
+
+type transport struct {
+	next    http.RoundTripper
+	opts    Options
+	metrics sync.Map // *clientMetrics by host, method, status class and route
+	hosts   sync.Map // *hostMetrics by host
+}
+
+// Transport wraps http.RoundTripper (http.DefaultTransport if nil) and records (with names prefix, http.client by default):
+//
+//	PREFIX.requests - requests counter (tagged with host, method, status class and route)
+//	PREFIX.duration - requests duration histogram, until response headers are read (tagged with host, method, status class and route)
+//	PREFIX.dns, PREFIX.connect, PREFIX.tls - DNS lookup, TCP connect and TLS handshake duration histograms (tagged with host)
+//	PREFIX.ttfb - time to first response byte histogram (tagged with host)
+//	PREFIX.connections - got connections counter (tagged with host and reused=true|false)
+//
+// Failed requests (without response) are tagged with status=error. SizeWeights option is not used.
+// Returns error if histograms weights are invalid.
+func Transport(next http.RoundTripper, opts Options) (http.RoundTripper, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if err := opts.init("http.client"); err != nil {
+		return nil, err
+	}
+	return &transport{next: next, opts: opts}, nil
+}
+
+func (t *transport) clientMetrics(host, method, status, route string) *clientMetrics {
+	key := host + "\x00" + method + "\x00" + status + "\x00" + route
+	if m, ok := t.metrics.Load(key); ok {
+		return m.(*clientMetrics)
+	}
+	tags := metrics.MergeTags(map[string]string{"host": host}, t.opts.tags(method, status, route))
+	// weights are validated in init
+	duration, _ := metrics.GetOrRegisterDurationSumHistogramT(t.opts.Prefix+".duration", tags, t.opts.Registry, t.opts.DurationWeights, nil, t.opts.DurationUnit)
+	m := &clientMetrics{
+		requests: metrics.GetOrRegisterCounterT(t.opts.Prefix+".requests", tags, t.opts.Registry),
+		duration: duration,
+	}
+	actual, _ := t.metrics.LoadOrStore(key, m)
+	return actual.(*clientMetrics)
+}
+
+func (t *transport) hostMetrics(host string) *hostMetrics {
+	if m, ok := t.hosts.Load(host); ok {
+		return m.(*hostMetrics)
+	}
+	tags := metrics.MergeTags(map[string]string{"host": host}, t.opts.Tags)
+	histogram := func(name string) metrics.DurationHistogram {
+		h, _ := metrics.GetOrRegisterDurationSumHistogramT(t.opts.Prefix+"."+name, tags, t.opts.Registry, t.opts.DurationWeights, nil, t.opts.DurationUnit)
+		return h
+	}
+	m := &hostMetrics{
+		dns:     histogram("dns"),
+		connect: histogram("connect"),
+		tls:     histogram("tls"),
+		ttfb:    histogram("ttfb"),
+		connReused: metrics.GetOrRegisterCounterT(t.opts.Prefix+".connections",
+			metrics.MergeTags(map[string]string{"reused": "true"}, tags), t.opts.Registry),
+		connCreated: metrics.GetOrRegisterCounterT(t.opts.Prefix+".connections",
+			metrics.MergeTags(map[string]string{"reused": "false"}, tags), t.opts.Registry),
+	}
+	actual, _ := t.hosts.LoadOrStore(host, m)
+	return actual.(*hostMetrics)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	host := req.URL.Host
+	method := Method(req.Method)
+	var route string
+	if t.opts.Route != nil {
+		route = t.opts.Route(req)
+	}
+
+	hm := t.hostMetrics(host)
+	trace := newClientTrace(hm, start)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.trace()))
+
+	resp, err := t.next.RoundTrip(req)
+
+	status := StatusError
+	if err == nil {
+		status = StatusClass(resp.StatusCode)
+	}
+	m := t.clientMetrics(host, method, status, route)
+	m.requests.Add(1)
+	m.duration.UpdateSince(start)
+
+	return resp, err
+}
+
+// clientTrace records connection phases durations, httptrace callbacks can be called concurrently (like parallel dials)
+type clientTrace struct {
+	m       *hostMetrics
+	start   time.Time
+	lock    sync.Mutex
+	dns     time.Time
+	connect map[string]time.Time // by address
+	tls     time.Time
+}
+
+func newClientTrace(m *hostMetrics, start time.Time) *clientTrace {
+	return &clientTrace{m: m, start: start}
+}
+
+func (c *clientTrace) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			c.lock.Lock()
+			c.dns = time.Now()
+			c.lock.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			c.lock.Lock()
+			if info.Err == nil && !c.dns.IsZero() {
+				c.m.dns.UpdateSince(c.dns)
+			}
+			c.lock.Unlock()
+		},
+		ConnectStart: func(network, addr string) {
+			c.lock.Lock()
+			if c.connect == nil {
+				c.connect = make(map[string]time.Time)
+			}
+			c.connect[network+"/"+addr] = time.Now()
+			c.lock.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			c.lock.Lock()
+			if start, ok := c.connect[network+"/"+addr]; ok && err == nil {
+				c.m.connect.UpdateSince(start)
+			}
+			c.lock.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			c.lock.Lock()
+			c.tls = time.Now()
+			c.lock.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			c.lock.Lock()
+			if err == nil && !c.tls.IsZero() {
+				c.m.tls.UpdateSince(c.tls)
+			}
+			c.lock.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.m.connReused.Add(1)
+			} else {
+				c.m.connCreated.Add(1)
+			}
+		},
+		GotFirstResponseByte: func() {
+			c.m.ttfb.UpdateSince(c.start)
+		},
+	}
+}
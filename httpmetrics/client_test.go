@@ -0,0 +1,115 @@
+package httpmetrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/missing" {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	// localhost for DNS lookup
+	host := "localhost:" + u.Port()
+
+	r := metrics.NewRegistry()
+	tr, err := Transport(&http.Transport{}, Options{Registry: r, Tags: map[string]string{"app": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: tr}
+
+	for _, path := range []string{"/", "/", "/missing"} {
+		resp, err := client.Get("http://" + host + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	tags := func(status string) map[string]string {
+		return map[string]string{"app": "test", "host": host, "method": "GET", "status": status}
+	}
+	for status, want := range map[string]uint64{"2xx": 2, "4xx": 1} {
+		if got := r.GetT("http.client.requests", tags(status)).(metrics.Counter).Count(); got != want {
+			t.Errorf("http.client.requests%s = %d, want %d", metrics.JoinTags(tags(status)), got, want)
+		}
+	}
+	if got := r.GetT("http.client.duration", tags("2xx")).(metrics.DurationHistogram).Stats().Count; got != 2 {
+		t.Errorf("http.client.duration%s count = %d, want 2", metrics.JoinTags(tags("2xx")), got)
+	}
+
+	hostTags := map[string]string{"app": "test", "host": host}
+	for name, want := range map[string]uint64{"http.client.dns": 1, "http.client.connect": 1, "http.client.tls": 0, "http.client.ttfb": 3} {
+		if got := r.GetT(name, hostTags).(metrics.DurationHistogram).Stats().Count; got != want {
+			t.Errorf("%s%s count = %d, want %d", name, metrics.JoinTags(hostTags), got, want)
+		}
+	}
+	for reused, want := range map[string]uint64{"true": 2, "false": 1} {
+		connTags := map[string]string{"app": "test", "host": host, "reused": reused}
+		if got := r.GetT("http.client.connections", connTags).(metrics.Counter).Count(); got != want {
+			t.Errorf("http.client.connections%s = %d, want %d", metrics.JoinTags(connTags), got, want)
+		}
+	}
+}
+
+func TestTransport_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	r := metrics.NewRegistry()
+	tr, err := Transport(srv.Client().Transport, Options{Registry: r, Prefix: "api.client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := r.GetT("api.client.tls", map[string]string{"host": host}).(metrics.DurationHistogram).Stats().Count; got != 1 {
+		t.Errorf("api.client.tls count = %d, want 1", got)
+	}
+}
+
+type errTransport struct{}
+
+func (errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTransport_Error(t *testing.T) {
+	r := metrics.NewRegistry()
+	tr, err := Transport(errTransport{}, Options{
+		Registry: r,
+		Route:    func(req *http.Request) string { return "users" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (&http.Client{Transport: tr}).Post("http://example.com/users", "text/plain", nil); err == nil {
+		t.Fatal("request must fail")
+	}
+	tags := map[string]string{"host": "example.com", "method": "POST", "status": StatusError, "route": "users"}
+	if got := r.GetT("http.client.requests", tags).(metrics.Counter).Count(); got != 1 {
+		t.Errorf("http.client.requests%s = %d, want 1", metrics.JoinTags(tags), got)
+	}
+}
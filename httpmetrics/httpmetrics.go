@@ -0,0 +1,208 @@
+// Package httpmetrics provides net/http server and client instrumentation with go-metrics.
+package httpmetrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+var (
+	// DefaultDurationWeights is a default requests duration histogram weights (in milliseconds)
+	DefaultDurationWeights = []time.Duration{
+		5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+		100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second,
+		2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+	}
+	// DefaultSizeWeights is a default requests and responses size histograms weights (in bytes)
+	DefaultSizeWeights = []int64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Options is a server handler and client transport metrics naming and histograms options.
+type Options struct {
+	// Registry for metrics registration, metrics.DefaultRegistry if nil
+	Registry metrics.Registry
+	// Prefix is a metrics names prefix, http.server (for Handler) or http.client (for Transport) by default
+	Prefix string
+	// Tags for all metrics, merged with method, status and route tags
+	Tags map[string]string
+	// Route returns route name for request (like users.get), route tag is not set if nil.
+	// Must return a limited set of values (don't use raw URL path).
+	Route func(r *http.Request) string
+	// DurationWeights is a requests duration histogram weights, DefaultDurationWeights if nil
+	DurationWeights []time.Duration
+	// DurationUnit is a requests duration histogram unit, time.Millisecond by default
+	DurationUnit time.Duration
+	// SizeWeights is a requests and responses size histograms weights, DefaultSizeWeights if nil
+	SizeWeights []int64
+}
+
+// init sets defaults and validate histograms weights
+func (o *Options) init(prefix string) error {
+	if o.Registry == nil {
+		o.Registry = metrics.DefaultRegistry
+	}
+	if o.Prefix == "" {
+		o.Prefix = prefix
+	}
+	if o.DurationWeights == nil {
+		o.DurationWeights = DefaultDurationWeights
+	}
+	if o.DurationUnit == 0 {
+		o.DurationUnit = time.Millisecond
+	}
+	if o.SizeWeights == nil {
+		o.SizeWeights = DefaultSizeWeights
+	}
+	if _, err := metrics.NewDurationSumHistogram(o.DurationWeights, nil, o.DurationUnit); err != nil {
+		return err
+	}
+	if _, err := metrics.NewVSumHistogram(o.SizeWeights, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *Options) tags(method, status, route string) map[string]string {
+	tags := map[string]string{"method": method}
+	if status != "" {
+		tags["status"] = status
+	}
+	if o.Route != nil {
+		tags["route"] = route
+	}
+	return metrics.MergeTags(tags, o.Tags)
+}
+
+// requestMetrics is a metrics for method, status class and route
+type requestMetrics struct {
+	requests     metrics.Counter
+	duration     metrics.DurationHistogram
+	requestSize  metrics.Histogram
+	responseSize metrics.Histogram
+}
+
+func (o *Options) requestMetrics(cache *sync.Map, method, status, route string) *requestMetrics {
+	key := method + "\x00" + status + "\x00" + route
+	if m, ok := cache.Load(key); ok {
+		return m.(*requestMetrics)
+	}
+	tags := o.tags(method, status, route)
+	// weights are validated in init
+	duration, _ := metrics.GetOrRegisterDurationSumHistogramT(o.Prefix+".duration", tags, o.Registry, o.DurationWeights, nil, o.DurationUnit)
+	requestSize, _ := metrics.GetOrRegisterVSumHistogramT(o.Prefix+".request_size", tags, o.Registry, o.SizeWeights, nil)
+	responseSize, _ := metrics.GetOrRegisterVSumHistogramT(o.Prefix+".response_size", tags, o.Registry, o.SizeWeights, nil)
+	m := &requestMetrics{
+		requests:     metrics.GetOrRegisterCounterT(o.Prefix+".requests", tags, o.Registry),
+		duration:     duration,
+		requestSize:  requestSize,
+		responseSize: responseSize,
+	}
+	actual, _ := cache.LoadOrStore(key, m)
+	return actual.(*requestMetrics)
+}
+
+type handler struct {
+	next     http.Handler
+	opts     Options
+	inFlight sync.Map // DownCounter by method and route
+	metrics  sync.Map // *requestMetrics by method, status class and route
+}
+
+// Handler wraps http.Handler and records (with names prefix, http.server by default):
+//
+//	PREFIX.requests - requests counter (tagged with method, status class and route)
+//	PREFIX.in_flight - in-flight requests (tagged with method and route)
+//	PREFIX.duration - requests duration histogram (tagged with method, status class and route)
+//	PREFIX.request_size, PREFIX.response_size - requests and responses size histograms (tagged with method, status class and route)
+//
+// Status class tag is like 2xx, unknown methods are tagged as other.
+// Returns error if histograms weights are invalid.
+func Handler(next http.Handler, opts Options) (http.Handler, error) {
+	if err := opts.init("http.server"); err != nil {
+		return nil, err
+	}
+	return &handler{next: next, opts: opts}, nil
+}
+
+func (h *handler) inFlightCounter(method, route string) metrics.DownCounter {
+	key := method + "\x00" + route
+	if c, ok := h.inFlight.Load(key); ok {
+		return c.(metrics.DownCounter)
+	}
+	c := metrics.GetOrRegisterDownCounterT(h.opts.Prefix+".in_flight", h.opts.tags(method, "", route), h.opts.Registry)
+	actual, _ := h.inFlight.LoadOrStore(key, c)
+	return actual.(metrics.DownCounter)
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	method := Method(r.Method)
+	var route string
+	if h.opts.Route != nil {
+		route = h.opts.Route(r)
+	}
+
+	inFlight := h.inFlightCounter(method, route)
+	inFlight.Add(1)
+	defer inFlight.Sub(1)
+
+	var body *countingReader
+	if r.Body != nil && r.Body != http.NoBody {
+		body = &countingReader{ReadCloser: r.Body}
+		r.Body = body
+	}
+	rw := &responseWriter{ResponseWriter: w}
+
+	h.next.ServeHTTP(wrapWriter(rw), r)
+
+	requestSize := r.ContentLength
+	if requestSize < 0 {
+		requestSize = 0
+		if body != nil {
+			requestSize = body.n
+		}
+	}
+	m := h.opts.requestMetrics(&h.metrics, method, StatusClass(rw.Status()), route)
+	m.requests.Add(1)
+	m.duration.UpdateSince(start)
+	m.requestSize.Add(requestSize)
+	m.responseSize.Add(rw.size)
+}
+
+// countingReader counts read bytes (for requests without Content-Length)
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
+// Method returns HTTP method for tag (other for unknown methods, for limit cardinality)
+func Method(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	case "":
+		return http.MethodGet
+	default:
+		return "other"
+	}
+}
+
+// StatusClass returns HTTP status class for tag (like 2xx)
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "other"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,252 @@
+package httpmetrics
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+func TestHandler(t *testing.T) {
+	r := metrics.NewRegistry()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			_, _ = io.Copy(io.Discard, req.Body)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		_, _ = w.Write([]byte("users list"))
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "fail", http.StatusServiceUnavailable)
+	})
+
+	h, err := Handler(mux, Options{
+		Registry: r,
+		Tags:     map[string]string{"app": "test"},
+		Route: func(req *http.Request) string {
+			return strings.TrimPrefix(req.URL.Path, "/")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(srv.URL + "/users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	resp, err := http.Post(srv.URL+"/users", "text/plain", strings.NewReader("new user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get(srv.URL + "/fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	tags := func(method, status, route string) map[string]string {
+		return map[string]string{"app": "test", "method": method, "status": status, "route": route}
+	}
+	counters := []struct {
+		tags map[string]string
+		want uint64
+	}{
+		{tags("GET", "2xx", "users"), 3},
+		{tags("POST", "2xx", "users"), 1},
+		{tags("GET", "5xx", "fail"), 1},
+	}
+	for _, tt := range counters {
+		if got := r.GetT("http.server.requests", tt.tags).(metrics.Counter).Count(); got != tt.want {
+			t.Errorf("http.server.requests%s = %d, want %d", metrics.JoinTags(tt.tags), got, tt.want)
+		}
+	}
+
+	get := tags("GET", "2xx", "users")
+	if got := r.GetT("http.server.duration", get).(metrics.DurationHistogram).Stats().Count; got != 3 {
+		t.Errorf("http.server.duration%s count = %d, want 3", metrics.JoinTags(get), got)
+	}
+	if got := r.GetT("http.server.response_size", get).(metrics.Histogram).Sum(); got != 3*int64(len("users list")) {
+		t.Errorf("http.server.response_size%s sum = %d, want %d", metrics.JoinTags(get), got, 3*len("users list"))
+	}
+	post := tags("POST", "2xx", "users")
+	if got := r.GetT("http.server.request_size", post).(metrics.Histogram).Sum(); got != int64(len("new user")) {
+		t.Errorf("http.server.request_size%s sum = %d, want %d", metrics.JoinTags(post), got, len("new user"))
+	}
+	inFlight := map[string]string{"app": "test", "method": "GET", "route": "users"}
+	if got := r.GetT("http.server.in_flight", inFlight).(metrics.DownCounter).Count(); got != 0 {
+		t.Errorf("http.server.in_flight%s = %d, want 0", metrics.JoinTags(inFlight), got)
+	}
+}
+
+func TestHandler_InFlight(t *testing.T) {
+	r := metrics.NewRegistry()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h, err := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	}), Options{Registry: r, Prefix: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-started
+	c := r.GetT("api.in_flight", map[string]string{"method": "GET"}).(metrics.DownCounter)
+	if got := c.Count(); got != 1 {
+		t.Errorf("api.in_flight = %d, want 1", got)
+	}
+	close(release)
+	<-done
+	if got := c.Count(); got != 0 {
+		t.Errorf("api.in_flight = %d, want 0", got)
+	}
+	if got := r.GetT("api.requests", map[string]string{"method": "GET", "status": "2xx"}).(metrics.Counter).Count(); got != 1 {
+		t.Errorf("api.requests = %d, want 1", got)
+	}
+}
+
+func TestHandler_InvalidWeights(t *testing.T) {
+	if _, err := Handler(http.NotFoundHandler(), Options{SizeWeights: []int64{10, 1}}); err == nil {
+		t.Error("Handler() with unsorted size weights must fail")
+	}
+	if _, err := Handler(http.NotFoundHandler(), Options{DurationWeights: []time.Duration{time.Microsecond}}); err == nil {
+		t.Error("Handler() with duration weights, not multiple of unit, must fail")
+	}
+}
+
+type testWriter struct {
+	http.ResponseWriter
+	flushed bool
+}
+
+type testFlusher struct{ *testWriter }
+
+func (w testFlusher) Flush() { w.flushed = true }
+
+type testHijacker struct{ *testWriter }
+
+func (w testHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+type testPusher struct{ *testWriter }
+
+func (w testPusher) Push(string, *http.PushOptions) error { return nil }
+
+func TestWrapWriter(t *testing.T) {
+	tests := []struct {
+		name                      string
+		w                         func(w *testWriter) http.ResponseWriter
+		flusher, hijacker, pusher bool
+	}{
+		{
+			name: "plain",
+			w:    func(w *testWriter) http.ResponseWriter { return w },
+		},
+		{
+			name:    "flusher",
+			w:       func(w *testWriter) http.ResponseWriter { return struct{ testFlusher }{testFlusher{w}} },
+			flusher: true,
+		},
+		{
+			name: "hijacker, pusher",
+			w: func(w *testWriter) http.ResponseWriter {
+				return struct {
+					*testWriter
+					http.Hijacker
+					http.Pusher
+				}{w, testHijacker{w}, testPusher{w}}
+			},
+			hijacker: true,
+			pusher:   true,
+		},
+		{
+			name: "all",
+			w: func(w *testWriter) http.ResponseWriter {
+				return struct {
+					*testWriter
+					http.Flusher
+					http.Hijacker
+					http.Pusher
+				}{w, testFlusher{w}, testHijacker{w}, testPusher{w}}
+			},
+			flusher:  true,
+			hijacker: true,
+			pusher:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := &testWriter{ResponseWriter: httptest.NewRecorder()}
+			rw := &responseWriter{ResponseWriter: tt.w(tw)}
+			w := wrapWriter(rw)
+
+			f, isFlusher := w.(http.Flusher)
+			h, isHijacker := w.(http.Hijacker)
+			_, isPusher := w.(http.Pusher)
+			if isFlusher != tt.flusher || isHijacker != tt.hijacker || isPusher != tt.pusher {
+				t.Fatalf("wrapWriter() implements Flusher = %v, Hijacker = %v, Pusher = %v, want %v, %v, %v",
+					isFlusher, isHijacker, isPusher, tt.flusher, tt.hijacker, tt.pusher)
+			}
+			if isFlusher {
+				f.Flush()
+				if !tw.flushed {
+					t.Error("Flush() is not passed to underlying writer")
+				}
+				if rw.Status() != http.StatusOK {
+					t.Errorf("status after Flush() = %d, want %d", rw.Status(), http.StatusOK)
+				}
+			}
+			if isHijacker {
+				if _, _, err := h.Hijack(); err != nil {
+					t.Fatal(err)
+				}
+				if !isFlusher && rw.Status() != http.StatusSwitchingProtocols {
+					t.Errorf("status after Hijack() = %d, want %d", rw.Status(), http.StatusSwitchingProtocols)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for status, want := range map[int]string{200: "2xx", 101: "1xx", 404: "4xx", 599: "5xx", 0: "other", 600: "other"} {
+		if got := StatusClass(status); got != want {
+			t.Errorf("StatusClass(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func BenchmarkHandler(b *testing.B) {
+	h, err := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}), Options{Registry: metrics.NewRegistry()})
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(w, req)
+	}
+}
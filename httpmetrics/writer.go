@@ -0,0 +1,121 @@
+package httpmetrics
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// responseWriter records response status and size
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Unwrap returns underlying http.ResponseWriter (for http.ResponseController)
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns response status (200 if header is not written)
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+type flusher struct{ w *responseWriter }
+
+func (f flusher) Flush() {
+	if f.w.status == 0 {
+		f.w.status = http.StatusOK
+	}
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ w *responseWriter }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && h.w.status == 0 {
+		// connection is upgraded (like websocket), response is written by handler
+		h.w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+type pusher struct{ w *responseWriter }
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// wrapWriter returns http.ResponseWriter, which implements the same optional interfaces (http.Flusher, http.Hijacker, http.Pusher) as w
+func wrapWriter(w *responseWriter) http.ResponseWriter {
+	_, isFlusher := w.ResponseWriter.(http.Flusher)
+	_, isHijacker := w.ResponseWriter.(http.Hijacker)
+	_, isPusher := w.ResponseWriter.(http.Pusher)
+
+	switch {
+	case isFlusher && isHijacker && isPusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, flusher{w}, hijacker{w}, pusher{w}}
+	case isFlusher && isHijacker:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+		}{w, flusher{w}, hijacker{w}}
+	case isFlusher && isPusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Pusher
+		}{w, flusher{w}, pusher{w}}
+	case isHijacker && isPusher:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.Pusher
+		}{w, hijacker{w}, pusher{w}}
+	case isFlusher:
+		return struct {
+			*responseWriter
+			http.Flusher
+		}{w, flusher{w}}
+	case isHijacker:
+		return struct {
+			*responseWriter
+			http.Hijacker
+		}{w, hijacker{w}}
+	case isPusher:
+		return struct {
+			*responseWriter
+			http.Pusher
+		}{w, pusher{w}}
+	default:
+		return w
+	}
+}
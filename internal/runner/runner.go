@@ -0,0 +1,84 @@
+// Package runner runs periodic tasks in background goroutine, shared by collectors Start()/Stop() methods.
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrStarted  = errors.New("already started")
+	ErrInterval = errors.New("interval must be positive")
+)
+
+// Runner runs periodic task in background goroutine. Zero value is ready for use.
+type Runner struct {
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start calls f immediately and then with interval in background goroutine, until Stop() call or ctx cancellation.
+// Start doesn't wait for the first f call. Stopped runner can be started again.
+func (r *Runner) Start(ctx context.Context, interval time.Duration, f func()) error {
+	return r.StartContext(ctx, interval, func(context.Context) { f() })
+}
+
+// StartContext is like Start, but f is called with context, canceled on Stop() call or ctx cancellation.
+func (r *Runner) StartContext(ctx context.Context, interval time.Duration, f func(ctx context.Context)) error {
+	if interval <= 0 {
+		return ErrInterval
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.done != nil {
+		select {
+		case <-r.done:
+			// stopped with context
+		default:
+			return ErrStarted
+		}
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	done := make(chan struct{})
+	r.done = done
+
+	go func() {
+		defer close(done)
+		f(ctx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				f(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops background runs and wait for goroutine exit.
+func (r *Runner) Stop() {
+	r.lock.Lock()
+	if r.done != nil {
+		r.cancel()
+		<-r.done
+		r.cancel = nil
+		r.done = nil
+	}
+	r.lock.Unlock()
+}
+
+// Done returns a channel, closed on background goroutine exit (nil if runner is not started).
+func (r *Runner) Done() <-chan struct{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.done
+}
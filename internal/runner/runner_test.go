@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunner(t *testing.T) {
+	var (
+		r     Runner
+		calls int32
+	)
+	f := func() { atomic.AddInt32(&calls, 1) }
+
+	if err := r.Start(context.Background(), 0, f); err != ErrInterval {
+		t.Errorf("Start() error = %v, want %v", err, ErrInterval)
+	}
+	if r.Done() != nil {
+		t.Errorf("Done() is not nil before Start()")
+	}
+	if err := r.Start(context.Background(), time.Millisecond, f); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Start(context.Background(), time.Millisecond, f); err != ErrStarted {
+		t.Errorf("Start() error = %v, want %v", err, ErrStarted)
+	}
+	for atomic.LoadInt32(&calls) < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	r.Stop()
+	r.Stop()
+
+	// restart after context cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := r.Start(ctx, time.Millisecond, f); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-r.Done()
+	if err := r.Start(context.Background(), time.Millisecond, f); err != nil {
+		t.Fatal(err)
+	}
+	r.Stop()
+}
+
+func TestRunner_StartContext(t *testing.T) {
+	var r Runner
+	ctxs := make(chan context.Context, 1)
+	if err := r.StartContext(context.Background(), time.Hour, func(ctx context.Context) { ctxs <- ctx }); err != nil {
+		t.Fatal(err)
+	}
+	ctx := <-ctxs
+	if ctx.Err() != nil {
+		t.Fatalf("context is canceled after Start(): %v", ctx.Err())
+	}
+	r.Stop()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("context error after Stop() = %v, want %v", ctx.Err(), context.Canceled)
+	}
+}
+
+func TestRunner_StartSlow(t *testing.T) {
+	var r Runner
+	started := make(chan struct{})
+	// first call is in background goroutine, so Start() and Stop() are not blocked by slow f
+	if err := r.StartContext(context.Background(), time.Hour, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	r.Stop()
+}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/msaf1980/go-metrics"
@@ -11,37 +13,58 @@ type Logger interface {
 }
 
 // Log outputs each metric in the given registry periodically using the given logger.
-func Log(r metrics.Registry, freq time.Duration, l Logger, minLock bool) {
-	LogScaled(r, freq, time.Nanosecond, l, minLock)
+// Histograms percentiles are metrics.DefaultPercentiles, if not set.
+func Log(r metrics.Registry, freq time.Duration, l Logger, minLock bool, percentiles ...float64) {
+	LogScaled(r, freq, time.Nanosecond, l, minLock, percentiles...)
 }
 
 // LogOnCue outputs each metric in the given registry on demand through the channel
 // using the given logger
-func LogOnCue(r metrics.Registry, ch chan interface{}, l Logger, minLock bool) {
-	LogScaledOnCue(r, ch, time.Nanosecond, l, minLock)
+func LogOnCue(r metrics.Registry, ch chan interface{}, l Logger, minLock bool, percentiles ...float64) {
+	LogScaledOnCue(r, ch, time.Nanosecond, l, minLock, percentiles...)
 }
 
 // LogScaled outputs each metric in the given registry periodically using the given
 // logger. Print timings in `scale` units (eg time.Millisecond) rather than nanos.
-func LogScaled(r metrics.Registry, freq time.Duration, scale time.Duration, l Logger, minLock bool) {
+func LogScaled(r metrics.Registry, freq time.Duration, scale time.Duration, l Logger, minLock bool, percentiles ...float64) {
 	ch := make(chan interface{})
 	go func(channel chan interface{}) {
 		for range time.Tick(freq) {
 			channel <- struct{}{}
 		}
 	}(ch)
-	LogScaledOnCue(r, ch, scale, l, minLock)
+	LogScaledOnCue(r, ch, scale, l, minLock, percentiles...)
 }
 
 // LogScaledOnCue outputs each metric in the given registry on demand through the channel
 // using the given logger. Print timings in `scale` units (eg time.Millisecond) rather
 // than nanos.
-func LogScaledOnCue(r metrics.Registry, ch chan interface{}, scale time.Duration, l Logger, minLock bool) {
-	// du := float64(scale)
-	// duSuffix := scale.String()[1:]
+func LogScaledOnCue(r metrics.Registry, ch chan interface{}, scale time.Duration, l Logger, minLock bool, percentiles ...float64) {
+	if len(percentiles) == 0 {
+		percentiles = metrics.DefaultPercentiles
+	}
+	names := make([]string, len(percentiles))
+	for i, p := range percentiles {
+		names[i] = metrics.PercentileName(p)
+	}
+	// formatPercentiles formats percentiles values like " median: %12.2f 99%%: %12.2f"
+	formatPercentiles := func(format string, value func(i int) interface{}) string {
+		var sb strings.Builder
+		for i, name := range names {
+			sb.WriteString(" ")
+			sb.WriteString(name)
+			sb.WriteString(": ")
+			fmt.Fprintf(&sb, format, value(i))
+		}
+		return sb.String()
+	}
 
 	for range ch {
 		r.Each(func(name, tags string, tagsMap map[string]string, i interface{}) error {
+			if h, ok := i.(metrics.HistogramInterface); ok {
+				// buckets, stats and quantiles are read once (exp histogram buckets layout may change with scale)
+				i = metrics.SnapshotHistogram(h)
+			}
 			switch metric := i.(type) {
 			case metrics.Counter:
 				l.Printf("counter %s%s count: %9d\n", name, tags, metric.Count())
@@ -70,6 +93,25 @@ func LogScaledOnCue(r metrics.Registry, ch chan interface{}, scale time.Duration
 					}
 					l.Printf("histogram %s%s %s: %9d\n", name, tags, metric.NameTotal(), total)
 				}
+				stats := metric.Stats()
+				l.Printf("histogram %s%s sum: %f min: %f max: %f mean: %f\n", name, tags, stats.Sum, stats.Min, stats.Max, stats.Mean())
+				// estimated from the same snapshot buckets
+				ps := metric.Quantiles(percentiles)
+				l.Printf("histogram %s%s%s\n", name, tags, formatPercentiles("%12.2f", func(i int) interface{} { return ps[i] }))
+			case metrics.HDRHistogram:
+				h := metric.Snapshot()
+				ps := h.Percentiles(percentiles)
+				l.Printf("hdrhistogram %s%s  count: %9d min: %9d max: %9d mean: %12.2f%s\n",
+					name, tags, h.Count(), h.Min(), h.Max(), h.Mean(),
+					formatPercentiles("%9d", func(i int) interface{} { return ps[i] }),
+				)
+			case metrics.DDSketch:
+				s := metric.Snapshot()
+				ps := s.Quantiles(percentiles)
+				l.Printf("ddsketch %s%s  count: %9d min: %12.2f max: %12.2f mean: %12.2f%s\n",
+					name, tags, s.Count(), s.Min(), s.Max(), s.Mean(),
+					formatPercentiles("%12.2f", func(i int) interface{} { return ps[i] }),
+				)
 			case metrics.Rate:
 				v, rate := metric.Values()
 				l.Printf("rate %s%s%s value: %f rate: %f\n", name, metric.Name(), tags, v, rate)
@@ -78,32 +120,6 @@ func LogScaledOnCue(r metrics.Registry, ch chan interface{}, scale time.Duration
 				v, rate := metric.Values()
 				l.Printf("rate %s%s%s value: %f rate: %f\n", name, metric.Name(), tags, v, rate)
 				l.Printf("rate %s%s%s value: %f rate: %f\n", name, metric.RateName(), tags, v, rate)
-				// case metrics.Histogram:
-				// 	h := metric.Snapshot()
-				// 	ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-				// 	l.Printf("histogram %s%s  count: %9d min: %9d max: %9d mean: %9d stddev: %12.2f "+
-				// 		"median: %12.2f 75%%: %12.2f 95%%: %12.2f 99%%: %12.2f 99.9%%: %12.2f\n",
-				// 		name, tags, h.Count(), h.Min(), h.Max(), h.Mean(), h.StdDev(),
-				// 		ps[0], ps[1], ps[2], ps[3], ps[4],
-				// 	)
-				// case metrics.Meter:
-				// 	m := metric.Snapshot()
-				// 	l.Printf("meter %s%s  count: %9d 1-min rate: %12.2f 5-min rate: %12.2f 15-min rate: %12.2f mean rate: %12.2f\n",
-				// 		name, tags, m.Count(), m.Rate1(), m.Rate5(), m.Rate15(), m.RateMean(),
-				// 	)
-				// case metrics.Timer:
-				// 	t := metric.Snapshot()
-				// 	ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-				// 	l.Printf("timer %s%s  count: %9d min: %12.2f%s max: %12.2f%s mean: %12.2f%s stddev: %12.2f%s "+
-				// 		"median: %12.2f%s 75%%: %12.2f%s 95%%: %12.2f%s 99%%: %12.2f%s 99.9%%: %12.2f%s "+
-				// 		"mean rate: %12.2f\n",
-				// 		// " 1-min rate: %12.2f 5-min rate: %12.2 15-min rate: %12.2f\n",
-				// 		name, tags, t.Count(), float64(t.Min())/du, duSuffix, float64(t.Max())/du, duSuffix,
-				// 		t.Mean()/du, duSuffix, t.StdDev()/du, duSuffix,
-				// 		ps[0]/du, duSuffix, ps[1]/du, duSuffix, ps[2]/du, duSuffix, ps[3]/du, duSuffix, ps[4]/du, duSuffix,
-				// 		t.RateMean(),
-				// 		// t.Rate1(), t.Rate5(), t.Rate15(),
-				// 	)
 			}
 			return nil
 		}, minLock)
@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+// NetLifetimeWeights is a connections lifetime histogram (in seconds) weights, must be sorted.
+var NetLifetimeWeights = []time.Duration{
+	time.Second, 10 * time.Second, time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour,
+}
+
+// NetMetrics is a connections metrics, shared by wrapped connections (see WrapListener and WrapConn):
+//
+//	NAME.connections - opened (accepted by listener or wrapped) connections counter
+//	NAME.active - active connections
+//	NAME.read_bytes, NAME.write_bytes - read and written bytes (rates are updated on Capture() calls or periodically after Start())
+//	NAME.lifetime - closed connections lifetime histogram (in seconds)
+//	NAME.errors;op=accept|read|write;kind=timeout|closed|reset|broken_pipe|other - errors counters (registered on first error)
+type NetMetrics struct {
+	// read and written bytes, updated atomically (first for 64-bit alignment)
+	read    int64
+	written int64
+
+	r       Registry
+	name    string
+	tags    map[string]string
+	metrics []collectorMetric // registered metrics
+
+	Connections Counter
+	Active      DownCounter
+	ReadBytes   Rate
+	WriteBytes  Rate
+	Lifetime    DurationHistogram
+
+	errorsLock sync.Mutex
+	errors     map[netErrorKey]Counter
+
+	lock   sync.Mutex
+	runner runner.Runner
+}
+
+// NewNetMetrics constructs and registers connections metrics with name prefix.
+// Returns DuplicateMetric error (and unregister already registered metrics) if metric with the same name exists.
+func NewNetMetrics(r Registry, name string, tags map[string]string) (*NetMetrics, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	lifetime, err := NewDurationHistogram(NetLifetimeWeights, nil, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	m := &NetMetrics{
+		r:           r,
+		name:        name,
+		tags:        tags,
+		Connections: NewCounter(),
+		Active:      NewDownCounter(),
+		ReadBytes:   NewRate(),
+		WriteBytes:  NewRate(),
+		Lifetime:    lifetime,
+		errors:      make(map[netErrorKey]Counter),
+	}
+	m.metrics = []collectorMetric{
+		{name + ".connections", m.Connections},
+		{name + ".active", m.Active},
+		{name + ".read_bytes", m.ReadBytes},
+		{name + ".write_bytes", m.WriteBytes},
+		{name + ".lifetime", m.Lifetime},
+	}
+	if err := registerCollectorMetrics(r, m.metrics, m.tags); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Capture updates read and written bytes rates.
+func (m *NetMetrics) Capture() {
+	m.lock.Lock()
+	t := time.Now().UnixNano()
+	m.ReadBytes.UpdateTs(atomic.LoadInt64(&m.read), t)
+	m.WriteBytes.UpdateTs(atomic.LoadInt64(&m.written), t)
+	m.lock.Unlock()
+}
+
+// Start updates read and written bytes rates with interval in background goroutine, until Stop() call or ctx cancellation.
+// Stopped metrics can be started again.
+func (m *NetMetrics) Start(ctx context.Context, interval time.Duration) error {
+	return m.runner.Start(ctx, interval, m.Capture)
+}
+
+// Stop stops background captures and wait for goroutine exit.
+func (m *NetMetrics) Stop() {
+	m.runner.Stop()
+}
+
+// Unregister stops background captures and unregisters metrics.
+func (m *NetMetrics) Unregister() {
+	m.Stop()
+	unregisterCollectorMetrics(m.r, m.metrics, m.tags)
+	m.errorsLock.Lock()
+	for key := range m.errors {
+		m.r.UnregisterT(m.name+".errors", m.errorTags(key))
+		delete(m.errors, key)
+	}
+	m.errorsLock.Unlock()
+}
+
+type netErrorKey struct {
+	op   string
+	kind string
+}
+
+func (m *NetMetrics) errorTags(key netErrorKey) map[string]string {
+	return MergeTags(map[string]string{"op": key.op, "kind": key.kind}, m.tags)
+}
+
+// error counts operation error (io.EOF is not an error)
+func (m *NetMetrics) error(op string, err error) {
+	if err == io.EOF {
+		return
+	}
+	key := netErrorKey{op: op, kind: NetErrorKind(err)}
+	m.errorsLock.Lock()
+	c, ok := m.errors[key]
+	if !ok {
+		c = GetOrRegisterCounterT(m.name+".errors", m.errorTags(key), m.r)
+		m.errors[key] = c
+	}
+	m.errorsLock.Unlock()
+	c.Add(1)
+}
+
+// NetErrorKind returns network error kind (timeout, closed, reset, broken_pipe or other) for errors tag.
+func NetErrorKind(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, net.ErrClosed), errors.Is(err, io.ErrClosedPipe):
+		return "closed"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "reset"
+	case errors.Is(err, syscall.EPIPE):
+		return "broken_pipe"
+	default:
+		return "other"
+	}
+}
+
+// Listener is a net.Listener, which accepts connections, wrapped with NetMetrics.
+type Listener struct {
+	net.Listener
+	*NetMetrics
+}
+
+// WrapListener constructs and registers connections metrics (see NetMetrics) and wraps accepted connections.
+// Call Start() for periodic bytes rates updates. Close() does not unregister metrics (use Unregister()).
+func WrapListener(l net.Listener, r Registry, name string, tags map[string]string) (*Listener, error) {
+	m, err := NewNetMetrics(r, name, tags)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{Listener: l, NetMetrics: m}, nil
+}
+
+// Accept waits for and returns the next connection, wrapped with metrics.
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		l.NetMetrics.error("accept", err)
+		return nil, err
+	}
+	return WrapConn(conn, l.NetMetrics), nil
+}
+
+// netConn is a net.Conn, wrapped with metrics
+type netConn struct {
+	net.Conn
+	m         *NetMetrics
+	start     time.Time
+	closeOnce sync.Once
+}
+
+// WrapConn returns connection (like client connection), which updates metrics m.
+func WrapConn(conn net.Conn, m *NetMetrics) net.Conn {
+	m.Connections.Add(1)
+	m.Active.Add(1)
+	return &netConn{Conn: conn, m: m, start: time.Now()}
+}
+
+func (c *netConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.m.read, int64(n))
+	if err != nil {
+		c.m.error("read", err)
+	}
+	return n, err
+}
+
+func (c *netConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.m.written, int64(n))
+	if err != nil {
+		c.m.error("write", err)
+	}
+	return n, err
+}
+
+func (c *netConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.m.Active.Sub(1)
+		c.m.Lifetime.UpdateSince(c.start)
+	})
+	return c.Conn.Close()
+}
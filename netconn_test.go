@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWrapListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRegistry()
+	tags := map[string]string{"app": "test"}
+	l, err := WrapListener(ln, r, "tcp.server", tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = WrapListener(ln, r, "tcp.server", tags); err == nil {
+		t.Error("WrapListener() with duplicate metrics must fail")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		buf := make([]byte, 16)
+		n, err := io.ReadFull(conn, buf[:5])
+		if err == nil {
+			_, err = conn.Write(buf[:n])
+		}
+		if err == nil {
+			// client close, io.EOF is not an error
+			_, err = conn.Read(buf)
+			if err == io.EOF {
+				err = nil
+			}
+		}
+		conn.Close()
+		conn.Close()
+		done <- err
+	}()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err = io.ReadFull(client, buf); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	l.Capture()
+	if n := l.Connections.Count(); n != 1 {
+		t.Errorf("connections = %d, want 1", n)
+	}
+	if n := l.Active.Count(); n != 0 {
+		t.Errorf("active = %d, want 0", n)
+	}
+	if n, _ := l.ReadBytes.Values(); n != 5 {
+		t.Errorf("read_bytes = %d, want 5", n)
+	}
+	if n, _ := l.WriteBytes.Values(); n != 5 {
+		t.Errorf("write_bytes = %d, want 5", n)
+	}
+	if n := l.Lifetime.Stats().Count; n != 1 {
+		t.Errorf("lifetime count = %d, want 1", n)
+	}
+	if m := r.GetT("tcp.server.errors", MergeTags(map[string]string{"op": "read", "kind": "other"}, tags)); m != nil {
+		t.Error("io.EOF counted as read error")
+	}
+
+	// accept on closed listener
+	l.Close()
+	if _, err = l.Accept(); err == nil {
+		t.Fatal("Accept() on closed listener must fail")
+	}
+	acceptTags := MergeTags(map[string]string{"op": "accept", "kind": "closed"}, tags)
+	if c, ok := r.GetT("tcp.server.errors", acceptTags).(Counter); !ok || c.Count() != 1 {
+		t.Errorf("tcp.server.errors%s = %v, want 1", JoinTags(acceptTags), r.GetT("tcp.server.errors", acceptTags))
+	}
+
+	if err = l.Start(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	l.Unregister()
+	if m := r.GetT("tcp.server.connections", tags); m != nil {
+		t.Error("tcp.server.connections is registered after Unregister()")
+	}
+	if m := r.GetT("tcp.server.errors", acceptTags); m != nil {
+		t.Errorf("tcp.server.errors%s is registered after Unregister()", JoinTags(acceptTags))
+	}
+}
+
+func TestWrapConnErrors(t *testing.T) {
+	r := NewRegistry()
+	m, err := NewNetMetrics(r, "pipe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := WrapConn(c1, m)
+
+	conn.SetReadDeadline(time.Now().Add(-time.Second))
+	if _, err = conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Read() with expired deadline must fail")
+	}
+	conn.Close()
+	if _, err = conn.Write([]byte("x")); err == nil {
+		t.Fatal("Write() on closed connection must fail")
+	}
+
+	for _, tags := range []map[string]string{
+		{"op": "read", "kind": "timeout"},
+		{"op": "write", "kind": "closed"},
+	} {
+		if c, ok := r.GetT("pipe.errors", tags).(Counter); !ok || c.Count() != 1 {
+			t.Errorf("pipe.errors%s = %v, want 1", JoinTags(tags), r.GetT("pipe.errors", tags))
+		}
+	}
+}
+
+func TestNetErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{os.ErrDeadlineExceeded, "timeout"},
+		{&net.OpError{Op: "read", Err: net.ErrClosed}, "closed"},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, "reset"},
+		{&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}, "broken_pipe"},
+		{errors.New("unknown"), "other"},
+	}
+	for _, tt := range tests {
+		if got := NetErrorKind(tt.err); got != tt.want {
+			t.Errorf("NetErrorKind(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkNetConnWrite(b *testing.B) {
+	m, err := NewNetMetrics(NewRegistry(), "bench", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	c1, c2 := net.Pipe()
+	go io.Copy(io.Discard, c2)
+	conn := WrapConn(c1, m)
+	defer conn.Close()
+	buf := make([]byte, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn.Write(buf)
+	}
+}
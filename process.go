@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+var (
+	ErrProcessNotSupported = errors.New("process metrics are not supported on this platform")
+	ErrProcessFormat       = errors.New("invalid process statistics format")
+
+	ProcessNames struct {
+		CPUUser                string
+		CPUSystem              string
+		RSS                    string
+		VMS                    string
+		OpenFDs                string
+		MaxFDs                 string
+		Threads                string
+		VoluntaryCtxSwitches   string
+		InvoluntaryCtxSwitches string
+		ReadBytes              string
+		WriteBytes             string
+		StartTime              string
+	}
+)
+
+func init() {
+	ProcessNames.CPUUser = "process.cpu.user_seconds"
+	ProcessNames.CPUSystem = "process.cpu.system_seconds"
+	ProcessNames.RSS = "process.memory.rss_bytes"
+	ProcessNames.VMS = "process.memory.vms_bytes"
+	ProcessNames.OpenFDs = "process.fd.open"
+	ProcessNames.MaxFDs = "process.fd.max"
+	ProcessNames.Threads = "process.threads"
+	ProcessNames.VoluntaryCtxSwitches = "process.ctx_switches.voluntary"
+	ProcessNames.InvoluntaryCtxSwitches = "process.ctx_switches.involuntary"
+	ProcessNames.ReadBytes = "process.io.read_bytes"
+	ProcessNames.WriteBytes = "process.io.write_bytes"
+	ProcessNames.StartTime = "process.start_time_seconds"
+}
+
+// ProcessCollectorOptions is a ProcessCollector naming options.
+type ProcessCollectorOptions struct {
+	// Prefix replaces process prefix in default metric names (like process.threads)
+	Prefix string
+	// Tags for tagged metrics registration (not tagged if empty)
+	Tags map[string]string
+}
+
+// ProcessCollector captures current process statistics (from /proc/self on Linux) into metrics, registered in one registry.
+type ProcessCollector struct {
+	r       Registry
+	tags    map[string]string
+	metrics []collectorMetric // registered metrics
+
+	CPUUser                FRate
+	CPUSystem              FRate
+	RSS                    Gauge
+	VMS                    Gauge
+	OpenFDs                Gauge
+	MaxFDs                 Gauge // -1 if unlimited
+	Threads                Gauge
+	VoluntaryCtxSwitches   Rate
+	InvoluntaryCtxSwitches Rate
+	ReadBytes              Rate
+	WriteBytes             Rate
+	StartTime              Gauge
+
+	proc     string // procfs path, /proc by default
+	pid      string // self by default
+	bootTime int64  // system boot time (unix seconds), read once
+	lock     sync.Mutex
+	runner   runner.Runner
+}
+
+// NewProcessCollector constructs and registers process metrics in registry.
+// Returns DuplicateMetric error (and unregister already registered metrics) if metric with the same name exists.
+func NewProcessCollector(r Registry, opts ProcessCollectorOptions) (*ProcessCollector, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	c := &ProcessCollector{
+		r:                      r,
+		tags:                   opts.Tags,
+		CPUUser:                NewFRate(),
+		CPUSystem:              NewFRate(),
+		RSS:                    NewGauge(),
+		VMS:                    NewGauge(),
+		OpenFDs:                NewGauge(),
+		MaxFDs:                 NewGauge(),
+		Threads:                NewGauge(),
+		VoluntaryCtxSwitches:   NewRate(),
+		InvoluntaryCtxSwitches: NewRate(),
+		ReadBytes:              NewRate(),
+		WriteBytes:             NewRate(),
+		StartTime:              NewGauge(),
+		proc:                   "/proc",
+		pid:                    "self",
+	}
+	for _, m := range c.list() {
+		if opts.Prefix != "" {
+			m.name = opts.Prefix + strings.TrimPrefix(m.name, "process")
+		}
+		c.metrics = append(c.metrics, m)
+	}
+	if err := registerCollectorMetrics(r, c.metrics, c.tags); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// list returns metrics with default names (from ProcessNames)
+func (c *ProcessCollector) list() []collectorMetric {
+	return []collectorMetric{
+		{ProcessNames.CPUUser, c.CPUUser},
+		{ProcessNames.CPUSystem, c.CPUSystem},
+		{ProcessNames.RSS, c.RSS},
+		{ProcessNames.VMS, c.VMS},
+		{ProcessNames.OpenFDs, c.OpenFDs},
+		{ProcessNames.MaxFDs, c.MaxFDs},
+		{ProcessNames.Threads, c.Threads},
+		{ProcessNames.VoluntaryCtxSwitches, c.VoluntaryCtxSwitches},
+		{ProcessNames.InvoluntaryCtxSwitches, c.InvoluntaryCtxSwitches},
+		{ProcessNames.ReadBytes, c.ReadBytes},
+		{ProcessNames.WriteBytes, c.WriteBytes},
+		{ProcessNames.StartTime, c.StartTime},
+	}
+}
+
+// Capture new values for the process statistics.
+// Unreadable sources (like /proc/self/io without permissions) are skipped, first error is returned.
+func (c *ProcessCollector) Capture() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.capture(time.Now().UnixNano())
+}
+
+// Start captures the process statistics with interval in background goroutine, until Stop() call or ctx cancellation.
+// Stopped collector can be started again.
+func (c *ProcessCollector) Start(ctx context.Context, interval time.Duration) error {
+	return c.runner.Start(ctx, interval, func() { _ = c.Capture() })
+}
+
+// Stop stops background captures and wait for goroutine exit.
+func (c *ProcessCollector) Stop() {
+	c.runner.Stop()
+}
+
+// Unregister stops collector and unregisters it's metrics.
+func (c *ProcessCollector) Unregister() {
+	c.Stop()
+	unregisterCollectorMetrics(c.r, c.metrics, c.tags)
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// userHZ is a clock ticks per second for /proc/[pid]/stat times, fixed at 100 on all supported architectures
+const userHZ = 100
+
+func (c *ProcessCollector) path(name string) string {
+	return filepath.Join(c.proc, c.pid, name)
+}
+
+func (c *ProcessCollector) capture(t int64) error {
+	var firstErr error
+	for _, f := range []func(t int64) error{
+		c.captureStat, c.captureStatus, c.captureIO, c.captureFDs, c.captureLimits,
+	} {
+		if err := f(t); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// captureStat reads /proc/self/stat (see proc(5))
+func (c *ProcessCollector) captureStat(t int64) error {
+	data, err := os.ReadFile(c.path("stat"))
+	if err != nil {
+		return err
+	}
+	// process name (2nd field) can contains spaces and brackets
+	i := bytes.LastIndexByte(data, ')')
+	if i == -1 {
+		return ErrProcessFormat
+	}
+	// fields from 3rd (state)
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return ErrProcessFormat
+	}
+	var v [6]int64
+	for n, field := range []int{14, 15, 20, 22, 23, 24} {
+		if v[n], err = strconv.ParseInt(fields[field-3], 10, 64); err != nil {
+			return err
+		}
+	}
+	utime, stime, threads, startTime, vsize, rss := v[0], v[1], v[2], v[3], v[4], v[5]
+
+	c.CPUUser.UpdateTs(float64(utime)/userHZ, t)
+	c.CPUSystem.UpdateTs(float64(stime)/userHZ, t)
+	c.Threads.Update(threads)
+	c.VMS.Update(vsize)
+	c.RSS.Update(rss * int64(os.Getpagesize()))
+
+	if c.bootTime == 0 {
+		if c.bootTime, err = c.readBootTime(); err != nil {
+			return err
+		}
+	}
+	c.StartTime.Update(c.bootTime + startTime/userHZ)
+
+	return nil
+}
+
+// readBootTime reads btime from /proc/stat
+func (c *ProcessCollector) readBootTime() (int64, error) {
+	data, err := os.ReadFile(filepath.Join(c.proc, "stat"))
+	if err != nil {
+		return 0, err
+	}
+	if v, ok := procValue(data, "btime"); ok {
+		return v, nil
+	}
+	return 0, ErrProcessFormat
+}
+
+// captureStatus reads context switches from /proc/self/status
+func (c *ProcessCollector) captureStatus(t int64) error {
+	data, err := os.ReadFile(c.path("status"))
+	if err != nil {
+		return err
+	}
+	if v, ok := procValue(data, "voluntary_ctxt_switches:"); ok {
+		c.VoluntaryCtxSwitches.UpdateTs(v, t)
+	}
+	if v, ok := procValue(data, "nonvoluntary_ctxt_switches:"); ok {
+		c.InvoluntaryCtxSwitches.UpdateTs(v, t)
+	}
+	return nil
+}
+
+// captureIO reads storage read/write bytes from /proc/self/io
+func (c *ProcessCollector) captureIO(t int64) error {
+	data, err := os.ReadFile(c.path("io"))
+	if err != nil {
+		return err
+	}
+	if v, ok := procValue(data, "read_bytes:"); ok {
+		c.ReadBytes.UpdateTs(v, t)
+	}
+	if v, ok := procValue(data, "write_bytes:"); ok {
+		c.WriteBytes.UpdateTs(v, t)
+	}
+	return nil
+}
+
+// captureFDs counts /proc/self/fd entries
+func (c *ProcessCollector) captureFDs(int64) error {
+	f, err := os.Open(c.path("fd"))
+	if err != nil {
+		return err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	c.OpenFDs.Update(int64(len(names)))
+	return nil
+}
+
+// captureLimits reads open files soft limit from /proc/self/limits
+func (c *ProcessCollector) captureLimits(int64) error {
+	data, err := os.ReadFile(c.path("limits"))
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+		fields := strings.Fields(line[len("Max open files"):])
+		if len(fields) == 0 {
+			return ErrProcessFormat
+		}
+		if fields[0] == "unlimited" {
+			c.MaxFDs.Update(-1)
+			return nil
+		}
+		v, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		c.MaxFDs.Update(v)
+		return nil
+	}
+	return ErrProcessFormat
+}
+
+// procValue returns first integer value from line, started with key
+func procValue(data []byte, key string) (int64, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, key) {
+			continue
+		}
+		fields := strings.Fields(line[len(key):])
+		if len(fields) == 0 {
+			return 0, false
+		}
+		v, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeProcFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProcessCollector_Fake(t *testing.T) {
+	dir := t.TempDir()
+	writeProcFiles(t, dir, map[string]string{
+		"stat": "cpu  1 2 3 4\nbtime 1700000000\nprocesses 100\n",
+		// name with spaces and brackets
+		"42/stat": "42 (my (app) x) S 1 42 42 0 -1 4194560 1000 0 0 0 250 150 0 0 20 0 7 0 12345 104857600 2560 " +
+			"18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0\n",
+		"42/status": "Name:\tapp\nThreads:\t7\nvoluntary_ctxt_switches:\t300\nnonvoluntary_ctxt_switches:\t20\n",
+		"42/io":     "rchar: 1000\nwchar: 2000\nsyscr: 10\nsyscw: 20\nread_bytes: 4096\nwrite_bytes: 8192\ncancelled_write_bytes: 0\n",
+		"42/limits": "Limit                     Soft Limit           Hard Limit           Units     \n" +
+			"Max cpu time              unlimited            unlimited            seconds   \n" +
+			"Max open files            1024                 524288               files     \n",
+		"42/fd/0": "",
+		"42/fd/1": "",
+		"42/fd/2": "",
+	})
+
+	r := NewRegistry()
+	c, err := NewProcessCollector(r, ProcessCollectorOptions{Prefix: "app.process"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.proc = dir
+	c.pid = "42"
+	if err = c.capture(1e9); err != nil {
+		t.Fatal(err)
+	}
+
+	gauges := map[string]int64{
+		"app.process.memory.rss_bytes":   2560 * int64(os.Getpagesize()),
+		"app.process.memory.vms_bytes":   104857600,
+		"app.process.fd.open":            3,
+		"app.process.fd.max":             1024,
+		"app.process.threads":            7,
+		"app.process.start_time_seconds": 1700000000 + 123,
+	}
+	for name, want := range gauges {
+		if got := r.Get(name).(Gauge).Value(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	rates := map[string]int64{
+		"app.process.ctx_switches.voluntary":   300,
+		"app.process.ctx_switches.involuntary": 20,
+		"app.process.io.read_bytes":            4096,
+		"app.process.io.write_bytes":           8192,
+	}
+	for name, want := range rates {
+		if got, _ := r.Get(name).(Rate).Values(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	fRates := map[string]float64{
+		"app.process.cpu.user_seconds":   2.5,
+		"app.process.cpu.system_seconds": 1.5,
+	}
+	for name, want := range fRates {
+		if got, _ := r.Get(name).(FRate).Values(); got != want {
+			t.Errorf("%s = %f, want %f", name, got, want)
+		}
+	}
+
+	// rates
+	writeProcFiles(t, dir, map[string]string{
+		"42/stat": "42 (my (app) x) S 1 42 42 0 -1 4194560 1000 0 0 0 450 250 0 0 20 0 7 0 12345 104857600 2560 " +
+			"18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0\n",
+		"42/limits": "Max open files            unlimited            unlimited            files     \n",
+	})
+	if err = c.capture(3e9); err != nil {
+		t.Fatal(err)
+	}
+	if _, rate := c.CPUUser.Values(); rate != 1 {
+		t.Errorf("%s rate = %f, want 1", ProcessNames.CPUUser, rate)
+	}
+	if v := c.MaxFDs.Value(); v != -1 {
+		t.Errorf("%s = %d, want -1", ProcessNames.MaxFDs, v)
+	}
+
+	// unreadable sources are skipped
+	if err = os.Remove(filepath.Join(dir, "42", "io")); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.capture(4e9); !os.IsNotExist(err) {
+		t.Errorf("capture() error = %v, want not exist", err)
+	}
+	if v := c.OpenFDs.Value(); v != 3 {
+		t.Errorf("%s = %d, want 3", ProcessNames.OpenFDs, v)
+	}
+}
+
+func TestProcessCollector(t *testing.T) {
+	r := NewRegistry()
+	c, err := NewProcessCollector(r, ProcessCollectorOptions{Tags: map[string]string{"app": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewProcessCollector(r, ProcessCollectorOptions{Tags: map[string]string{"app": "test"}}); err == nil {
+		t.Error("NewProcessCollector() with duplicate metrics must fail")
+	}
+	if err = c.Capture(); err != nil && !os.IsPermission(err) {
+		t.Fatal(err)
+	}
+	if v := c.Threads.Value(); v < 1 {
+		t.Errorf("%s = %d, want at least 1", ProcessNames.Threads, v)
+	}
+	if v := c.RSS.Value(); v <= 0 {
+		t.Errorf("%s = %d, want > 0", ProcessNames.RSS, v)
+	}
+	if v := c.OpenFDs.Value(); v < 1 || (c.MaxFDs.Value() != -1 && v > c.MaxFDs.Value()) {
+		t.Errorf("%s = %d, want [1, %d]", ProcessNames.OpenFDs, v, c.MaxFDs.Value())
+	}
+	if v := c.StartTime.Value(); v > time.Now().Unix() || v < time.Now().Add(-24*time.Hour).Unix() {
+		t.Errorf("%s = %d, want process start time", ProcessNames.StartTime, v)
+	}
+
+	c.Unregister()
+	if m := r.GetT(ProcessNames.Threads, map[string]string{"app": "test"}); m != nil {
+		t.Errorf("%s is registered after Unregister()", ProcessNames.Threads)
+	}
+}
+
+func BenchmarkProcessCollector(b *testing.B) {
+	c, err := NewProcessCollector(NewRegistry(), ProcessCollectorOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.Capture()
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package metrics
+
+func (c *ProcessCollector) capture(t int64) error {
+	return ErrProcessNotSupported
+}
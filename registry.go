@@ -141,6 +141,9 @@ func (r *StandardRegistry) GetT(name string, tagsMap map[string]string) interfac
 	r.mutex.RLock()
 	metric, _ := r.metricsT[NameTagged{Name: name, Tags: tags}]
 	r.mutex.RUnlock()
+	if metric == nil {
+		return nil
+	}
 	return metric.I
 }
 
@@ -253,11 +256,24 @@ func (r *StandardRegistry) RunHealthchecks() {
 	}
 }
 
-// GetAll metrics in the Registry
+// GetAll metrics in the Registry (histograms with DefaultPercentiles)
 func (r *StandardRegistry) GetAll() map[string]map[string]interface{} {
+	return r.GetAllPercentiles(DefaultPercentiles)
+}
+
+// GetAllPercentiles metrics in the Registry, histograms percentiles are named like "median", "99%" or "99.9%" (see PercentileName)
+func (r *StandardRegistry) GetAllPercentiles(percentiles []float64) map[string]map[string]interface{} {
+	names := make([]string, len(percentiles))
+	for i, p := range percentiles {
+		names[i] = PercentileName(p)
+	}
 	data := make(map[string]map[string]interface{})
 	r.Each(func(name, tags string, tagsMap map[string]string, i interface{}) error {
 		values := make(map[string]interface{})
+		if h, ok := i.(HistogramInterface); ok {
+			// buckets, stats and quantiles are read once (exp histogram buckets layout may change with scale)
+			i = SnapshotHistogram(h)
+		}
 		switch metric := i.(type) {
 		case Counter:
 			values["count"] = metric.Count()
@@ -288,6 +304,33 @@ func (r *StandardRegistry) GetAll() map[string]map[string]interface{} {
 				}
 				values[metric.NameTotal()] = vals[0]
 			}
+			stats := metric.Stats()
+			values[".sum"] = stats.Sum
+			values[".min"] = stats.Min
+			values[".max"] = stats.Max
+			values[".mean"] = stats.Mean()
+			// estimated from the same snapshot buckets
+			for i, v := range metric.Quantiles(percentiles) {
+				values["."+names[i]] = v
+			}
+		case HDRHistogram:
+			h := metric.Snapshot()
+			values["count"] = h.Count()
+			values["min"] = h.Min()
+			values["max"] = h.Max()
+			values["mean"] = h.Mean()
+			for i, v := range h.Percentiles(percentiles) {
+				values[names[i]] = v
+			}
+		case DDSketch:
+			s := metric.Snapshot()
+			values["count"] = s.Count()
+			values["min"] = s.Min()
+			values["max"] = s.Max()
+			values["mean"] = s.Mean()
+			for i, v := range s.Quantiles(percentiles) {
+				values[names[i]] = v
+			}
 		case Rate:
 			v, rate := metric.Values()
 			values["value"] = v
@@ -296,43 +339,6 @@ func (r *StandardRegistry) GetAll() map[string]map[string]interface{} {
 			v, rate := metric.Values()
 			values["value"] = v
 			values["rate"] = rate
-			// case Histogram:
-			// 	h := metric.Snapshot()
-			// 	ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-			// 	values["count"] = h.Count()
-			// 	values["min"] = h.Min()
-			// 	values["max"] = h.Max()
-			// 	values["mean"] = h.Mean()
-			// 	values["stddev"] = h.StdDev()
-			// 	values["median"] = ps[0]
-			// 	values["75%"] = ps[1]
-			// 	values["95%"] = ps[2]
-			// 	values["99%"] = ps[3]
-			// 	values["99.9%"] = ps[4]
-			// case Meter:
-			// 	m := metric.Snapshot()
-			// 	values["count"] = m.Count()
-			// 	values["1m.rate"] = m.Rate1()
-			// 	values["5m.rate"] = m.Rate5()
-			// 	values["15m.rate"] = m.Rate15()
-			// 	values["mean.rate"] = m.RateMean()
-			// case Timer:
-			// 	t := metric.Snapshot()
-			// 	ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-			// 	values["count"] = t.Count()
-			// 	values["min"] = t.Min()
-			// 	values["max"] = t.Max()
-			// 	values["mean"] = t.Mean()
-			// 	values["stddev"] = t.StdDev()
-			// 	values["median"] = ps[0]
-			// 	values["75%"] = ps[1]
-			// 	values["95%"] = ps[2]
-			// 	values["99%"] = ps[3]
-			// 	values["99.9%"] = ps[4]
-			// 	values["1m.rate"] = t.Rate1()
-			// 	values["5m.rate"] = t.Rate5()
-			// 	values["15m.rate"] = t.Rate15()
-			// 	values["mean.rate"] = t.RateMean()
 		}
 		data[name+tags] = values
 		return nil
@@ -398,7 +404,7 @@ func (r *StandardRegistry) register(name string, i interface{}) error {
 		updater.Register(s)
 	}
 	switch i.(type) {
-	case Counter, DownCounter, Gauge, UGauge, FGauge, Healthcheck, HistogramInterface, Rate, FRate:
+	case Counter, DownCounter, Gauge, UGauge, FGauge, Healthcheck, HistogramInterface, HDRHistogram, DDSketch, Rate, FRate:
 		// , Histogram, Meter, Timer:
 		r.metrics[name] = i
 	default:
@@ -415,7 +421,7 @@ func (r *StandardRegistry) registerT(ntags NameTagged, v *ValTagged) error {
 		updater.Register(s)
 	}
 	switch v.I.(type) {
-	case Counter, DownCounter, Gauge, UGauge, FGauge, Healthcheck, HistogramInterface, Rate, FRate:
+	case Counter, DownCounter, Gauge, UGauge, FGauge, Healthcheck, HistogramInterface, HDRHistogram, DDSketch, Rate, FRate:
 		// , Histogram, Meter, Timer:
 		r.metricsT[ntags] = v
 	default:
@@ -29,63 +29,79 @@ var (
 			NextGC        string
 			NumGC         string
 			GCCPUFraction string
-			// PauseNs       Histogram
-			PauseTotalNs string
-			StackInUse   string
-			StackSys     string
-			Sys          string
-			TotalAlloc   string
+			PauseNs       string
+			PauseTotalNs  string
+			SinceLastGCNs string
+			StackInUse    string
+			StackSys      string
+			Sys           string
+			TotalAlloc    string
 		}
 		NumCgoCall   string
 		NumGoroutine string
 		NumThread    string
+		Goroutines   string // goroutines census (see GoroutineCollector)
 	}
 	memStats       runtime.MemStats
-	runtimeMetrics struct {
-		MemStats struct {
-			Alloc       Gauge
-			BuckHashSys Gauge
-			// DebugGC       Gauge
-			// EnableGC      Gauge
-			Frees         Rate
-			HeapAlloc     Gauge
-			HeapIdle      Gauge
-			HeapInUse     Gauge
-			HeapObjects   Gauge
-			HeapReleased  Gauge
-			HeapSys       Gauge
-			LastGC        Gauge
-			Lookups       Rate
-			Mallocs       Rate
-			MCacheInUse   Gauge
-			MCacheSys     Gauge
-			MSpanInUse    Gauge
-			MSpanSys      Gauge
-			NextGC        Gauge
-			NumGC         Rate
-			GCCPUFraction FGauge
-			// PauseNs       Histogram
-			PauseTotalNs Gauge
-			StackInUse   Gauge
-			StackSys     Gauge
-			Sys          Gauge
-			TotalAlloc   Gauge
-		}
-		NumCgoCall   Rate
-		NumGoroutine Gauge
-		NumThread    Gauge
-		// ReadMemStats Timer
-	}
+	runtimeMetrics runtimeMemStatsMetrics
 	// frees       uint64
 	// lookups     uint64
 	// mallocs     uint64
 	// numGC       uint32
 	// numCgoCalls int64
 
+	// RuntimeGCPauseWeights is a GC pauses histogram (runtime.mem_stats.pause_ns) weights, must be sorted.
+	RuntimeGCPauseWeights = []time.Duration{
+		10 * time.Microsecond, 25 * time.Microsecond, 50 * time.Microsecond, 100 * time.Microsecond,
+		250 * time.Microsecond, 500 * time.Microsecond, time.Millisecond, 2500 * time.Microsecond,
+		5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond,
+	}
+
 	threadCreateProfile        = pprof.Lookup("threadcreate")
 	registerRuntimeMetricsOnce = sync.Once{}
+	registerRuntimeMetricsErr  error // RegisterRuntimeMemStats result
 )
 
+// runtimeMemStatsMetrics is a metrics for the Go runtime statistics exported in runtime and runtime.MemStats
+type runtimeMemStatsMetrics struct {
+	MemStats struct {
+		Alloc       Gauge
+		BuckHashSys Gauge
+		// DebugGC       Gauge
+		// EnableGC      Gauge
+		Frees         Rate
+		HeapAlloc     Gauge
+		HeapIdle      Gauge
+		HeapInUse     Gauge
+		HeapObjects   Gauge
+		HeapReleased  Gauge
+		HeapSys       Gauge
+		LastGC        Gauge
+		Lookups       Rate
+		Mallocs       Rate
+		MCacheInUse   Gauge
+		MCacheSys     Gauge
+		MSpanInUse    Gauge
+		MSpanSys      Gauge
+		NextGC        Gauge
+		NumGC         Rate
+		GCCPUFraction FGauge
+		PauseNs       DurationHistogram
+		PauseTotalNs  Gauge
+		SinceLastGCNs Gauge
+		StackInUse    Gauge
+		StackSys      Gauge
+		Sys           Gauge
+		TotalAlloc    Gauge
+	}
+	NumCgoCall   Rate
+	NumGoroutine Gauge
+	NumThread    Gauge
+	// ReadMemStats Timer
+
+	numGC uint32 // NumGC at last capture, for PauseNs circular buffer walk
+}
+
 func init() {
 	RuntimeNames.MemStats.Alloc = "runtime.mem_stats.alloc_bytes"
 	RuntimeNames.MemStats.BuckHashSys = "runtime.mem_stats.buck_hash_sys_bytes"
@@ -106,8 +122,9 @@ func init() {
 	RuntimeNames.MemStats.NextGC = "runtime.mem_stats.next_gc"
 	RuntimeNames.MemStats.NumGC = "runtime.mem_stats.num_gc"
 	RuntimeNames.MemStats.GCCPUFraction = "runtime.mem_stats.gcccpu_fraction"
-	// RuntimeNames.MemStats.PauseNs = r.Register("runtime.mem_stats.pause_ns"
+	RuntimeNames.MemStats.PauseNs = "runtime.mem_stats.pause_ns"
 	RuntimeNames.MemStats.PauseTotalNs = "runtime.mem_stats.pause_total_ns"
+	RuntimeNames.MemStats.SinceLastGCNs = "runtime.mem_stats.since_last_gc_ns"
 	RuntimeNames.MemStats.StackInUse = "runtime.mem_stats.stack_in_use_bytes"
 	RuntimeNames.MemStats.StackSys = "runtime.mem_stats.stack_sys_bytes"
 	RuntimeNames.MemStats.Sys = "runtime.mem_stats.sys"
@@ -116,6 +133,7 @@ func init() {
 	RuntimeNames.NumCgoCall = "runtime.num_cgo_call"
 	RuntimeNames.NumGoroutine = "runtime.num_goroutine"
 	RuntimeNames.NumThread = "runtime.num_thread"
+	RuntimeNames.Goroutines = "runtime.goroutines"
 }
 
 // Capture new values for the Go runtime statistics exported in
@@ -139,148 +157,180 @@ func CaptureRuntimeMemStatsOnce() {
 	runtime.ReadMemStats(&memStats) // This takes 50-200us.
 	// runtimeMetrics.ReadMemStats.UpdateSince(t)
 
-	runtimeMetrics.MemStats.Alloc.Update(int64(memStats.Alloc))
-	runtimeMetrics.MemStats.BuckHashSys.Update(int64(memStats.BuckHashSys))
-	// if memStats.DebugGC {
-	// 	runtimeMetrics.MemStats.DebugGC.Update(1)
+	runtimeMetrics.capture(&memStats, t)
+}
+
+// capture updates metrics from ms, read at t (in nanoseconds)
+func (m *runtimeMemStatsMetrics) capture(ms *runtime.MemStats, t int64) {
+	m.MemStats.Alloc.Update(int64(ms.Alloc))
+	m.MemStats.BuckHashSys.Update(int64(ms.BuckHashSys))
+	// if ms.DebugGC {
+	// 	m.MemStats.DebugGC.Update(1)
 	// } else {
-	// 	runtimeMetrics.MemStats.DebugGC.Update(0)
+	// 	m.MemStats.DebugGC.Update(0)
 	// }
-	// if memStats.EnableGC {
-	// 	runtimeMetrics.MemStats.EnableGC.Update(1)
+	// if ms.EnableGC {
+	// 	m.MemStats.EnableGC.Update(1)
 	// } else {
-	// 	runtimeMetrics.MemStats.EnableGC.Update(0)
+	// 	m.MemStats.EnableGC.Update(0)
 	// }
 
-	runtimeMetrics.MemStats.Frees.UpdateTs(int64(memStats.Frees), t)
-	runtimeMetrics.MemStats.HeapAlloc.Update(int64(memStats.HeapAlloc))
-	runtimeMetrics.MemStats.HeapIdle.Update(int64(memStats.HeapIdle))
-	runtimeMetrics.MemStats.HeapInUse.Update(int64(memStats.HeapInuse))
-	runtimeMetrics.MemStats.HeapObjects.Update(int64(memStats.HeapObjects))
-	runtimeMetrics.MemStats.HeapReleased.Update(int64(memStats.HeapReleased))
-	runtimeMetrics.MemStats.HeapSys.Update(int64(memStats.HeapSys))
-	runtimeMetrics.MemStats.LastGC.Update(int64(memStats.LastGC))
-	runtimeMetrics.MemStats.Lookups.UpdateTs(int64(memStats.Lookups), t)
-	runtimeMetrics.MemStats.Mallocs.UpdateTs(int64(memStats.Mallocs), t)
-	runtimeMetrics.MemStats.MCacheInUse.Update(int64(memStats.MCacheInuse))
-	runtimeMetrics.MemStats.MCacheSys.Update(int64(memStats.MCacheSys))
-	runtimeMetrics.MemStats.MSpanInUse.Update(int64(memStats.MSpanInuse))
-	runtimeMetrics.MemStats.MSpanSys.Update(int64(memStats.MSpanSys))
-	runtimeMetrics.MemStats.NextGC.Update(int64(memStats.NextGC))
-	runtimeMetrics.MemStats.NumGC.UpdateTs(int64(memStats.NumGC), t)
-	runtimeMetrics.MemStats.GCCPUFraction.Update(gcCPUFraction(&memStats))
+	m.MemStats.Frees.UpdateTs(int64(ms.Frees), t)
+	m.MemStats.HeapAlloc.Update(int64(ms.HeapAlloc))
+	m.MemStats.HeapIdle.Update(int64(ms.HeapIdle))
+	m.MemStats.HeapInUse.Update(int64(ms.HeapInuse))
+	m.MemStats.HeapObjects.Update(int64(ms.HeapObjects))
+	m.MemStats.HeapReleased.Update(int64(ms.HeapReleased))
+	m.MemStats.HeapSys.Update(int64(ms.HeapSys))
+	m.MemStats.LastGC.Update(int64(ms.LastGC))
+	m.MemStats.Lookups.UpdateTs(int64(ms.Lookups), t)
+	m.MemStats.Mallocs.UpdateTs(int64(ms.Mallocs), t)
+	m.MemStats.MCacheInUse.Update(int64(ms.MCacheInuse))
+	m.MemStats.MCacheSys.Update(int64(ms.MCacheSys))
+	m.MemStats.MSpanInUse.Update(int64(ms.MSpanInuse))
+	m.MemStats.MSpanSys.Update(int64(ms.MSpanSys))
+	m.MemStats.NextGC.Update(int64(ms.NextGC))
+	m.MemStats.NumGC.UpdateTs(int64(ms.NumGC), t)
+	m.MemStats.GCCPUFraction.Update(gcCPUFraction(ms))
 
-	// <https://code.google.com/p/go/source/browse/src/pkg/runtime/mgc0.c>
-	// i := numGC % uint32(len(memStats.PauseNs))
-	// ii := memStats.NumGC % uint32(len(memStats.PauseNs))
-	// if memStats.NumGC-numGC >= uint32(len(memStats.PauseNs)) {
-	// 	for i = 0; i < uint32(len(memStats.PauseNs)); i++ {
-	// 		runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[i]))
-	// 	}
-	// } else {
-	// 	if i > ii {
-	// 		for ; i < uint32(len(memStats.PauseNs)); i++ {
-	// 			runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[i]))
-	// 		}
-	// 		i = 0
-	// 	}
-	// 	for ; i < ii; i++ {
-	// 		runtimeMetrics.MemStats.PauseNs.Update(int64(memStats.PauseNs[i]))
-	// 	}
-	// }
-	// frees = memStats.Frees
-	// lookups = memStats.Lookups
-	// mallocs = memStats.Mallocs
-	// numGC = memStats.NumGC
+	m.capturePauses(ms, t)
+
+	m.MemStats.PauseTotalNs.Update(int64(ms.PauseTotalNs))
+	m.MemStats.StackInUse.Update(int64(ms.StackInuse))
+	m.MemStats.StackSys.Update(int64(ms.StackSys))
+	m.MemStats.Sys.Update(int64(ms.Sys))
+	m.MemStats.TotalAlloc.Update(int64(ms.TotalAlloc))
 
-	runtimeMetrics.MemStats.PauseTotalNs.Update(int64(memStats.PauseTotalNs))
-	runtimeMetrics.MemStats.StackInUse.Update(int64(memStats.StackInuse))
-	runtimeMetrics.MemStats.StackSys.Update(int64(memStats.StackSys))
-	runtimeMetrics.MemStats.Sys.Update(int64(memStats.Sys))
-	runtimeMetrics.MemStats.TotalAlloc.Update(int64(memStats.TotalAlloc))
+	m.NumCgoCall.UpdateTs(int64(numCgoCall()), t)
 
-	runtimeMetrics.NumCgoCall.UpdateTs(int64(numCgoCall()), t)
+	m.NumGoroutine.Update(int64(runtime.NumGoroutine()))
 
-	runtimeMetrics.NumGoroutine.Update(int64(runtime.NumGoroutine()))
+	m.NumThread.Update(int64(threadCreateProfile.Count()))
+}
+
+// capturePauses walks PauseNs circular buffer from NumGC at last capture.
+// PauseNs holds only last 256 pauses, so older pauses are lost if more GCs happens between captures.
+func (m *runtimeMemStatsMetrics) capturePauses(ms *runtime.MemStats, t int64) {
+	n := ms.NumGC - m.numGC
+	if n > uint32(len(ms.PauseNs)) {
+		n = uint32(len(ms.PauseNs))
+	}
+	// GC with number i (from 0) stores pause at PauseNs[i % 256]
+	for i := ms.NumGC - n; i != ms.NumGC; i++ {
+		m.MemStats.PauseNs.Observe(time.Duration(ms.PauseNs[i%uint32(len(ms.PauseNs))]))
+	}
+	m.numGC = ms.NumGC
 
-	runtimeMetrics.NumThread.Update(int64(threadCreateProfile.Count()))
+	if ms.NumGC > 0 {
+		last := ms.PauseEnd[(ms.NumGC+uint32(len(ms.PauseEnd))-1)%uint32(len(ms.PauseEnd))]
+		m.MemStats.SinceLastGCNs.Update(t - int64(last))
+	}
 }
 
 // Register runtimeMetrics for the Go runtime statistics exported in runtime and
 // specifically runtime.MemStats.  The runtimeMetrics are named by their
 // fully-qualified Go symbols, i.e. runtime.MemStats.Alloc.
-func RegisterRuntimeMemStats(r Registry) {
+//
+// Metrics are registered only once (in first registry), use NewRuntimeCollector for
+// several registries, tagged metrics or stoppable captures.
+// Returns error if metrics can't be created (like unsorted RuntimeGCPauseWeights) or registered,
+// the same error is returned on next calls. Don't call CaptureRuntimeMemStats on error.
+func RegisterRuntimeMemStats(r Registry) error {
 	registerRuntimeMetricsOnce.Do(func() {
 		if nil == r {
 			r = DefaultRegistry
 		}
 
-		runtimeMetrics.MemStats.Alloc = NewGauge()
-		runtimeMetrics.MemStats.BuckHashSys = NewGauge()
-		// runtimeMetrics.MemStats.DebugGC = NewGauge()
-		// runtimeMetrics.MemStats.EnableGC = NewGauge()
-		// runtimeMetrics.MemStats.Frees = NewDiffer(int64(memStats.Frees))
-		runtimeMetrics.MemStats.Frees = NewRate()
-		runtimeMetrics.MemStats.HeapAlloc = NewGauge()
-		runtimeMetrics.MemStats.HeapIdle = NewGauge()
-		runtimeMetrics.MemStats.HeapInUse = NewGauge()
-		runtimeMetrics.MemStats.HeapObjects = NewGauge()
-		runtimeMetrics.MemStats.HeapReleased = NewGauge()
-		runtimeMetrics.MemStats.HeapSys = NewGauge()
-		runtimeMetrics.MemStats.LastGC = NewGauge()
-		// runtimeMetrics.MemStats.Lookups = NewDiffer(int64(memStats.Lookups))
-		runtimeMetrics.MemStats.Lookups = NewRate()
-		// runtimeMetrics.MemStats.Mallocs = NewDiffer(int64(memStats.Mallocs))
-		runtimeMetrics.MemStats.Mallocs = NewRate()
-		runtimeMetrics.MemStats.MCacheInUse = NewGauge()
-		runtimeMetrics.MemStats.MCacheSys = NewGauge()
-		runtimeMetrics.MemStats.MSpanInUse = NewGauge()
-		runtimeMetrics.MemStats.MSpanSys = NewGauge()
-		runtimeMetrics.MemStats.NextGC = NewGauge()
-		// runtimeMetrics.MemStats.NumGC = NewDiffer(int64(memStats.NextGC))
-		runtimeMetrics.MemStats.NumGC = NewRate()
-		runtimeMetrics.MemStats.GCCPUFraction = NewFGauge()
-		// runtimeMetrics.MemStats.PauseNs = NewHistogram(NewExpDecaySample(1028, 0.015))
-		runtimeMetrics.MemStats.PauseTotalNs = NewGauge()
-		runtimeMetrics.MemStats.StackInUse = NewGauge()
-		runtimeMetrics.MemStats.StackSys = NewGauge()
-		runtimeMetrics.MemStats.Sys = NewGauge()
-		runtimeMetrics.MemStats.TotalAlloc = NewGauge()
-		// runtimeMetrics.NumCgoCall = NewDiffer(numCgoCall())
-		runtimeMetrics.NumCgoCall = NewRate()
-		runtimeMetrics.NumGoroutine = NewGauge()
-		runtimeMetrics.NumThread = NewGauge()
-		// runtimeMetrics.ReadMemStats = NewTimer()
-		r.Register(RuntimeNames.MemStats.Alloc, runtimeMetrics.MemStats.Alloc)
-		r.Register(RuntimeNames.MemStats.BuckHashSys, runtimeMetrics.MemStats.BuckHashSys)
-		// r.Register("runtime.mem_stats.DebugGC", runtimeMetrics.MemStats.DebugGC)
-		// r.Register("runtime.mem_stats.EnableGC", runtimeMetrics.MemStats.EnableGC)
-		r.Register(RuntimeNames.MemStats.Frees, runtimeMetrics.MemStats.Frees)
-		r.Register(RuntimeNames.MemStats.HeapAlloc, runtimeMetrics.MemStats.HeapAlloc)
-		r.Register(RuntimeNames.MemStats.HeapIdle, runtimeMetrics.MemStats.HeapIdle)
-		r.Register(RuntimeNames.MemStats.HeapInUse, runtimeMetrics.MemStats.HeapInUse)
-		r.Register(RuntimeNames.MemStats.HeapObjects, runtimeMetrics.MemStats.HeapObjects)
-		r.Register(RuntimeNames.MemStats.HeapReleased, runtimeMetrics.MemStats.HeapReleased)
-		r.Register(RuntimeNames.MemStats.HeapSys, runtimeMetrics.MemStats.HeapSys)
-		r.Register(RuntimeNames.MemStats.LastGC, runtimeMetrics.MemStats.LastGC)
-		r.Register(RuntimeNames.MemStats.Lookups, runtimeMetrics.MemStats.Lookups)
-		r.Register(RuntimeNames.MemStats.Mallocs, runtimeMetrics.MemStats.Mallocs)
-		r.Register(RuntimeNames.MemStats.MCacheInUse, runtimeMetrics.MemStats.MCacheInUse)
-		r.Register(RuntimeNames.MemStats.MCacheSys, runtimeMetrics.MemStats.MCacheSys)
-		r.Register(RuntimeNames.MemStats.MCacheInUse, runtimeMetrics.MemStats.MSpanInUse)
-		r.Register(RuntimeNames.MemStats.MCacheSys, runtimeMetrics.MemStats.MSpanSys)
-		r.Register(RuntimeNames.MemStats.NextGC, runtimeMetrics.MemStats.NextGC)
-		r.Register(RuntimeNames.MemStats.NumGC, runtimeMetrics.MemStats.NumGC)
-		r.Register(RuntimeNames.MemStats.GCCPUFraction, runtimeMetrics.MemStats.GCCPUFraction)
-		// r.Register("runtime.mem_stats.pause_ns", runtimeMetrics.MemStats.PauseNs)
-		r.Register(RuntimeNames.MemStats.PauseTotalNs, runtimeMetrics.MemStats.PauseTotalNs)
-		r.Register(RuntimeNames.MemStats.StackInUse, runtimeMetrics.MemStats.StackInUse)
-		r.Register(RuntimeNames.MemStats.StackSys, runtimeMetrics.MemStats.StackSys)
-		r.Register(RuntimeNames.MemStats.Sys, runtimeMetrics.MemStats.Sys)
-		r.Register(RuntimeNames.MemStats.TotalAlloc, runtimeMetrics.MemStats.TotalAlloc)
-		r.Register(RuntimeNames.NumCgoCall, runtimeMetrics.NumCgoCall)
-		r.Register(RuntimeNames.NumGoroutine, runtimeMetrics.NumGoroutine)
-		r.Register(RuntimeNames.NumThread, runtimeMetrics.NumThread)
-		// r.Register("runtime.read_mem_stats", runtimeMetrics.ReadMemStats)
+		if registerRuntimeMetricsErr = runtimeMetrics.init(); registerRuntimeMetricsErr != nil {
+			return
+		}
+		for _, m := range runtimeMetrics.list() {
+			if err := r.Register(m.name, m.metric); err != nil && registerRuntimeMetricsErr == nil {
+				registerRuntimeMetricsErr = err
+			}
+		}
 	})
+	return registerRuntimeMetricsErr
+}
+
+// init creates metrics
+func (m *runtimeMemStatsMetrics) init() (err error) {
+	m.MemStats.Alloc = NewGauge()
+	m.MemStats.BuckHashSys = NewGauge()
+	// m.MemStats.DebugGC = NewGauge()
+	// m.MemStats.EnableGC = NewGauge()
+	// m.MemStats.Frees = NewDiffer(int64(memStats.Frees))
+	m.MemStats.Frees = NewRate()
+	m.MemStats.HeapAlloc = NewGauge()
+	m.MemStats.HeapIdle = NewGauge()
+	m.MemStats.HeapInUse = NewGauge()
+	m.MemStats.HeapObjects = NewGauge()
+	m.MemStats.HeapReleased = NewGauge()
+	m.MemStats.HeapSys = NewGauge()
+	m.MemStats.LastGC = NewGauge()
+	// m.MemStats.Lookups = NewDiffer(int64(memStats.Lookups))
+	m.MemStats.Lookups = NewRate()
+	// m.MemStats.Mallocs = NewDiffer(int64(memStats.Mallocs))
+	m.MemStats.Mallocs = NewRate()
+	m.MemStats.MCacheInUse = NewGauge()
+	m.MemStats.MCacheSys = NewGauge()
+	m.MemStats.MSpanInUse = NewGauge()
+	m.MemStats.MSpanSys = NewGauge()
+	m.MemStats.NextGC = NewGauge()
+	// m.MemStats.NumGC = NewDiffer(int64(memStats.NextGC))
+	m.MemStats.NumGC = NewRate()
+	m.MemStats.GCCPUFraction = NewFGauge()
+	if m.MemStats.PauseNs, err = NewDurationHistogram(RuntimeGCPauseWeights, nil, time.Nanosecond); err != nil {
+		return err
+	}
+	m.MemStats.PauseTotalNs = NewGauge()
+	m.MemStats.SinceLastGCNs = NewGauge()
+	m.MemStats.StackInUse = NewGauge()
+	m.MemStats.StackSys = NewGauge()
+	m.MemStats.Sys = NewGauge()
+	m.MemStats.TotalAlloc = NewGauge()
+	// m.NumCgoCall = NewDiffer(numCgoCall())
+	m.NumCgoCall = NewRate()
+	m.NumGoroutine = NewGauge()
+	m.NumThread = NewGauge()
+	// m.ReadMemStats = NewTimer()
+	return nil
+}
+
+// list returns metrics with default names (from RuntimeNames)
+func (m *runtimeMemStatsMetrics) list() []collectorMetric {
+	return []collectorMetric{
+		{RuntimeNames.MemStats.Alloc, m.MemStats.Alloc},
+		{RuntimeNames.MemStats.BuckHashSys, m.MemStats.BuckHashSys},
+		// {"runtime.mem_stats.DebugGC", m.MemStats.DebugGC},
+		// {"runtime.mem_stats.EnableGC", m.MemStats.EnableGC},
+		{RuntimeNames.MemStats.Frees, m.MemStats.Frees},
+		{RuntimeNames.MemStats.HeapAlloc, m.MemStats.HeapAlloc},
+		{RuntimeNames.MemStats.HeapIdle, m.MemStats.HeapIdle},
+		{RuntimeNames.MemStats.HeapInUse, m.MemStats.HeapInUse},
+		{RuntimeNames.MemStats.HeapObjects, m.MemStats.HeapObjects},
+		{RuntimeNames.MemStats.HeapReleased, m.MemStats.HeapReleased},
+		{RuntimeNames.MemStats.HeapSys, m.MemStats.HeapSys},
+		{RuntimeNames.MemStats.LastGC, m.MemStats.LastGC},
+		{RuntimeNames.MemStats.Lookups, m.MemStats.Lookups},
+		{RuntimeNames.MemStats.Mallocs, m.MemStats.Mallocs},
+		{RuntimeNames.MemStats.MCacheInUse, m.MemStats.MCacheInUse},
+		{RuntimeNames.MemStats.MCacheSys, m.MemStats.MCacheSys},
+		{RuntimeNames.MemStats.MSpanInuse, m.MemStats.MSpanInUse},
+		{RuntimeNames.MemStats.MSpanSys, m.MemStats.MSpanSys},
+		{RuntimeNames.MemStats.NextGC, m.MemStats.NextGC},
+		{RuntimeNames.MemStats.NumGC, m.MemStats.NumGC},
+		{RuntimeNames.MemStats.GCCPUFraction, m.MemStats.GCCPUFraction},
+		{RuntimeNames.MemStats.PauseNs, m.MemStats.PauseNs},
+		{RuntimeNames.MemStats.PauseTotalNs, m.MemStats.PauseTotalNs},
+		{RuntimeNames.MemStats.SinceLastGCNs, m.MemStats.SinceLastGCNs},
+		{RuntimeNames.MemStats.StackInUse, m.MemStats.StackInUse},
+		{RuntimeNames.MemStats.StackSys, m.MemStats.StackSys},
+		{RuntimeNames.MemStats.Sys, m.MemStats.Sys},
+		{RuntimeNames.MemStats.TotalAlloc, m.MemStats.TotalAlloc},
+		{RuntimeNames.NumCgoCall, m.NumCgoCall},
+		{RuntimeNames.NumGoroutine, m.NumGoroutine},
+		{RuntimeNames.NumThread, m.NumThread},
+		// {"runtime.read_mem_stats", m.ReadMemStats},
+	}
 }
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+// RuntimeCollectorOptions is a RuntimeCollector metrics set and naming options.
+type RuntimeCollectorOptions struct {
+	// Prefix replaces runtime prefix in default metric names (like runtime.num_goroutine)
+	Prefix string
+	// Tags for tagged metrics registration (not tagged if empty)
+	Tags map[string]string
+	// MemStats selects runtime.MemStats based metrics by default names (from RuntimeNames), all if nil
+	MemStats func(name string) bool
+	// DisableMemStats disables runtime.MemStats based metrics (runtime.ReadMemStats stops the world)
+	DisableMemStats bool
+	// RuntimeMetrics enables runtime/metrics based metrics (see RegisterRuntimeMetrics)
+	RuntimeMetrics bool
+	// RuntimeMetricsFilter selects runtime/metrics names, all if nil
+	RuntimeMetricsFilter RuntimeMetricsFilter
+}
+
+// RuntimeCollector captures the Go runtime statistics into metrics, registered in one registry.
+// Unlike RegisterRuntimeMemStats, collectors can be created for several registries and can be stopped.
+type RuntimeCollector struct {
+	r        Registry
+	tags     map[string]string
+	metrics  []collectorMetric // registered metrics
+	memStats *runtimeMemStatsMetrics
+	ms       runtime.MemStats
+	rt       *runtimeMetricsSet
+	lock     sync.Mutex // serialize captures
+	runner   runner.Runner
+}
+
+// NewRuntimeCollector constructs and registers runtime metrics in registry.
+// Returns DuplicateMetric error (and unregister already registered metrics) if metric with the same name exists.
+func NewRuntimeCollector(r Registry, opts RuntimeCollectorOptions) (*RuntimeCollector, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	c := &RuntimeCollector{r: r, tags: opts.Tags}
+	name := func(name string) string {
+		if opts.Prefix == "" {
+			return name
+		}
+		return opts.Prefix + strings.TrimPrefix(name, "runtime")
+	}
+	if !opts.DisableMemStats {
+		memStats := &runtimeMemStatsMetrics{}
+		if err := memStats.init(); err != nil {
+			return nil, err
+		}
+		for _, m := range memStats.list() {
+			if opts.MemStats == nil || opts.MemStats(m.name) {
+				c.metrics = append(c.metrics, collectorMetric{name(m.name), m.metric})
+				c.memStats = memStats
+			}
+		}
+	}
+	if opts.RuntimeMetrics {
+		c.rt = newRuntimeMetricsSet(opts.RuntimeMetricsFilter)
+		for _, m := range c.rt.list() {
+			c.metrics = append(c.metrics, collectorMetric{name(m.name), m.metric})
+		}
+	}
+	if err := registerCollectorMetrics(r, c.metrics, c.tags); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Capture new values for the Go runtime statistics.
+func (c *RuntimeCollector) Capture() {
+	c.lock.Lock()
+	if c.memStats != nil {
+		t := time.Now().UnixNano()
+		runtime.ReadMemStats(&c.ms)
+		c.memStats.capture(&c.ms, t)
+	}
+	if c.rt != nil {
+		c.rt.capture()
+	}
+	c.lock.Unlock()
+}
+
+// Start captures the Go runtime statistics with interval in background goroutine, until Stop() call or ctx cancellation.
+// Stopped collector can be started again.
+func (c *RuntimeCollector) Start(ctx context.Context, interval time.Duration) error {
+	return c.runner.Start(ctx, interval, c.Capture)
+}
+
+// Stop stops background captures and wait for goroutine exit.
+func (c *RuntimeCollector) Stop() {
+	c.runner.Stop()
+}
+
+// Unregister stops collector and unregisters it's metrics.
+func (c *RuntimeCollector) Unregister() {
+	c.Stop()
+	unregisterCollectorMetrics(c.r, c.metrics, c.tags)
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRuntimeMemStatsNames(t *testing.T) {
+	var m runtimeMemStatsMetrics
+	if err := m.init(); err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, rm := range m.list() {
+		if names[rm.name] {
+			t.Errorf("duplicate runtime metric name %q", rm.name)
+		}
+		names[rm.name] = true
+	}
+	if !names[RuntimeNames.MemStats.MSpanInuse] || !names[RuntimeNames.MemStats.MSpanSys] {
+		t.Errorf("MSpan metrics are not listed")
+	}
+}
+
+func TestRuntimeCollector(t *testing.T) {
+	tags := map[string]string{"host": "a"}
+	opts := RuntimeCollectorOptions{
+		Prefix: "app.runtime",
+		Tags:   tags,
+		MemStats: func(name string) bool {
+			return name == RuntimeNames.NumGoroutine || name == RuntimeNames.MemStats.MSpanInuse
+		},
+		RuntimeMetrics:       true,
+		RuntimeMetricsFilter: RuntimeMetricsPrefixes("/sched/goroutines:"),
+	}
+	r := NewRegistry()
+	c, err := NewRuntimeCollector(r, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Capture()
+
+	for _, name := range []string{"app.runtime.num_goroutine", "app.runtime.sched.goroutines_goroutines"} {
+		if g, ok := r.GetT(name, tags).(Gauge); !ok {
+			t.Errorf("%s is %T, want Gauge", name, r.GetT(name, tags))
+		} else if g.Value() < 1 {
+			t.Errorf("%s = %d, want at least 1", name, g.Value())
+		}
+	}
+	if g, ok := r.GetT("app.runtime.mem_stats.mspan_inuse", tags).(Gauge); !ok {
+		t.Errorf("app.runtime.mem_stats.mspan_inuse is %T, want Gauge", r.GetT("app.runtime.mem_stats.mspan_inuse", tags))
+	} else if g.Value() < 1 {
+		t.Errorf("app.runtime.mem_stats.mspan_inuse = %d, want at least 1", g.Value())
+	}
+	for _, name := range []string{"app.runtime.mem_stats.mcache_inuse", "app.runtime.num_thread"} {
+		if m := r.GetT(name, tags); m != nil {
+			t.Errorf("%s is registered, but filtered", name)
+		}
+	}
+	if m := r.Get("app.runtime.num_goroutine"); m != nil {
+		t.Errorf("app.runtime.num_goroutine is registered without tags")
+	}
+
+	// duplicate metrics in the same registry
+	if _, err = NewRuntimeCollector(r, opts); err == nil {
+		t.Error("NewRuntimeCollector() with duplicate metrics must fail")
+	}
+	if m := r.GetT("app.runtime.num_goroutine", tags); m == nil {
+		t.Errorf("app.runtime.num_goroutine is unregistered after failed registration")
+	}
+
+	// other registry
+	r2 := NewRegistry()
+	c2, err := NewRuntimeCollector(r2, RuntimeCollectorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2.Capture()
+	if g := r2.Get(RuntimeNames.NumThread).(Gauge); g.Value() < 1 {
+		t.Errorf("%s = %d, want at least 1", RuntimeNames.NumThread, g.Value())
+	}
+
+	c.Unregister()
+	if m := r.GetT("app.runtime.num_goroutine", tags); m != nil {
+		t.Errorf("app.runtime.num_goroutine is registered after Unregister()")
+	}
+}
+
+func TestRuntimeCollector_Start(t *testing.T) {
+	r := NewRegistry()
+	c, err := NewRuntimeCollector(r, RuntimeCollectorOptions{
+		MemStats: func(name string) bool { return name == RuntimeNames.NumGoroutine },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := r.Get(RuntimeNames.NumGoroutine).(Gauge)
+
+	if err = c.Start(context.Background(), 0); err != ErrCollectorInterval {
+		t.Errorf("Start() error = %v, want %v", err, ErrCollectorInterval)
+	}
+
+	if err = c.Start(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// first capture in background on start
+	for g.Value() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if err = c.Start(context.Background(), time.Millisecond); err != ErrCollectorStarted {
+		t.Errorf("Start() error = %v, want %v", err, ErrCollectorStarted)
+	}
+	c.Stop()
+	c.Stop()
+
+	// restart after Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	if err = c.Start(ctx, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-c.runner.Done()
+
+	// restart after context cancellation
+	if err = c.Start(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	c.Unregister()
+	if m := r.Get(RuntimeNames.NumGoroutine); m != nil {
+		t.Errorf("%s is registered after Unregister()", RuntimeNames.NumGoroutine)
+	}
+}
+
+func BenchmarkRuntimeCollector(b *testing.B) {
+	c, err := NewRuntimeCollector(NewRegistry(), RuntimeCollectorOptions{RuntimeMetrics: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Capture()
+	}
+}
@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+// GoroutineOther is a func tag value for goroutines, not in top-N groups
+const GoroutineOther = "other"
+
+// GoroutineCollectorOptions is a GoroutineCollector grouping and naming options.
+type GoroutineCollectorOptions struct {
+	// Name is a metric name, RuntimeNames.Goroutines (runtime.goroutines) by default
+	Name string
+	// Tags for tagged metrics registration, merged with func tag
+	Tags map[string]string
+	// Creator groups goroutines by creator function (from created by frame) instead of top stack frames
+	Creator bool
+	// Depth is a number of top stack frames in group (joined with <, like pkg.(*T).read<pkg.(*T).loop), 1 by default
+	Depth int
+	// Skip excludes stack frames by function name from top stack frames grouping, runtime package frames are skipped if nil
+	Skip func(function string) bool
+	// TopN is a maximum number of exported groups (largest), other goroutines are counted with func=other, 20 by default
+	TopN int
+}
+
+// GoroutineCollector periodically takes the goroutine profile and counts goroutines, grouped by top or creator stack frames,
+// into gauges like runtime.goroutines;func=pkg.(*T).loop, registered in one registry.
+//
+// Only top-N groups are registered (gauges for groups, dropped from top-N, are unregistered), so cardinality is capped by TopN+1.
+// Goroutine profile with stacks is expensive (stops the world), so use a reasonable capture interval.
+type GoroutineCollector struct {
+	r       Registry
+	name    string
+	tags    map[string]string
+	creator bool
+	depth   int
+	skip    func(function string) bool
+	topN    int
+
+	other  Gauge
+	gauges map[string]Gauge // registered top-N groups by func tag
+
+	buf    bytes.Buffer
+	lock   sync.Mutex
+	runner runner.Runner
+}
+
+// NewGoroutineCollector constructs goroutines collector and registers gauge for other goroutines in registry.
+// Returns DuplicateMetric error if metric with the same name exists.
+func NewGoroutineCollector(r Registry, opts GoroutineCollectorOptions) (*GoroutineCollector, error) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	c := &GoroutineCollector{
+		r:       r,
+		name:    opts.Name,
+		tags:    opts.Tags,
+		creator: opts.Creator,
+		depth:   opts.Depth,
+		skip:    opts.Skip,
+		topN:    opts.TopN,
+		other:   NewGauge(),
+		gauges:  make(map[string]Gauge),
+	}
+	if c.name == "" {
+		c.name = RuntimeNames.Goroutines
+	}
+	if c.depth < 1 {
+		c.depth = 1
+	}
+	if c.skip == nil {
+		c.skip = skipRuntimeFrame
+	}
+	if c.topN < 1 {
+		c.topN = 20
+	}
+	if err := c.r.RegisterT(c.name, c.funcTags(GoroutineOther), c.other); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func skipRuntimeFrame(function string) bool {
+	return strings.HasPrefix(function, "runtime.")
+}
+
+func (c *GoroutineCollector) funcTags(function string) map[string]string {
+	return MergeTags(map[string]string{"func": function}, c.tags)
+}
+
+type goroutineGroup struct {
+	function string
+	count    int64
+}
+
+// Capture takes the goroutine profile and update goroutines counts.
+func (c *GoroutineCollector) Capture() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.buf.Reset()
+	if err := pprof.Lookup("goroutine").WriteTo(&c.buf, 2); err != nil {
+		return err
+	}
+	counts := c.census(c.buf.Bytes())
+
+	groups := make([]goroutineGroup, 0, len(counts))
+	for function, count := range counts {
+		groups = append(groups, goroutineGroup{function, count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].count == groups[j].count {
+			return groups[i].function < groups[j].function
+		}
+		return groups[i].count > groups[j].count
+	})
+
+	var other int64
+	if len(groups) > c.topN {
+		for _, g := range groups[c.topN:] {
+			other += g.count
+		}
+		groups = groups[:c.topN]
+	}
+	// unregister dropped groups
+	for function := range c.gauges {
+		if !inGoroutineGroups(function, groups) {
+			c.r.UnregisterT(c.name, c.funcTags(function))
+			delete(c.gauges, function)
+		}
+	}
+
+	var firstErr error
+	for _, g := range groups {
+		gauge, ok := c.gauges[g.function]
+		if !ok {
+			gauge = NewGauge()
+			if err := c.r.RegisterT(c.name, c.funcTags(g.function), gauge); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				other += g.count
+				continue
+			}
+			c.gauges[g.function] = gauge
+		}
+		gauge.Update(g.count)
+	}
+	c.other.Update(other)
+
+	return firstErr
+}
+
+func inGoroutineGroups(function string, groups []goroutineGroup) bool {
+	for _, g := range groups {
+		if g.function == function {
+			return true
+		}
+	}
+	return false
+}
+
+// census counts goroutines by groups in the goroutine profile with stacks (debug=2), like
+//
+//	goroutine 18 [chan receive]:
+//	pkg.(*T).loop(0xc0000a0000)
+//		/src/pkg/t.go:20 +0x45
+//	created by pkg.NewT in goroutine 1
+//		/src/pkg/t.go:10 +0x85
+func (c *GoroutineCollector) census(data []byte) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, block := range strings.Split(string(data), "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if !strings.HasPrefix(lines[0], "goroutine ") {
+			continue
+		}
+		var (
+			frames  []string
+			first   string
+			creator string
+		)
+		for _, line := range lines[1:] {
+			if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "...") {
+				// source file line or elided frames
+				continue
+			}
+			if strings.HasPrefix(line, "created by ") {
+				creator = line[len("created by "):]
+				if n := strings.Index(creator, " in goroutine "); n != -1 {
+					creator = creator[:n]
+				}
+				break
+			}
+			function := line
+			if strings.HasSuffix(function, ")") {
+				if n := strings.LastIndexByte(function, '('); n > 0 {
+					function = function[:n]
+				}
+			}
+			if first == "" {
+				first = function
+			}
+			if len(frames) < c.depth && !c.skip(function) {
+				frames = append(frames, function)
+			}
+		}
+
+		var group string
+		if c.creator {
+			group = creator
+		} else if len(frames) > 0 {
+			group = strings.Join(frames, "<")
+		} else {
+			// all frames are skipped
+			group = first
+		}
+		if group == "" {
+			group = "unknown"
+		}
+		counts[group]++
+	}
+	return counts
+}
+
+// Start captures goroutines census with interval in background goroutine, until Stop() call or ctx cancellation.
+// Stopped collector can be started again.
+func (c *GoroutineCollector) Start(ctx context.Context, interval time.Duration) error {
+	return c.runner.Start(ctx, interval, func() { _ = c.Capture() })
+}
+
+// Stop stops background captures and wait for goroutine exit.
+func (c *GoroutineCollector) Stop() {
+	c.runner.Stop()
+}
+
+// Unregister stops collector and unregisters it's metrics.
+func (c *GoroutineCollector) Unregister() {
+	c.Stop()
+	c.lock.Lock()
+	for function := range c.gauges {
+		c.r.UnregisterT(c.name, c.funcTags(function))
+		delete(c.gauges, function)
+	}
+	c.r.UnregisterT(c.name, c.funcTags(GoroutineOther))
+	c.lock.Unlock()
+}
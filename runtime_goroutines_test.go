@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const goroutineProfile = `goroutine 1 [running]:
+main.main()
+	/src/main.go:10 +0x1d
+
+goroutine 18 [chan receive]:
+runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)
+	/go/src/runtime/proc.go:398 +0xce
+runtime.chanrecv1(0xc0000a0000?, 0x0?)
+	/go/src/runtime/chan.go:442 +0x12
+example.com/pkg.(*T).read(...)
+	/src/pkg/t.go:30
+example.com/pkg.(*T).loop(0xc0000a0000)
+	/src/pkg/t.go:20 +0x45
+created by example.com/pkg.NewT in goroutine 1
+	/src/pkg/t.go:10 +0x85
+
+goroutine 19 [chan receive]:
+runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)
+	/go/src/runtime/proc.go:398 +0xce
+example.com/pkg.(*T).loop(0xc0000a0000)
+	/src/pkg/t.go:20 +0x45
+created by example.com/pkg.NewT
+	/src/pkg/t.go:10 +0x85
+
+goroutine 20 [select, 2 minutes]:
+runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)
+	/go/src/runtime/proc.go:398 +0xce
+runtime.selectgo(0xc000057f28, 0xc000057f00, 0x0?, 0x0, 0x0?, 0x1)
+	/go/src/runtime/select.go:327 +0x725
+example.com/srv.worker[...](0x0)
+	/src/srv/srv.go:50 +0x10
+...additional frames elided...
+created by example.com/srv.Start in goroutine 1
+	/src/srv/srv.go:40 +0x85
+
+goroutine 2 [force gc (idle)]:
+runtime.gopark(0x0?, 0x0?, 0x0?, 0x0?, 0x0?)
+	/go/src/runtime/proc.go:398 +0xce
+runtime.goparkunlock(...)
+	/go/src/runtime/proc.go:404
+created by runtime.init.6 in goroutine 1
+	/go/src/runtime/proc.go:314 +0x1a
+`
+
+func TestGoroutineCollector_census(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GoroutineCollectorOptions
+		want map[string]int64
+	}{
+		{
+			name: "top",
+			want: map[string]int64{
+				"main.main":                   1,
+				"example.com/pkg.(*T).read":   1,
+				"example.com/pkg.(*T).loop":   1,
+				"example.com/srv.worker[...]": 1,
+				"runtime.gopark":              1,
+			},
+		},
+		{
+			name: "top depth 2",
+			opts: GoroutineCollectorOptions{Depth: 2},
+			want: map[string]int64{
+				"main.main": 1,
+				"example.com/pkg.(*T).read<example.com/pkg.(*T).loop": 1,
+				"example.com/pkg.(*T).loop":                           1,
+				"example.com/srv.worker[...]":                         1,
+				"runtime.gopark":                                      1,
+			},
+		},
+		{
+			name: "top without skip",
+			opts: GoroutineCollectorOptions{Skip: func(string) bool { return false }},
+			want: map[string]int64{
+				"main.main":      1,
+				"runtime.gopark": 4,
+			},
+		},
+		{
+			name: "creator",
+			opts: GoroutineCollectorOptions{Creator: true},
+			want: map[string]int64{
+				"unknown":               1,
+				"example.com/pkg.NewT":  2,
+				"example.com/srv.Start": 1,
+				"runtime.init.6":        1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewGoroutineCollector(NewRegistry(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.census([]byte(goroutineProfile)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("census() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func goroutineCensusTestLoop(stop chan struct{}, started, wg *sync.WaitGroup) {
+	defer wg.Done()
+	started.Done()
+	<-stop
+}
+
+func TestGoroutineCollector(t *testing.T) {
+	const n = 10
+	stop := make(chan struct{})
+	var started, wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		started.Add(1)
+		wg.Add(1)
+		go goroutineCensusTestLoop(stop, &started, &wg)
+	}
+	started.Wait()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	r := NewRegistry()
+	tags := map[string]string{"app": "test"}
+	c, err := NewGoroutineCollector(r, GoroutineCollectorOptions{
+		Tags: tags,
+		TopN: 1,
+		Skip: func(function string) bool {
+			return strings.HasPrefix(function, "runtime.") || strings.HasPrefix(function, "sync.")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewGoroutineCollector(r, GoroutineCollectorOptions{Tags: tags}); err == nil {
+		t.Error("NewGoroutineCollector() with duplicate metrics must fail")
+	}
+	if err = c.Capture(); err != nil {
+		t.Fatal(err)
+	}
+
+	funcs := make(map[string]int64)
+	r.Each(func(name, _ string, tagsMap map[string]string, i interface{}) error {
+		if name == RuntimeNames.Goroutines {
+			if tagsMap["app"] != "test" {
+				t.Errorf("%s tags = %v, app tag is lost", name, tagsMap)
+			}
+			funcs[tagsMap["func"]] = i.(Gauge).Value()
+		}
+		return nil
+	}, false)
+	if len(funcs) != 2 {
+		t.Errorf("registered funcs = %v, want top-1 and %s", funcs, GoroutineOther)
+	}
+	var top string
+	for function := range funcs {
+		if function != GoroutineOther {
+			top = function
+		}
+	}
+	if !strings.HasSuffix(top, "goroutineCensusTestLoop") {
+		t.Errorf("top func = %q, want goroutineCensusTestLoop", top)
+	}
+	if funcs[top] != n {
+		t.Errorf("%s;func=%s = %d, want %d", RuntimeNames.Goroutines, top, funcs[top], n)
+	}
+	if funcs[GoroutineOther] < 1 {
+		t.Errorf("%s;func=%s = %d, want at least 1", RuntimeNames.Goroutines, GoroutineOther, funcs[GoroutineOther])
+	}
+
+	c.Unregister()
+	r.Each(func(name, _ string, _ map[string]string, _ interface{}) error {
+		t.Errorf("%s is registered after Unregister()", name)
+		return nil
+	}, false)
+}
+
+func BenchmarkGoroutineCollector(b *testing.B) {
+	c, err := NewGoroutineCollector(NewRegistry(), GoroutineCollectorOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.Capture()
+	}
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"math"
+	rtmetrics "runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RuntimeMetricsFilter selects runtime/metrics names (like /gc/heap/allocs:bytes) for collection.
+type RuntimeMetricsFilter func(name string) bool
+
+// RuntimeMetricsPrefixes returns filter for runtime/metrics names with one of prefixes (like /gc/ or /sched/latencies:).
+func RuntimeMetricsPrefixes(prefixes ...string) RuntimeMetricsFilter {
+	return func(name string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RuntimeHistogramWeights are weights for runtime/metrics histograms by unit (name suffix after ':').
+// Runtime histograms with other units keep runtime buckets boundaries.
+var RuntimeHistogramWeights = map[string][]float64{
+	"seconds": {1e-6, 1e-5, 1e-4, 2.5e-4, 5e-4, 1e-3, 2.5e-3, 5e-3, 1e-2, 2.5e-2, 5e-2, 0.1, 1},
+}
+
+var (
+	runtimeNameReplacer = strings.NewReplacer("/", ".", ":", "_", "-", "_")
+
+	runtimeMetricsSets     []*runtimeMetricsSet
+	runtimeMetricsSetsLock sync.Mutex
+)
+
+// RuntimeMetricName returns metric name for runtime/metrics name, like runtime.gc.heap.allocs_bytes for /gc/heap/allocs:bytes
+func RuntimeMetricName(name string) string {
+	return "runtime." + runtimeNameReplacer.Replace(strings.TrimPrefix(name, "/"))
+}
+
+// runtimeMetricsSet is a runtime/metrics samples with metrics, updated from them.
+type runtimeMetricsSet struct {
+	samples []rtmetrics.Sample
+	metrics []interface{}
+	updates []func(v rtmetrics.Value)
+}
+
+// newRuntimeMetricsSet discovers supported runtime/metrics (filtered by filter, if not nil).
+// Cumulative uint64 values are mapped to Counter, other uint64 values to Gauge, float64 values to FGauge
+// and histograms to RuntimeFHistogram.
+func newRuntimeMetricsSet(filter RuntimeMetricsFilter) *runtimeMetricsSet {
+	descs := rtmetrics.All()
+	s := &runtimeMetricsSet{}
+	cumulative := make([]bool, 0, len(descs))
+	for _, d := range descs {
+		if filter != nil && !filter(d.Name) {
+			continue
+		}
+		switch d.Kind {
+		case rtmetrics.KindUint64, rtmetrics.KindFloat64, rtmetrics.KindFloat64Histogram:
+			s.samples = append(s.samples, rtmetrics.Sample{Name: d.Name})
+			cumulative = append(cumulative, d.Cumulative)
+		}
+	}
+	// read histograms buckets boundaries
+	rtmetrics.Read(s.samples)
+
+	samples := s.samples[:0]
+	for i := range s.samples {
+		m, update := newRuntimeMetric(s.samples[i], cumulative[i])
+		if m == nil {
+			// not supported by current runtime
+			continue
+		}
+		samples = append(samples, s.samples[i])
+		s.metrics = append(s.metrics, m)
+		s.updates = append(s.updates, update)
+	}
+	s.samples = samples
+	return s
+}
+
+func newRuntimeMetric(sample rtmetrics.Sample, cumulative bool) (interface{}, func(v rtmetrics.Value)) {
+	switch sample.Value.Kind() {
+	case rtmetrics.KindUint64:
+		if cumulative {
+			c := NewCounter()
+			var prev uint64
+			return c, func(v rtmetrics.Value) {
+				n := v.Uint64()
+				if n > prev {
+					c.Add(n - prev)
+				}
+				prev = n
+			}
+		}
+		g := NewGauge()
+		return g, func(v rtmetrics.Value) {
+			g.Update(int64(v.Uint64()))
+		}
+	case rtmetrics.KindFloat64:
+		g := NewFGauge()
+		return g, func(v rtmetrics.Value) {
+			g.Update(v.Float64())
+		}
+	case rtmetrics.KindFloat64Histogram:
+		h, _ := NewRuntimeFHistogram(runtimeHistogramWeights(sample.Name, sample.Value.Float64Histogram().Buckets), nil)
+		rh, ok := h.(*RuntimeFHistogram)
+		if !ok {
+			// UseNilMetrics
+			return h, func(rtmetrics.Value) {}
+		}
+		return rh, func(v rtmetrics.Value) {
+			rh.Update(v.Float64Histogram())
+		}
+	default:
+		return nil, nil
+	}
+}
+
+// runtimeHistogramWeights returns RuntimeHistogramWeights for name unit or finite runtime buckets upper bounds.
+func runtimeHistogramWeights(name string, buckets []float64) []float64 {
+	if n := strings.LastIndexByte(name, ':'); n != -1 {
+		if weights, ok := RuntimeHistogramWeights[name[n+1:]]; ok {
+			return weights
+		}
+	}
+	weights := make([]float64, 0, len(buckets))
+	for _, w := range buckets[1:] {
+		if !math.IsInf(w, 0) {
+			weights = append(weights, w)
+		}
+	}
+	return weights
+}
+
+// list returns metrics with default names (from RuntimeMetricName)
+func (s *runtimeMetricsSet) list() []collectorMetric {
+	list := make([]collectorMetric, len(s.samples))
+	for i := range s.samples {
+		list[i] = collectorMetric{RuntimeMetricName(s.samples[i].Name), s.metrics[i]}
+	}
+	return list
+}
+
+func (s *runtimeMetricsSet) capture() {
+	rtmetrics.Read(s.samples)
+	for i := range s.samples {
+		s.updates[i](s.samples[i].Value)
+	}
+}
+
+// Capture new values for the Go runtime statistics exported in runtime/metrics.
+// This is designed to be called as a goroutine.
+func CaptureRuntimeMetrics(d time.Duration) {
+	for range time.Tick(d) {
+		CaptureRuntimeMetricsOnce()
+	}
+}
+
+// Capture new values for the Go runtime statistics exported in runtime/metrics for all registries,
+// given to RegisterRuntimeMetrics.
+//
+// Unlike CaptureRuntimeMemStatsOnce, runtime/metrics are read without stop the world.
+func CaptureRuntimeMetricsOnce() {
+	runtimeMetricsSetsLock.Lock()
+	for _, s := range runtimeMetricsSets {
+		s.capture()
+	}
+	runtimeMetricsSetsLock.Unlock()
+}
+
+// RegisterRuntimeMetrics registers metrics for the Go runtime statistics exported in runtime/metrics.
+// All supported metrics are discovered with runtime/metrics.All() and selected by filter (all if filter is nil).
+// Metrics are named with RuntimeMetricName, i.e. runtime.gc.heap.allocs_bytes for /gc/heap/allocs:bytes.
+func RegisterRuntimeMetrics(r Registry, filter RuntimeMetricsFilter) error {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	s := newRuntimeMetricsSet(filter)
+	if err := registerCollectorMetrics(r, s.list(), nil); err != nil {
+		return err
+	}
+	runtimeMetricsSetsLock.Lock()
+	runtimeMetricsSets = append(runtimeMetricsSets, s)
+	runtimeMetricsSetsLock.Unlock()
+	return nil
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	rtmetrics "runtime/metrics"
+	"testing"
+)
+
+func TestRuntimeMetricName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/gc/heap/allocs:bytes", "runtime.gc.heap.allocs_bytes"},
+		{"/sched/goroutines:goroutines", "runtime.sched.goroutines_goroutines"},
+		{"/cpu/classes/gc/mark/assist:cpu-seconds", "runtime.cpu.classes.gc.mark.assist_cpu_seconds"},
+		{"/gc/heap/allocs-by-size:bytes", "runtime.gc.heap.allocs_by_size_bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RuntimeMetricName(tt.name); got != tt.want {
+				t.Errorf("RuntimeMetricName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterRuntimeMetrics(t *testing.T) {
+	r := NewRegistry()
+	if err := RegisterRuntimeMetrics(r, RuntimeMetricsPrefixes("/gc/", "/sched/goroutines:")); err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	CaptureRuntimeMetricsOnce()
+
+	if c, ok := r.Get("runtime.gc.cycles.total_gc_cycles").(Counter); !ok {
+		t.Errorf("runtime.gc.cycles.total_gc_cycles is %T, want Counter", r.Get("runtime.gc.cycles.total_gc_cycles"))
+	} else if c.Count() < 1 {
+		t.Errorf("runtime.gc.cycles.total_gc_cycles = %d, want at least 1", c.Count())
+	}
+	if g, ok := r.Get("runtime.sched.goroutines_goroutines").(Gauge); !ok {
+		t.Errorf("runtime.sched.goroutines_goroutines is %T, want Gauge", r.Get("runtime.sched.goroutines_goroutines"))
+	} else if g.Value() < 1 {
+		t.Errorf("runtime.sched.goroutines_goroutines = %d, want at least 1", g.Value())
+	}
+	if h, ok := r.Get("runtime.gc.pauses_seconds").(*RuntimeFHistogram); !ok {
+		t.Errorf("runtime.gc.pauses_seconds is %T, want *RuntimeFHistogram", r.Get("runtime.gc.pauses_seconds"))
+	} else {
+		if h.Count() < 1 {
+			t.Errorf("runtime.gc.pauses_seconds count = %d, want at least 1", h.Count())
+		}
+		if len(h.Weights()) != len(RuntimeHistogramWeights["seconds"])+1 {
+			t.Errorf("runtime.gc.pauses_seconds weights = %v, want %v", h.Weights(), RuntimeHistogramWeights["seconds"])
+		}
+	}
+	// filtered
+	if m := r.Get("runtime.sched.gomaxprocs_threads"); m != nil {
+		t.Errorf("runtime.sched.gomaxprocs_threads is registered, but filtered")
+	}
+
+	if err := RegisterRuntimeMetrics(r, RuntimeMetricsPrefixes("/sched/gomaxprocs:", "/sched/goroutines:")); err == nil {
+		t.Error("RegisterRuntimeMetrics() with duplicate metrics must fail")
+	}
+	if m := r.Get("runtime.sched.gomaxprocs_threads"); m != nil {
+		t.Errorf("runtime.sched.gomaxprocs_threads must be unregistered after failed registration")
+	}
+}
+
+func TestRuntimeFHistogram(t *testing.T) {
+	h, err := NewRuntimeFHistogram([]float64{1, 4}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rh := h.(*RuntimeFHistogram)
+	buckets := []float64{math.Inf(-1), 0, 1, 2, 4, math.Inf(1)}
+
+	rh.Update(&rtmetrics.Float64Histogram{Counts: []uint64{0, 2, 1, 0, 3}, Buckets: buckets})
+	if want := []uint64{2, 1, 3}; !reflect.DeepEqual(want, rh.Values()) {
+		t.Errorf("Values() = %v, want %v", rh.Values(), want)
+	}
+	if want := []string{".1", ".4", ".inf"}; !reflect.DeepEqual(want, rh.Labels()) {
+		t.Errorf("Labels() = %v, want %v", rh.Labels(), want)
+	}
+	if want := (HistogramStats{Count: 6, Sum: 14.5, Min: 0, Max: 4}); rh.Stats() != want {
+		t.Errorf("Stats() = %+v, want %+v", rh.Stats(), want)
+	}
+
+	if want := []uint64{2, 1, 3}; !reflect.DeepEqual(want, rh.Clear()) {
+		t.Errorf("Clear() = %v, want %v", rh.Values(), want)
+	}
+	if want := []uint64{0, 0, 0}; !reflect.DeepEqual(want, rh.Values()) {
+		t.Errorf("Values() after Clear() = %v, want %v", rh.Values(), want)
+	}
+
+	// only observations after Clear()
+	rh.Update(&rtmetrics.Float64Histogram{Counts: []uint64{0, 3, 1, 0, 3}, Buckets: buckets})
+	if want := []uint64{1, 0, 0}; !reflect.DeepEqual(want, rh.Values()) {
+		t.Errorf("Values() = %v, want %v", rh.Values(), want)
+	}
+	if want := (HistogramStats{Count: 1, Sum: 0.5, Min: 0, Max: 1}); rh.Stats() != want {
+		t.Errorf("Stats() = %+v, want %+v", rh.Stats(), want)
+	}
+	if got := rh.Snapshot().Values(); !reflect.DeepEqual([]uint64{1, 0, 0}, got) {
+		t.Errorf("Snapshot().Values() = %v, want [1 0 0]", got)
+	}
+}
+
+func BenchmarkRuntimeMetrics(b *testing.B) {
+	s := newRuntimeMetricsSet(nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.capture()
+	}
+}
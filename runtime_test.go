@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"math"
 	"runtime"
 	"testing"
 	"time"
@@ -8,7 +9,9 @@ import (
 
 func TestRuntimeMemStatsDoubleRegister(t *testing.T) {
 	r := NewRegistry()
-	RegisterRuntimeMemStats(r)
+	if err := RegisterRuntimeMemStats(r); err != nil {
+		t.Fatal(err)
+	}
 	storedGauge := r.Get(RuntimeNames.MemStats.LastGC).(Gauge)
 
 	runtime.GC()
@@ -21,7 +24,9 @@ func TestRuntimeMemStatsDoubleRegister(t *testing.T) {
 
 	time.Sleep(time.Millisecond)
 
-	RegisterRuntimeMemStats(r)
+	if err := RegisterRuntimeMemStats(r); err != nil {
+		t.Fatal(err)
+	}
 	runtime.GC()
 	CaptureRuntimeMemStatsOnce()
 	if lastGC := storedGauge.Value(); firstGC == lastGC {
@@ -174,3 +179,79 @@ func TestRuntimeStats(t *testing.T) {
 		t.Error("goroutines", goroutines1, goroutines2)
 	}
 }
+
+func TestRuntimeMemStatsPauses(t *testing.T) {
+	var m runtimeMemStatsMetrics
+	if err := m.init(); err != nil {
+		t.Fatal(err)
+	}
+	var ms runtime.MemStats
+
+	ms.NumGC = 3
+	ms.PauseNs[0] = 20000   // 20us
+	ms.PauseNs[1] = 200000  // 200us
+	ms.PauseNs[2] = 2000000 // 2ms
+	ms.PauseEnd[2] = 1000
+	m.capturePauses(&ms, 5000)
+	if n := m.MemStats.PauseNs.Stats().Count; n != 3 {
+		t.Errorf("PauseNs count = %d, want 3", n)
+	}
+	if v := m.MemStats.SinceLastGCNs.Value(); v != 4000 {
+		t.Errorf("SinceLastGCNs = %d, want 4000", v)
+	}
+	// no new GCs, pauses must not be double-counted
+	m.capturePauses(&ms, 6000)
+	if n := m.MemStats.PauseNs.Stats().Count; n != 3 {
+		t.Errorf("PauseNs count = %d, want 3", n)
+	}
+	if v := m.MemStats.SinceLastGCNs.Value(); v != 5000 {
+		t.Errorf("SinceLastGCNs = %d, want 5000", v)
+	}
+
+	// more than 256 GCs between captures, only last 256 pauses are available
+	for i := range ms.PauseNs {
+		ms.PauseNs[i] = 1000000 // 1ms
+	}
+	ms.NumGC += 300
+	m.capturePauses(&ms, 7000)
+	if n := m.MemStats.PauseNs.Stats().Count; n != 3+256 {
+		t.Errorf("PauseNs count = %d, want %d", n, 3+256)
+	}
+
+	// NumGC overflow
+	m.MemStats.PauseNs.Clear()
+	m.numGC = math.MaxUint32
+	ms.NumGC = 1
+	ms.PauseNs[255] = 50000 // 50us
+	ms.PauseNs[0] = 60000   // 60us
+	ms.PauseEnd[0] = 6500
+	m.capturePauses(&ms, 7000)
+	if s := m.MemStats.PauseNs.Stats(); s.Count != 2 || s.Sum != 110000 {
+		t.Errorf("PauseNs stats = %+v, want count 2, sum 110000", s)
+	}
+	if v := m.MemStats.SinceLastGCNs.Value(); v != 500 {
+		t.Errorf("SinceLastGCNs = %d, want 500", v)
+	}
+}
+
+func TestRuntimeCollector_Pauses(t *testing.T) {
+	r := NewRegistry()
+	c, err := NewRuntimeCollector(r, RuntimeCollectorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	c.Capture()
+	h := r.Get(RuntimeNames.MemStats.PauseNs).(DurationHistogram)
+	if n := h.Stats().Count; n < 1 {
+		t.Errorf("PauseNs count = %d, want at least 1", n)
+	}
+	runtime.GC()
+	c.Capture()
+	if n := h.Stats().Count; n < 2 {
+		t.Errorf("PauseNs count = %d, want at least 2", n)
+	}
+	if v := r.Get(RuntimeNames.MemStats.SinceLastGCNs).(Gauge).Value(); v < 0 || v > int64(time.Minute) {
+		t.Errorf("SinceLastGCNs = %d, want [0, 1m]", v)
+	}
+}
@@ -6,16 +6,40 @@ package metrics
 import (
 	"fmt"
 	"log/syslog"
+	"strings"
 	"time"
 
 	"github.com/msaf1980/go-metrics"
 )
 
 // Output each metric in the given registry to syslog periodically using
-// the given syslogger.
-func Syslog(r metrics.Registry, d time.Duration, w *syslog.Writer, minLock bool) {
+// the given syslogger. Histograms percentiles are metrics.DefaultPercentiles, if not set.
+func Syslog(r metrics.Registry, d time.Duration, w *syslog.Writer, minLock bool, percentiles ...float64) {
+	if len(percentiles) == 0 {
+		percentiles = metrics.DefaultPercentiles
+	}
+	names := make([]string, len(percentiles))
+	for i, p := range percentiles {
+		names[i] = metrics.PercentileName(p)
+	}
+	// formatPercentiles formats percentiles values like " median: %.2f 99%%: %.2f"
+	formatPercentiles := func(format string, value func(i int) interface{}) string {
+		var sb strings.Builder
+		for i, name := range names {
+			sb.WriteString(" ")
+			sb.WriteString(name)
+			sb.WriteString(": ")
+			fmt.Fprintf(&sb, format, value(i))
+		}
+		return sb.String()
+	}
+
 	for range time.Tick(d) {
 		r.Each(func(name, tags string, tagsMap map[string]string, i interface{}) error {
+			if h, ok := i.(metrics.HistogramInterface); ok {
+				// buckets, stats and quantiles are read once (exp histogram buckets layout may change with scale)
+				i = metrics.SnapshotHistogram(h)
+			}
 			switch metric := i.(type) {
 			case metrics.Counter:
 				w.Info(fmt.Sprintf("counter %s%s count: %d", name, tags, metric.Count()))
@@ -44,6 +68,38 @@ func Syslog(r metrics.Registry, d time.Duration, w *syslog.Writer, minLock bool)
 					}
 					w.Info(fmt.Sprintf("histogram %s%s %s total: %d", name, tags, metric.NameTotal(), total))
 				}
+				stats := metric.Stats()
+				w.Info(fmt.Sprintf("histogram %s%s sum: %f min: %f max: %f mean: %f", name, tags, stats.Sum, stats.Min, stats.Max, stats.Mean()))
+				// estimated from the same snapshot buckets
+				ps := metric.Quantiles(percentiles)
+				w.Info(fmt.Sprintf(
+					"histogram %s%s%s",
+					name, tags, formatPercentiles("%.2f", func(i int) interface{} { return ps[i] }),
+				))
+			case metrics.HDRHistogram:
+				h := metric.Snapshot()
+				ps := h.Percentiles(percentiles)
+				w.Info(fmt.Sprintf(
+					"hdrhistogram %s%s count: %d min: %d max: %d mean: %.2f%s",
+					name, tags,
+					h.Count(),
+					h.Min(),
+					h.Max(),
+					h.Mean(),
+					formatPercentiles("%d", func(i int) interface{} { return ps[i] }),
+				))
+			case metrics.DDSketch:
+				s := metric.Snapshot()
+				ps := s.Quantiles(percentiles)
+				w.Info(fmt.Sprintf(
+					"ddsketch %s%s count: %d min: %.2f max: %.2f mean: %.2f%s",
+					name, tags,
+					s.Count(),
+					s.Min(),
+					s.Max(),
+					s.Mean(),
+					formatPercentiles("%.2f", func(i int) interface{} { return ps[i] }),
+				))
 			case metrics.Rate:
 				v, rate := metric.Values()
 				w.Info(fmt.Sprintf("rate %s%s%s value: %d rate: %f\n", name, metric.Name(), tags, v, rate))
@@ -52,56 +108,6 @@ func Syslog(r metrics.Registry, d time.Duration, w *syslog.Writer, minLock bool)
 				v, rate := metric.Values()
 				w.Info(fmt.Sprintf("rate %s%s%s value: %f rate: %f\n", name, metric.Name(), tags, v, rate))
 				w.Info(fmt.Sprintf("rate %s%s%s value: %f rate: %f\n", name, metric.RateName(), tags, v, rate))
-				// case metrics.Histogram:
-				// 	h := metric.Snapshot()
-				// 	ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-				// 	w.Info(fmt.Sprintf(
-				// 		"histogram %s%s count: %d min: %d max: %d mean: %.2f stddev: %.2f median: %.2f 75%%: %.2f 95%%: %.2f 99%%: %.2f 99.9%%: %.2f",
-				// 		name, tags,
-				// 		h.Count(),
-				// 		h.Min(),
-				// 		h.Max(),
-				// 		h.Mean(),
-				// 		h.StdDev(),
-				// 		ps[0],
-				// 		ps[1],
-				// 		ps[2],
-				// 		ps[3],
-				// 		ps[4],
-				// 	))
-				// case metrics.Meter:
-				// 	m := metric.Snapshot()
-				// 	w.Info(fmt.Sprintf(
-				// 		"meter %s%s count: %d 1-min: %.2f 5-min: %.2f 15-min: %.2f mean: %.2f",
-				// 		name, tags,
-				// 		m.Count(),
-				// 		m.Rate1(),
-				// 		m.Rate5(),
-				// 		m.Rate15(),
-				// 		m.RateMean(),
-				// 	))
-				// case metrics.Timer:
-				// 	t := metric.Snapshot()
-				// 	ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-				// 	w.Info(fmt.Sprintf(
-				// 		"timer %s%s count: %d min: %d max: %d mean: %.2f stddev: %.2f median: %.2f 75%%: %.2f 95%%: %.2f 99%%: %.2f 99.9%%: %.2f mean-rate: %.2f\n",
-				// 		// " 1-min: %.2f 5-min: %.2f 15-min: %.2f",
-				// 		name, tags,
-				// 		t.Count(),
-				// 		t.Min(),
-				// 		t.Max(),
-				// 		t.Mean(),
-				// 		t.StdDev(),
-				// 		ps[0],
-				// 		ps[1],
-				// 		ps[2],
-				// 		ps[3],
-				// 		ps[4],
-				// 		t.RateMean(),
-				// 		// t.Rate1(),
-				// 		// t.Rate5(),
-				// 		// t.Rate15(),
-				// 	))
 			}
 			return nil
 		}, minLock)
@@ -0,0 +1,310 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	userHZ     = 100 // clock ticks per second for /proc/stat cpu times
+	sectorSize = 512 // /proc/diskstats sector size
+)
+
+var (
+	// cpu times fields in /proc/stat cpu line order
+	cpuFields = []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal"}
+
+	// /proc/diskstats fields (from 4-th) with multipliers, empty name for skipped field
+	diskFields = []struct {
+		name string
+		mul  int64
+	}{
+		{"reads", 1},
+		{"", 0}, // reads merged
+		{"read_bytes", sectorSize},
+		{"read_time_ms", 1},
+		{"writes", 1},
+		{"", 0}, // writes merged
+		{"write_bytes", sectorSize},
+		{"write_time_ms", 1},
+		{"", 0}, // io in progress, gauge
+		{"io_time_ms", 1},
+	}
+
+	// /proc/net/dev collected receive and transmit fields (first 4 fields in each direction)
+	netFields = []string{"bytes", "packets", "errs", "drop"}
+)
+
+func (c *Collector) readFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(c.proc, name))
+}
+
+// captureLoadAvg parses /proc/loadavg, like
+//
+//	0.20 0.18 0.12 1/80 11206
+func (c *Collector) captureLoadAvg(t int64) error {
+	data, err := c.readFile("loadavg")
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return ErrFormat
+	}
+	for i, name := range []string{"load.1", "load.5", "load.15"} {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return ErrFormat
+		}
+		g, err := c.fGauge(name)
+		if err != nil {
+			return err
+		}
+		g.Update(v)
+	}
+	procs := strings.Split(fields[3], "/")
+	if len(procs) != 2 {
+		return ErrFormat
+	}
+	for i, name := range []string{"load.procs_running", "load.procs_total"} {
+		v, err := strconv.ParseInt(procs[i], 10, 64)
+		if err != nil {
+			return ErrFormat
+		}
+		g, err := c.gauge(name, "", "")
+		if err != nil {
+			return err
+		}
+		g.Update(v)
+	}
+	return nil
+}
+
+// captureMemInfo parses /proc/meminfo, like
+//
+//	MemTotal:        8167852 kB
+//	HugePages_Total:       0
+//
+// Values in kB are converted to bytes, names are lower-cased (MemTotal -> memory.memtotal, Active(anon) -> memory.active_anon).
+func (c *Collector) captureMemInfo(t int64) error {
+	data, err := c.readFile("meminfo")
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasSuffix(fields[0], ":") {
+			return ErrFormat
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return ErrFormat
+		}
+		if len(fields) == 3 && fields[2] == "kB" {
+			v *= 1024
+		}
+		g, err := c.gauge("memory."+memInfoName(fields[0]), "", "")
+		if err != nil {
+			return err
+		}
+		g.Update(v)
+	}
+	return nil
+}
+
+var memInfoReplacer = strings.NewReplacer("(", "_", ")", "", ":", "")
+
+func memInfoName(s string) string {
+	return strings.ToLower(memInfoReplacer.Replace(s))
+}
+
+// captureStat parses /proc/stat, like
+//
+//	cpu  4705 356 584 3699176 23060 0 277 0 0 0
+//	ctxt 1990473
+//	processes 2915
+//	procs_running 1
+//	procs_blocked 0
+//
+// Per-cpu lines are skipped.
+func (c *Collector) captureStat(t int64) error {
+	data, err := c.readFile("stat")
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "cpu":
+			for i, name := range cpuFields {
+				if i+1 >= len(fields) {
+					break
+				}
+				v, err := strconv.ParseUint(fields[i+1], 10, 64)
+				if err != nil {
+					return ErrFormat
+				}
+				r, err := c.fRate("cpu." + name + "_seconds")
+				if err != nil {
+					return err
+				}
+				r.UpdateTs(float64(v)/userHZ, t)
+			}
+		case "ctxt", "intr", "processes":
+			v, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return ErrFormat
+			}
+			r, err := c.rate("stat."+fields[0], "", "")
+			if err != nil {
+				return err
+			}
+			r.UpdateTs(v, t)
+		case "procs_running", "procs_blocked":
+			v, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return ErrFormat
+			}
+			g, err := c.gauge("stat."+fields[0], "", "")
+			if err != nil {
+				return err
+			}
+			g.Update(v)
+		}
+	}
+	return nil
+}
+
+// captureDiskStats parses /proc/diskstats, like
+//
+//	8       0 sda 6307 2107 490398 3163 8101 5617 335648 9212 0 10612 12375
+//
+// Partitions (like sda1) are skipped (they are already counted in disk stats), unless selected by exact name.
+func (c *Collector) captureDiskStats(t int64) error {
+	data, err := c.readFile("diskstats")
+	if err != nil {
+		return err
+	}
+	var lines [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			return ErrFormat
+		}
+		lines = append(lines, fields)
+	}
+	// devices by major number for partitions detection
+	majors := make(map[string]string, len(lines))
+	for _, fields := range lines {
+		majors[fields[2]] = fields[0]
+	}
+	devices := make(map[string]bool, len(lines))
+	for _, fields := range lines {
+		device := fields[2]
+		if !selected(device, c.disks) || (isPartition(device, fields[0], majors) && !contains(c.disks, device)) {
+			continue
+		}
+		devices[device] = true
+		for i, f := range diskFields {
+			if f.name == "" {
+				continue
+			}
+			v, err := strconv.ParseInt(fields[i+3], 10, 64)
+			if err != nil {
+				return ErrFormat
+			}
+			r, err := c.rate("disk."+f.name, "device", device)
+			if err != nil {
+				return err
+			}
+			r.UpdateTs(v*f.mul, t)
+		}
+		v, err := strconv.ParseInt(fields[11], 10, 64)
+		if err != nil {
+			return ErrFormat
+		}
+		g, err := c.gauge("disk.io_in_progress", "device", device)
+		if err != nil {
+			return err
+		}
+		g.Update(v)
+	}
+	c.unregisterAbsent("disk.", "device", devices)
+	return nil
+}
+
+// isPartition checks if device is a partition of other device with the same major number,
+// partitions are named by kernel like sda1 for sda or nvme0n1p1 for nvme0n1.
+func isPartition(device, major string, majors map[string]string) bool {
+	for i := len(device) - 1; i > 0 && device[i] >= '0' && device[i] <= '9'; i-- {
+		disk := device[:i]
+		if disk[len(disk)-1] == 'p' && len(disk) > 1 && disk[len(disk)-2] >= '0' && disk[len(disk)-2] <= '9' {
+			disk = disk[:len(disk)-1]
+		} else if disk[len(disk)-1] >= '0' && disk[len(disk)-1] <= '9' {
+			continue
+		}
+		if m, ok := majors[disk]; ok && m == major {
+			return true
+		}
+	}
+	return false
+}
+
+// captureNetDev parses /proc/net/dev, like
+//
+//	Inter-|   Receive                                                |  Transmit
+//	 face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+//	  eth0: 1215360   10305    0    0    0     0          0         0   717224    6946    0    0    0     0       0          0
+func (c *Collector) captureNetDev(t int64) error {
+	data, err := c.readFile("net/dev")
+	if err != nil {
+		return err
+	}
+	ifaces := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		n := strings.IndexByte(line, ':')
+		if n == -1 {
+			// header
+			continue
+		}
+		iface := strings.TrimSpace(line[:n])
+		if !selected(iface, c.ifaces) {
+			continue
+		}
+		fields := strings.Fields(line[n+1:])
+		if len(fields) < 16 {
+			return ErrFormat
+		}
+		ifaces[iface] = true
+		for _, dir := range []struct {
+			name   string
+			offset int
+		}{{"rx", 0}, {"tx", 8}} {
+			for i, name := range netFields {
+				v, err := strconv.ParseInt(fields[dir.offset+i], 10, 64)
+				if err != nil {
+					return ErrFormat
+				}
+				r, err := c.rate("net."+dir.name+"_"+name, "iface", iface)
+				if err != nil {
+					return err
+				}
+				r.UpdateTs(v, t)
+			}
+		}
+	}
+	c.unregisterAbsent("net.", "iface", ifaces)
+	return nil
+}
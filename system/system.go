@@ -0,0 +1,216 @@
+// Package system collects host system metrics from Linux procfs (load average, memory, cpu, disks and network interfaces).
+package system
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+	"github.com/msaf1980/go-metrics/internal/runner"
+)
+
+var (
+	ErrStarted  = runner.ErrStarted
+	ErrInterval = runner.ErrInterval
+	ErrFormat   = errors.New("invalid procfs file format")
+)
+
+// Config provides a container with configuration parameters for the system metrics collector
+type Config struct {
+	Prefix     string            `toml:"prefix" yaml:"prefix" json:"prefix"`             // Metrics names prefix (system by default)
+	Proc       string            `toml:"proc" yaml:"proc" json:"proc"`                   // Procfs root (/proc by default)
+	Tags       map[string]string `toml:"tags" yaml:"tags" json:"tags"`                   // Tags for all metrics
+	Disks      []string          `toml:"disks" yaml:"disks" json:"disks"`                // Collected disk devices names prefixes (all if empty), partitions are collected only by exact name
+	Interfaces []string          `toml:"interfaces" yaml:"interfaces" json:"interfaces"` // Collected network interfaces names prefixes (all if empty)
+}
+
+// Collector captures host system metrics into registry.
+//
+// Metrics are registered at first capture (devices and interfaces on discovery) with RegisterT,
+// disks metrics are tagged with device=NAME and network metrics with iface=NAME.
+// Metrics of removed devices and interfaces are unregistered at next capture.
+// Cumulative counters are registered as Rate (or FRate for cpu times).
+type Collector struct {
+	r      metrics.Registry
+	prefix string
+	proc   string
+	tags   map[string]string
+	disks  []string
+	ifaces []string
+
+	lock    sync.Mutex
+	metrics map[string]registered // by name and tags
+
+	runner runner.Runner
+}
+
+type registered struct {
+	name   string
+	tags   map[string]string
+	metric interface{}
+}
+
+// New constructs a new system metrics collector for registry.
+func New(r metrics.Registry, cfg Config) *Collector {
+	if nil == r {
+		r = metrics.DefaultRegistry
+	}
+	c := &Collector{
+		r:       r,
+		prefix:  cfg.Prefix,
+		proc:    cfg.Proc,
+		tags:    cfg.Tags,
+		disks:   cfg.Disks,
+		ifaces:  cfg.Interfaces,
+		metrics: make(map[string]registered),
+	}
+	if c.prefix == "" {
+		c.prefix = "system"
+	}
+	if c.proc == "" {
+		c.proc = "/proc"
+	}
+	return c
+}
+
+// Capture new values for the host system statistics.
+// Unreadable or invalid sources are skipped, first error is returned.
+func (c *Collector) Capture() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := time.Now().UnixNano()
+	var firstErr error
+	for _, f := range []func(t int64) error{
+		c.captureLoadAvg, c.captureMemInfo, c.captureStat, c.captureDiskStats, c.captureNetDev,
+	} {
+		if err := f(t); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Start captures the host system statistics with interval in background goroutine, until Stop() call or ctx cancellation.
+// Stopped collector can be started again.
+func (c *Collector) Start(ctx context.Context, interval time.Duration) error {
+	return c.runner.Start(ctx, interval, func() { _ = c.Capture() })
+}
+
+// Stop stops background captures and wait for goroutine exit.
+func (c *Collector) Stop() {
+	c.runner.Stop()
+}
+
+// Unregister stops collector and unregisters all registered metrics.
+func (c *Collector) Unregister() {
+	c.Stop()
+	c.lock.Lock()
+	for k, m := range c.metrics {
+		if len(m.tags) == 0 {
+			c.r.Unregister(m.name)
+		} else {
+			c.r.UnregisterT(m.name, m.tags)
+		}
+		delete(c.metrics, k)
+	}
+	c.lock.Unlock()
+}
+
+// unregisterAbsent unregisters metrics with name prefix, tagged with key not in values.
+func (c *Collector) unregisterAbsent(name, key string, values map[string]bool) {
+	name = c.prefix + "." + name
+	for k, m := range c.metrics {
+		if !strings.HasPrefix(m.name, name) {
+			continue
+		}
+		if v, ok := m.tags[key]; ok && !values[v] {
+			c.r.UnregisterT(m.name, m.tags)
+			delete(c.metrics, k)
+		}
+	}
+}
+
+// metric returns registered metric or registers a new one, constructed with newMetric.
+// Name is appended to prefix, tags are merged with collector tags and optional key=value tag.
+func (c *Collector) metric(name, key, value string, newMetric func() interface{}) (interface{}, error) {
+	name = c.prefix + "." + name
+	tags := c.tags
+	if key != "" {
+		tags = metrics.MergeTags(c.tags, map[string]string{key: value})
+	}
+	id := name + metrics.JoinTags(tags)
+	if m, ok := c.metrics[id]; ok {
+		return m.metric, nil
+	}
+	m := newMetric()
+	var err error
+	if len(tags) == 0 {
+		err = c.r.Register(name, m)
+	} else {
+		err = c.r.RegisterT(name, tags, m)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.metrics[id] = registered{name: name, tags: tags, metric: m}
+	return m, nil
+}
+
+func (c *Collector) gauge(name, key, value string) (metrics.Gauge, error) {
+	m, err := c.metric(name, key, value, func() interface{} { return metrics.NewGauge() })
+	if err != nil {
+		return nil, err
+	}
+	return m.(metrics.Gauge), nil
+}
+
+func (c *Collector) fGauge(name string) (metrics.FGauge, error) {
+	m, err := c.metric(name, "", "", func() interface{} { return metrics.NewFGauge() })
+	if err != nil {
+		return nil, err
+	}
+	return m.(metrics.FGauge), nil
+}
+
+func (c *Collector) rate(name, key, value string) (metrics.Rate, error) {
+	m, err := c.metric(name, key, value, func() interface{} { return metrics.NewRate() })
+	if err != nil {
+		return nil, err
+	}
+	return m.(metrics.Rate), nil
+}
+
+func (c *Collector) fRate(name string) (metrics.FRate, error) {
+	m, err := c.metric(name, "", "", func() interface{} { return metrics.NewFRate() })
+	if err != nil {
+		return nil, err
+	}
+	return m.(metrics.FRate), nil
+}
+
+// contains checks name in names
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selected checks name for prefixes (all if prefixes is empty)
+func selected(name string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
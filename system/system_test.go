@@ -0,0 +1,247 @@
+package system
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/msaf1980/go-metrics"
+)
+
+func TestCollector(t *testing.T) {
+	tags := map[string]string{"host": "a"}
+	r := metrics.NewRegistry()
+	c := New(r, Config{Proc: "testdata/proc", Tags: tags, Disks: []string{"sd"}, Interfaces: []string{"eth"}})
+	if err := c.Capture(); err != nil {
+		t.Fatal(err)
+	}
+
+	fGauges := map[string]float64{
+		"system.load.1":  0.2,
+		"system.load.5":  0.18,
+		"system.load.15": 0.12,
+	}
+	for name, want := range fGauges {
+		if got := r.GetT(name, tags).(metrics.FGauge).Value(); got != want {
+			t.Errorf("%s = %f, want %f", name, got, want)
+		}
+	}
+	gauges := map[string]int64{
+		"system.load.procs_running":     1,
+		"system.load.procs_total":       80,
+		"system.memory.memtotal":        8167852 * 1024,
+		"system.memory.active_anon":     845636 * 1024,
+		"system.memory.hugepages_total": 0,
+		"system.stat.procs_blocked":     0,
+	}
+	for name, want := range gauges {
+		if got := r.GetT(name, tags).(metrics.Gauge).Value(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	rates := map[string]int64{
+		"system.stat.ctxt":      1990473,
+		"system.stat.processes": 2915,
+	}
+	for name, want := range rates {
+		if got, _ := r.GetT(name, tags).(metrics.Rate).Values(); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	if got, _ := r.GetT("system.cpu.user_seconds", tags).(metrics.FRate).Values(); got != 47.05 {
+		t.Errorf("system.cpu.user_seconds = %f, want 47.05", got)
+	}
+	if m := r.GetT("system.cpu.guest_seconds", tags); m != nil {
+		t.Errorf("system.cpu.guest_seconds is registered")
+	}
+
+	sda := map[string]string{"host": "a", "device": "sda"}
+	disks := map[string]int64{
+		"system.disk.reads":       6307,
+		"system.disk.read_bytes":  490398 * 512,
+		"system.disk.writes":      8101,
+		"system.disk.write_bytes": 335648 * 512,
+		"system.disk.io_time_ms":  10612,
+	}
+	for name, want := range disks {
+		if got, _ := r.GetT(name, sda).(metrics.Rate).Values(); got != want {
+			t.Errorf("%s%s = %d, want %d", name, metrics.JoinTags(sda), got, want)
+		}
+	}
+	if got := r.GetT("system.disk.io_in_progress", sda).(metrics.Gauge).Value(); got != 2 {
+		t.Errorf("system.disk.io_in_progress%s = %d, want 2", metrics.JoinTags(sda), got)
+	}
+	if m := r.GetT("system.disk.reads", map[string]string{"host": "a", "device": "sda1"}); m != nil {
+		t.Errorf("system.disk.reads for partition sda1 is registered")
+	}
+	if m := r.GetT("system.disk.reads", map[string]string{"host": "a", "device": "loop0"}); m != nil {
+		t.Errorf("system.disk.reads for loop0 is registered, but filtered")
+	}
+
+	eth0 := map[string]string{"host": "a", "iface": "eth0"}
+	ifaces := map[string]int64{
+		"system.net.rx_bytes":   1215360,
+		"system.net.rx_packets": 10305,
+		"system.net.rx_errs":    1,
+		"system.net.rx_drop":    2,
+		"system.net.tx_bytes":   717224,
+		"system.net.tx_packets": 6946,
+		"system.net.tx_errs":    3,
+		"system.net.tx_drop":    4,
+	}
+	for name, want := range ifaces {
+		if got, _ := r.GetT(name, eth0).(metrics.Rate).Values(); got != want {
+			t.Errorf("%s%s = %d, want %d", name, metrics.JoinTags(eth0), got, want)
+		}
+	}
+	if m := r.GetT("system.net.rx_bytes", map[string]string{"host": "a", "iface": "lo"}); m != nil {
+		t.Errorf("system.net.rx_bytes for lo is registered, but filtered")
+	}
+
+	// capture again with the same metrics
+	if err := c.Capture(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Unregister()
+	n := 0
+	r.Each(func(string, string, map[string]string, interface{}) error {
+		n++
+		return nil
+	}, false)
+	if n != 0 {
+		t.Errorf("%d metrics are registered after Unregister()", n)
+	}
+}
+
+func TestCollector_Rates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("stat", "cpu  100 0 200 1000\nctxt 1000\n")
+	writeFile("net/dev", "  eth0: 1000 10 0 0 0 0 0 0 2000 20 0 0 0 0 0 0\n")
+
+	r := metrics.NewRegistry()
+	c := New(r, Config{Proc: dir, Prefix: "host"})
+	if err := c.captureStat(1e9); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.captureNetDev(1e9); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile("stat", "cpu  300 0 300 1000\nctxt 3000\n")
+	writeFile("net/dev", "  eth0: 5000 10 0 0 0 0 0 0 2000 20 0 0 0 0 0 0\n")
+	if err := c.captureStat(3e9); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.captureNetDev(3e9); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, rate := r.Get("host.cpu.user_seconds").(metrics.FRate).Values(); rate != 1 {
+		t.Errorf("host.cpu.user_seconds rate = %f, want 1", rate)
+	}
+	if _, rate := r.Get("host.stat.ctxt").(metrics.Rate).Values(); rate != 1000 {
+		t.Errorf("host.stat.ctxt rate = %f, want 1000", rate)
+	}
+	if _, rate := r.GetT("host.net.rx_bytes", map[string]string{"iface": "eth0"}).(metrics.Rate).Values(); rate != 2000 {
+		t.Errorf("host.net.rx_bytes rate = %f, want 2000", rate)
+	}
+
+	// removed devices and interfaces
+	writeFile("diskstats", "   8       0 sda 1 0 2 3 4 0 5 6 0 7 8 0 0 0 0\n   8       1 sda1 1 0 2 3 4 0 5 6 0 7 8 0 0 0 0\n"+
+		"   8      16 sdb 1 0 2 3 4 0 5 6 0 7 8 0 0 0 0\n 259       0 nvme0n1 1 0 2 3 4 0 5 6 0 7 8 0 0 0 0\n"+
+		" 259       1 nvme0n1p1 1 0 2 3 4 0 5 6 0 7 8 0 0 0 0\n")
+	c.disks = []string{"sd", "nvme", "nvme0n1p1"}
+	if err := c.captureDiskStats(3e9); err != nil {
+		t.Fatal(err)
+	}
+	for device, want := range map[string]bool{"sda": true, "sda1": false, "sdb": true, "nvme0n1": true, "nvme0n1p1": true} {
+		if m := r.GetT("host.disk.reads", map[string]string{"device": device}); (m != nil) != want {
+			t.Errorf("host.disk.reads for %s registered = %v, want %v", device, m != nil, want)
+		}
+	}
+	writeFile("diskstats", "   8       0 sda 1 0 2 3 4 0 5 6 0 7 8 0 0 0 0\n")
+	writeFile("net/dev", "  eth1: 5000 10 0 0 0 0 0 0 2000 20 0 0 0 0 0 0\n")
+	if err := c.captureDiskStats(4e9); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.captureNetDev(4e9); err != nil {
+		t.Fatal(err)
+	}
+	if m := r.GetT("host.disk.io_in_progress", map[string]string{"device": "sdb"}); m != nil {
+		t.Error("host.disk.io_in_progress for removed sdb is registered")
+	}
+	if m := r.GetT("host.disk.reads", map[string]string{"device": "sda"}); m == nil {
+		t.Error("host.disk.reads for sda is not registered")
+	}
+	if m := r.GetT("host.net.rx_bytes", map[string]string{"iface": "eth0"}); m != nil {
+		t.Error("host.net.rx_bytes for removed eth0 is registered")
+	}
+	if m := r.GetT("host.net.rx_bytes", map[string]string{"iface": "eth1"}); m == nil {
+		t.Error("host.net.rx_bytes for eth1 is not registered")
+	}
+
+	// missing and invalid sources
+	if err := c.Capture(); !os.IsNotExist(err) {
+		t.Errorf("Capture() error = %v, want not exist", err)
+	}
+	writeFile("loadavg", "0.20 0.18\n")
+	if err := c.captureLoadAvg(4e9); err != ErrFormat {
+		t.Errorf("captureLoadAvg() error = %v, want %v", err, ErrFormat)
+	}
+}
+
+func TestCollector_Start(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := New(r, Config{Proc: "testdata/proc"})
+
+	if err := c.Start(context.Background(), 0); err != ErrInterval {
+		t.Errorf("Start() error = %v, want %v", err, ErrInterval)
+	}
+	if err := c.Start(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// first capture in background on start
+	for r.Get("system.load.1") == nil {
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.Start(context.Background(), time.Millisecond); err != ErrStarted {
+		t.Errorf("Start() error = %v, want %v", err, ErrStarted)
+	}
+	c.Stop()
+	c.Stop()
+
+	// restart after context cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.Start(ctx, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-c.runner.Done()
+	if err := c.Start(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	c.Unregister()
+	if m := r.Get("system.load.1"); m != nil {
+		t.Errorf("system.load.1 is registered after Unregister()")
+	}
+}
+
+func BenchmarkCollector(b *testing.B) {
+	c := New(metrics.NewRegistry(), Config{Proc: "testdata/proc"})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.Capture()
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrInvalidWindow = errors.New("window and slices must be positive and window must be at least slices nanoseconds")
+
+// window is a ring of sub-interval slices, rotated by wall clock.
+// Slice with number epoch covers [epoch * slice, (epoch + 1) * slice) nanoseconds since Unix epoch.
+// Not thread-safe, protected by owner lock.
+type window struct {
+	slice  int64   // slice duration in nanoseconds
+	epochs []int64 // slice number, stored in ring slot
+	now    func() int64
+}
+
+func checkWindow(d time.Duration, slices int) error {
+	if slices <= 0 || d < time.Duration(slices) {
+		return ErrInvalidWindow
+	}
+	return nil
+}
+
+func newWindow(d time.Duration, slices int) (window, error) {
+	if err := checkWindow(d, slices); err != nil {
+		return window{}, err
+	}
+	w := window{
+		slice:  int64(d) / int64(slices),
+		epochs: make([]int64, slices),
+		now:    func() int64 { return time.Now().UnixNano() },
+	}
+	w.reset()
+	return w, nil
+}
+
+// Window returns the aggregation window duration.
+func (w *window) Window() time.Duration {
+	return time.Duration(w.slice * int64(len(w.epochs)))
+}
+
+// rotate returns ring slot for timestamp and reports, if slot holds outdated slice and must be reset
+func (w *window) rotate(ts int64) (int, bool) {
+	epoch := ts / w.slice
+	i := int(epoch % int64(len(w.epochs)))
+	if i < 0 {
+		i += len(w.epochs)
+	}
+	if w.epochs[i] >= epoch {
+		// current slice (or wall clock stepped backward, so store in newer slice)
+		return i, false
+	}
+	w.epochs[i] = epoch
+	return i, true
+}
+
+// live reports, if ring slot is inside window, ended at timestamp
+func (w *window) live(i int, ts int64) bool {
+	epoch := ts / w.slice
+	return w.epochs[i] > epoch-int64(len(w.epochs)) && w.epochs[i] <= epoch
+}
+
+func (w *window) reset() {
+	for i := range w.epochs {
+		w.epochs[i] = math.MinInt64
+	}
+}